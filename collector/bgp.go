@@ -1,8 +1,9 @@
 package collector
 
 import (
-	"encoding/json"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -19,6 +20,8 @@ var (
 	bgpRIBLabels      = []string{"routing_instance"}
 	bgpPeerRIBLabels  = append(bgpPeerLabels, bgpRIBLabels...)
 	bgpPeerTypeLabels = []string{"type"}
+	bgpPeerInfoLabels = append(append([]string{}, bgpPeerRIBLabels...), "description", "peer_as", "local_address", "group")
+	bgpTableLabels    = []string{"peer", "table"}
 	bgpDesc           = map[string]*prometheus.Desc{
 		"GroupCount":                       colPromDesc(bgpSubsystem, "groups", "Number of Configured Groups.", bgpRIBLabels),
 		"PeerCount":                        colPromDesc(bgpSubsystem, "peers", "Number of Configured Peers.", bgpRIBLabels),
@@ -51,8 +54,10 @@ var (
 		"RIBSuppressedInternalPrefixCount": colPromDesc(bgpSubsystem, "rib_suppressed_internal_prefixes", "Number of Suppressed Internal Prefixes in the RIB.", bgpRIBLabels),
 		"RIBPendingPrefixCount":            colPromDesc(bgpSubsystem, "rib_pending_prefixes", "Number of Pending Prefixes in the RIB.", bgpRIBLabels),
 		"PeerTypesUp":                      colPromDesc(bgpSubsystem, "peer_types_up", "Total Number of Peer Types that are Up.", bgpPeerTypeLabels),
+		"PeerInfo":                         colPromDesc(bgpSubsystem, "peer_info", "Description, AS, Local Address and Group of the Peer.", bgpPeerInfoLabels),
+		"PeerAdvertisedRoutes":             colPromDesc(bgpSubsystem, "peer_advertised_routes", "Number of Routes Advertised to the Monitored Peer per Table.", bgpTableLabels),
+		"PeerReceivedRoutes":               colPromDesc(bgpSubsystem, "peer_received_routes", "Number of Routes Received from the Monitored Peer per Table.", bgpTableLabels),
 	}
-	totalBGPErrors = 0.0
 )
 
 // BGPCollector collects BGP metrics, implemented as per the Collector interface.
@@ -70,66 +75,85 @@ func (*BGPCollector) Name() string {
 	return bgpSubsystem
 }
 
+// RPCs executed by the collector.
+func (*BGPCollector) RPCs() []string {
+	return []string{"get-bgp-summary-information", "get-bgp-neighbor-information", "get-instance-information", "get-route-information"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *BGPCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *BGPCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
-	if err != nil {
-		totalBGPErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalBGPErrors
-	}
-	defer s.Close()
-
-	// show bgp summary | display xml
-	reply, err := s.Exec(netconf.RawMethod(`<get-bgp-summary-information/>`))
-	if err != nil {
-		totalBGPErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalBGPErrors
+	// show bgp summary instance all | display xml
+	summary := `<get-bgp-summary-information><instance>all</instance></get-bgp-summary-information>`
+	// show bgp neighbor instance all | display xml
+	neighbor := `<get-bgp-neighbor-information><instance>all</instance></get-bgp-neighbor-information>`
+	if conf.DisableBGPInstances {
+		summary, neighbor = `<get-bgp-summary-information/>`, `<get-bgp-neighbor-information/>`
 	}
-
-	// show bgp neighbor | display xml
-	replyNeighbor, err := s.Exec(netconf.RawMethod(`<get-bgp-neighbor-information/>`))
-	if err != nil {
-		totalBGPErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalBGPErrors
+	replies, errs := execAll(ctx, conf,
+		netconf.RawMethod(summary),
+		netconf.RawMethod(neighbor),
+		// show route instance | display xml
+		netconf.RawMethod(`<get-instance-information/>`),
+	)
+	// Releases not supporting instance all fail with an rpc-error, the RPCs being retried without it. The summary of
+	// each routing instance is then requested separately, as instance all is not available to list them at once.
+	summaryFallback := false
+	for i, method := range []string{`<get-bgp-summary-information/>`, `<get-bgp-neighbor-information/>`} {
+		if isRPCError(errs[i]) && !conf.DisableBGPInstances {
+			summaryFallback = summaryFallback || i == 0
+			replies[i], errs[i] = conf.Session.Exec(ctx, netconf.RawMethod(method))
+		}
 	}
-
-	// show route instance | display xml
-	replyRouteInstance, err := s.Exec(netconf.RawMethod(`<get-instance-information/>`))
-	if err != nil {
-		totalBGPErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalBGPErrors
+	// The metrics of the RPCs that succeeded are still exported when others failed, leaving their replies nil.
+	for _, err := range errs {
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		}
 	}
+	reply, replyNeighbor, replyRouteInstance := replies[0], replies[1], replies[2]
 
-	bgpPeerInterfaces, err := getBgpPeerInterface(replyNeighbor)
-	if err != nil {
-		totalBGPErrors++
-		errors = append(errors, err)
+	bgpPeerInterfaces := map[string]string{}
+	if replyNeighbor != nil {
+		var err error
+		if bgpPeerInterfaces, err = getBgpPeerInterface(replyNeighbor); err != nil {
+			errors = append(errors, err)
+		}
 	}
 
-	routeInstances, routeInstanceNames, err := getInstanceNameToRibName(replyRouteInstance)
-	if err != nil {
-		totalBGPErrors++
-		errors = append(errors, err)
+	routeInstances, routeInstanceNames := map[string]string{}, map[string]string{}
+	if replyRouteInstance != nil {
+		var err error
+		if routeInstances, routeInstanceNames, err = getInstanceNameToRibName(replyRouteInstance); err != nil {
+			errors = append(errors, err)
+		}
 	}
 
-	replyBgpSummaryInstance := make(map[string]*netconf.RPCReply)
-	for routeInstance := range routeInstanceNames {
-		// filter out default routing instances that are reserved so we dont pull un-necessary RPC calls
-		if !strings.Contains(routeInstance, "__master") && !strings.Contains(routeInstance, "__juniper") && !strings.Contains(routeInstance, "mgmt_junos") {
-			routeInstanceCommand := fmt.Sprintf(`<get-bgp-summary-information><instance>%s</instance></get-bgp-summary-information>`, routeInstance)
-			replyBgpSummaryVrf, err := s.Exec(netconf.RawMethod(routeInstanceCommand))
-			replyBgpSummaryInstance[routeInstance] = replyBgpSummaryVrf
-			if err != nil {
-				totalBGPErrors++
-				errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-				return errors, totalBGPErrors
+	// Summaries per routing instance, only requested when instance all is not supported.
+	var replyBgpSummaryInstance map[string]*netconf.RPCReply
+	if summaryFallback {
+		var instances []string
+		var instanceMethods []netconf.RPCMethod
+		for routeInstance := range routeInstanceNames {
+			if !bgpInstanceEnabled(routeInstance, conf.BGPInstances) {
+				continue
+			}
+			// filter out default routing instances that are reserved so we dont pull un-necessary RPC calls
+			if !strings.Contains(routeInstance, "__master") && !strings.Contains(routeInstance, "__juniper") && !strings.Contains(routeInstance, "mgmt_junos") {
+				routeInstanceCommand := fmt.Sprintf(`<get-bgp-summary-information><instance>%s</instance></get-bgp-summary-information>`, routeInstance)
+				instances = append(instances, routeInstance)
+				instanceMethods = append(instanceMethods, netconf.RawMethod(routeInstanceCommand))
 			}
 		}
+		replyBgpSummaryInstance = make(map[string]*netconf.RPCReply)
+		replies, errs = execAll(ctx, conf, instanceMethods...)
+		for i, routeInstance := range instances {
+			if errs[i] != nil {
+				errors = append(errors, fmt.Errorf("could not execute netconf RPC call for routing instance %q: %w", routeInstance, errs[i]))
+				continue
+			}
+			replyBgpSummaryInstance[routeInstance] = replies[i]
+		}
 	}
 
 	if err := processBGPNetconfReply(
@@ -138,20 +162,72 @@ func (c *BGPCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, f
 		replyBgpSummaryInstance,
 		ch,
 		conf.BGPTypeKeys,
+		conf.BGPInstances,
 		bgpPeerInterfaces,
 		routeInstances,
-		routeInstanceNames,
 		c.logger,
 	); err != nil {
-		totalBGPErrors++
 		errors = append(errors, err)
 	}
 
-	if err := processBGPNeighborNetconfReply(replyNeighbor, ch, c.logger); err != nil {
-		totalBGPErrors++
-		errors = append(errors, err)
+	if replyNeighbor != nil {
+		if err := processBGPNeighborNetconfReply(replyNeighbor, ch, c.logger); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	var peerMethods []netconf.RPCMethod
+	for _, peer := range conf.BGPMonitoredPeers {
+		var escaped strings.Builder
+		xml.EscapeText(&escaped, []byte(peer))
+		peerMethods = append(peerMethods,
+			// show route advertising-protocol bgp <peer> | display xml
+			netconf.RawMethod(fmt.Sprintf(`<get-route-information><advertising-protocol-name>bgp</advertising-protocol-name><neighbor>%s</neighbor></get-route-information>`, escaped.String())),
+			// show route receive-protocol bgp <peer> | display xml
+			netconf.RawMethod(fmt.Sprintf(`<get-route-information><receive-protocol-name>bgp</receive-protocol-name><peer>%s</peer></get-route-information>`, escaped.String())),
+		)
+	}
+	replies, errs = execAll(ctx, conf, peerMethods...)
+	for i, peer := range conf.BGPMonitoredPeers {
+		for j, desc := range []*prometheus.Desc{bgpDesc["PeerAdvertisedRoutes"], bgpDesc["PeerReceivedRoutes"]} {
+			if errs[2*i+j] != nil {
+				errors = append(errors, fmt.Errorf("could not execute netconf RPC call for peer %q: %w", peer, errs[2*i+j]))
+				continue
+			}
+			if err := processBGPPeerRoutesNetconfReply(replies[2*i+j], ch, desc, peer); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+	return errors
+}
+
+// processBGPPeerRoutesNetconfReply exports the number of routes of each table of the routes advertised to or received
+// from peer.
+func processBGPPeerRoutesNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, desc *prometheus.Desc, peer string) error {
+	return decodeElements(reply, "route-table", func(table *bgpPeerRouteTable) error {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(len(table.Rt)), peer, strings.TrimSpace(table.TableName))
+		return nil
+	})
+}
+
+// GetGNMI gets metrics from the OpenConfig BGP model and sends to the Prometheus.Metric channel.
+func (c *BGPCollector) GetGNMI(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+
+	leaves, err := conf.GNMI.Get(ctx, conf.SSHTarget, "/network-instances/network-instance/protocols/protocol/bgp/neighbors")
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute gnmi get: %w", err))
+		return errors
 	}
-	return errors, totalBGPErrors
+	processBGPGNMILeaves(leaves, ch, c.logger)
+	return errors
+}
+
+// isRPCError returns whether err is, or wraps, a NETCONF rpc-error.
+func isRPCError(err error) bool {
+	var rpcErr *netconf.RPCError
+	return errors.As(err, &rpcErr)
 }
 
 func getInstanceNameToRibName(reply *netconf.RPCReply) (map[string]string, map[string]string, error) {
@@ -224,31 +300,84 @@ func processBGPNeighborNetconfReply(reply *netconf.RPCReply, ch chan<- prometheu
 	return nil
 }
 
-func processBGPNetconfReply(
-	reply *netconf.RPCReply,
-	replyNeighbor *netconf.RPCReply,
-	replyBgpSummaryInstance map[string]*netconf.RPCReply,
-	ch chan<- prometheus.Metric,
-	bgpTypeKeys []string,
-	bgpPeerInterfaces map[string]string,
-	routeInstances map[string]string,
-	routeInstanceNames map[string]string,
-	logger log.Logger,
-) error {
-
-	var netconfReply bgpNeighborRPCReply
-	var netconfInfoReply bgpRPCReply
-	routeInstanceCheck := make(map[string]string)
+// bgpInstanceEnabled returns whether the BGP summary of routeInstance is collected, which is the case for all instances
+// when instances is empty.
+func bgpInstanceEnabled(routeInstance string, instances []string) bool {
+	if len(instances) == 0 {
+		return true
+	}
+	for _, instance := range instances {
+		if instance == routeInstance {
+			return true
+		}
+	}
+	return false
+}
 
-	if err := xml.Unmarshal([]byte(replyNeighbor.RawReply), &netconfReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+// processBGPInstanceCounts exports the group, peer and down peer counts and the RIB totals of each routing instance
+// from the neighbors and summary of all instances. The groups, peers and down peers of each routing instance are
+// counted from the peers of all instances, of which the instance is the peer-cfg-rti of the peer, and are not exported
+// when the neighbor RPC failed. The RIB totals of each routing instance are those of the first RIB of the instance
+// listed by the summary.
+func processBGPInstanceCounts(netconfReply bgpNeighborRPCReply, netconfInfoReply bgpRPCReply, ch chan<- prometheus.Metric, bgpInstances []string, routeInstances map[string]string, logger log.Logger) {
+	type instanceCounts struct {
+		groups map[string]bool
+		peers  float64
+		down   float64
+	}
+	var instanceOrder []string
+	counts := map[string]*instanceCounts{}
+	for _, peerData := range netconfReply.BgpInformation.BgpPeer {
+		routeInstance := strings.TrimSpace(peerData.PeerCfgRti.Text)
+		if !bgpInstanceEnabled(routeInstance, bgpInstances) {
+			continue
+		}
+		c, ok := counts[routeInstance]
+		if !ok {
+			c = &instanceCounts{groups: map[string]bool{}}
+			counts[routeInstance] = c
+			instanceOrder = append(instanceOrder, routeInstance)
+		}
+		c.groups[strings.TrimSpace(peerData.PeerGroup.Text)] = true
+		c.peers++
+		if !strings.EqualFold(strings.TrimSpace(peerData.PeerState.Text), "established") {
+			c.down++
+		}
+	}
+	for _, routeInstance := range instanceOrder {
+		c := counts[routeInstance]
+		ch <- prometheus.MustNewConstMetric(bgpDesc["GroupCount"], prometheus.GaugeValue, float64(len(c.groups)), routeInstance)
+		ch <- prometheus.MustNewConstMetric(bgpDesc["PeerCount"], prometheus.GaugeValue, c.peers, routeInstance)
+		ch <- prometheus.MustNewConstMetric(bgpDesc["DownPeerCount"], prometheus.GaugeValue, c.down, routeInstance)
 	}
 
-	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfInfoReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	ribInstances := map[string]bool{}
+	for _, ribData := range netconfInfoReply.BGPInformation.BGPRIB {
+		routeInstance, ok := routeInstances[strings.TrimSpace(ribData.Name.Text)]
+		if !ok || ribInstances[routeInstance] || !bgpInstanceEnabled(routeInstance, bgpInstances) {
+			continue
+		}
+		ribInstances[routeInstance] = true
+		ribLabels := []string{routeInstance}
+		newGauge(logger, ch, bgpDesc["RIBTotalPrefixCount"], ribData.TotalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBHistoryPrefixCount"], ribData.HistoryPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBDampedPrefixCount"], ribData.DampedPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBTotalExternalPrefixCount"], ribData.TotalExternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBActiveExternalPrefixCount"], ribData.ActiveExternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBAcceptedExternalPrefixCount"], ribData.AcceptedExternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBSuppressedExternalPrefixCount"], ribData.SuppressedExternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBTotalInternalPrefixCount"], ribData.TotalInternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBActiveInternalPrefixCount"], ribData.ActiveInternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBAcceptedInternalPrefixCount"], ribData.AcceptedInternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBSuppressedInternalPrefixCount"], ribData.SuppressedInternalPrefixCount.Text, ribLabels...)
+		newGauge(logger, ch, bgpDesc["RIBPendingPrefixCount"], ribData.PendingPrefixCount.Text, ribLabels...)
 	}
+}
 
-	// loop through route instances, unmarshall, only gather RIB totals once per route instance
+// processBGPSummaryInstances exports the group, peer and down peer counts reported by the summary of each routing
+// instance, along with the RIB totals of the instance, only gathered once per instance.
+func processBGPSummaryInstances(replyBgpSummaryInstance map[string]*netconf.RPCReply, ch chan<- prometheus.Metric, routeInstances map[string]string, logger log.Logger) error {
+	routeInstanceCheck := make(map[string]string)
 	for routeInstanceBGP := range replyBgpSummaryInstance {
 		var netconfReplyInstance bgpRPCReplyInstance
 		if err := xml.Unmarshal([]byte(replyBgpSummaryInstance[routeInstanceBGP].RawReply), &netconfReplyInstance); err != nil {
@@ -282,8 +411,48 @@ func processBGPNetconfReply(
 			}
 		}
 	}
+	return nil
+}
+
+func processBGPNetconfReply(
+	reply *netconf.RPCReply,
+	replyNeighbor *netconf.RPCReply,
+	replyBgpSummaryInstance map[string]*netconf.RPCReply,
+	ch chan<- prometheus.Metric,
+	bgpTypeKeys []string,
+	bgpInstances []string,
+	bgpPeerInterfaces map[string]string,
+	routeInstances map[string]string,
+	logger log.Logger,
+) error {
+
+	var netconfReply bgpNeighborRPCReply
+	var netconfInfoReply bgpRPCReply
+
+	// Either reply is nil when its RPC failed.
+	if replyNeighbor != nil {
+		if err := xml.Unmarshal([]byte(replyNeighbor.RawReply), &netconfReply); err != nil {
+			return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+		}
+	}
+
+	if reply != nil {
+		if err := xml.Unmarshal([]byte(reply.RawReply), &netconfInfoReply); err != nil {
+			return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+		}
+	}
+
+	if replyBgpSummaryInstance != nil {
+		if err := processBGPSummaryInstances(replyBgpSummaryInstance, ch, routeInstances, logger); err != nil {
+			return err
+		}
+	} else {
+		processBGPInstanceCounts(netconfReply, netconfInfoReply, ch, bgpInstances, routeInstances, logger)
+	}
 
 	peerTypes := make(map[string]float64)
+	// Addresses of the peers of the neighbor output, of which the peers missing are exported from the summary.
+	neighborPeers := map[string]bool{}
 
 	for _, peerData := range netconfReply.BgpInformation.BgpPeer {
 		peerInterfaceLocal := ""
@@ -303,13 +472,15 @@ func processBGPNetconfReply(
 		if val, exists := bgpPeerInterfaces[peerAddress]; exists {
 			peerInterfaceLocal = val
 		}
+		neighborPeers[strings.TrimSpace(peerAddress)] = true
 
 		peerLabels := []string{strings.TrimSpace(peerAddress), strings.TrimSpace(peerInterfaceLocal), strings.TrimSpace(peerAddressFamily)}
-		var peerType map[string]string
+		peerType := map[string]string{}
 
 		if len(bgpTypeKeys) > 0 && peerData.Description.Text != "" {
-			if err := json.Unmarshal([]byte(peerData.Description.Text), &peerType); err == nil {
+			if descrKeys := parseDescrJSON(peerData.Description.Text); descrKeys != nil {
 				for _, descKey := range bgpTypeKeys {
+					peerType[descKey], _ = descrValue(descrKeys, descKey)
 					if peerType[descKey] != "" {
 						if _, exist := peerTypes[strings.TrimSpace(peerType[descKey])]; !exist {
 							peerTypes[strings.TrimSpace(peerType[descKey])] = 0
@@ -332,6 +503,22 @@ func processBGPNetconfReply(
 			ch <- prometheus.MustNewConstMetric(bgpDesc["PeerPeerState"], prometheus.GaugeValue, 0.0, peerRIBLabels...)
 		}
 
+		// Junos 19 lists the AS and local address of the peer in <bgp-peer-header>.
+		peerAs := peerData.PeerAs.Text
+		if peerAs == "" {
+			peerAs = peerData.BGPPeerHeader.PeerAs
+		}
+		localAddress := peerData.LocalAddress
+		if localAddress == "" {
+			localAddress = peerData.BGPPeerHeader.LocalAddress
+		}
+		ch <- prometheus.MustNewConstMetric(bgpDesc["PeerInfo"], prometheus.GaugeValue, 1.0, append(peerRIBLabels,
+			strings.TrimSpace(peerData.Description.Text),
+			strings.TrimSpace(peerAs),
+			strings.TrimSpace(strings.Split(localAddress, "+")[0]),
+			strings.TrimSpace(peerData.PeerGroup.Text),
+		)...)
+
 		newCounter(logger, ch, bgpDesc["PeerInputMessages"], peerData.InputMessages.Text, peerRIBLabels...)
 		newCounter(logger, ch, bgpDesc["PeerOutputMessages"], peerData.OutputMessages.Text, peerRIBLabels...)
 		newGauge(logger, ch, bgpDesc["PeerRouteQueueCount"], peerData.RouteQueueCount.Text, peerRIBLabels...)
@@ -343,6 +530,36 @@ func processBGPNetconfReply(
 		newGauge(logger, ch, bgpDesc["PeerRIBSuppressedPrefixCount"], peerData.BGPRIB.SuppressedPrefixCount, peerRIBLabels...)
 	}
 
+	// Peers that never reached Established may be missing from the neighbor output, while the summary lists every
+	// configured peer. Their routing instance is that of the RIBs of the peer when listed, and empty otherwise.
+	for _, peerData := range netconfInfoReply.BGPInformation.BGPPeer {
+		peerAddress := strings.TrimSpace(strings.Split(peerData.PeerAddress.Text, "+")[0])
+		if peerAddress == "" || neighborPeers[peerAddress] {
+			continue
+		}
+		neighborPeers[peerAddress] = true
+		routeInstance := ""
+		for _, ribData := range peerData.BGPRIB {
+			if instance, ok := routeInstances[strings.TrimSpace(ribData.Name.Text)]; ok {
+				routeInstance = instance
+				break
+			}
+		}
+		peerRIBLabels := []string{peerAddress, strings.TrimSpace(bgpPeerInterfaces[peerAddress]), "", routeInstance}
+		peerUp := 0.0
+		if strings.EqualFold(strings.TrimSpace(peerData.PeerState.Text), "established") {
+			peerUp = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(bgpDesc["PeerPeerState"], prometheus.GaugeValue, peerUp, peerRIBLabels...)
+		ch <- prometheus.MustNewConstMetric(bgpDesc["PeerInfo"], prometheus.GaugeValue, 1.0, append(peerRIBLabels,
+			strings.TrimSpace(peerData.Description.Text),
+			strings.TrimSpace(peerData.PeerAs.Text),
+			"",
+			"",
+		)...)
+		newCounter(logger, ch, bgpDesc["PeerFlapCount"], peerData.FlapCount.Text, peerRIBLabels...)
+	}
+
 	for peerType, count := range peerTypes {
 		ch <- prometheus.MustNewConstMetric(bgpDesc["PeerTypesUp"], prometheus.GaugeValue, count, peerType)
 	}
@@ -350,6 +567,43 @@ func processBGPNetconfReply(
 	return nil
 }
 
+// bgpGNMIPrefixes maps the OpenConfig per AFI/SAFI prefix counters to the BGP peer metrics.
+var bgpGNMIPrefixes = map[string]string{
+	"received":  "PeerRIBReceivedPrefixCount",
+	"installed": "PeerRIBActivePrefixCount",
+	"sent":      "PeerRIBAdvertisedPrefixCount",
+}
+
+func processBGPGNMILeaves(leaves []gnmiLeaf, ch chan<- prometheus.Metric, logger log.Logger) {
+	for _, leaf := range leaves {
+		peer, ok := leaf.key("neighbor", "neighbor-address")
+		if !ok {
+			continue
+		}
+		routingInstance, _ := leaf.key("network-instance", "name")
+		if afiSafi, ok := leaf.key("afi-safi", "afi-safi-name"); ok {
+			if descName, ok := bgpGNMIPrefixes[leaf.name()]; ok && leaf.parent() == "prefixes" {
+				newGauge(logger, ch, bgpDesc[descName], leaf.value, peer, "", afiSafi, routingInstance)
+			}
+			continue
+		}
+		if leaf.parent() == "state" && leaf.name() == "session-state" {
+			if leaf.value == "ESTABLISHED" {
+				ch <- prometheus.MustNewConstMetric(bgpDesc["PeerPeerState"], prometheus.GaugeValue, 1.0, peer, "", "", routingInstance)
+			} else {
+				ch <- prometheus.MustNewConstMetric(bgpDesc["PeerPeerState"], prometheus.GaugeValue, 0.0, peer, "", "", routingInstance)
+			}
+		}
+	}
+}
+
+// ********************* show route advertising-protocol START ********************* //
+type bgpPeerRouteTable struct {
+	TableName string `xml:"table-name"`
+	// Only the number of routes is needed.
+	Rt []struct{} `xml:"rt"`
+}
+
 // ********************* show route instance START ********************* //
 type routeInstanceRPCReply struct {
 	XMLName             xml.Name                 `xml:"rpc-reply"`
@@ -361,9 +615,15 @@ type routeInstanceInformation struct {
 }
 
 type routeInstanceCore struct {
-	InstanceName string             `xml:"instance-name"`
-	InstanceType string             `xml:"instance-type"`
-	InstanceRib  []routeInstanceRib `xml:"instance-rib"`
+	InstanceName      string                   `xml:"instance-name"`
+	InstanceType      string                   `xml:"instance-type"`
+	InstanceRib       []routeInstanceRib       `xml:"instance-rib"`
+	InstanceInterface []routeInstanceInterface `xml:"instance-interface"`
+}
+
+// Only in the detail output.
+type routeInstanceInterface struct {
+	InterfaceName string `xml:"interface-name"`
 }
 
 type routeInstanceRib struct {
@@ -458,6 +718,8 @@ type bgpNeighborPeer struct {
 	PeerAddress        string        `xml:"peer-address"`
 	BGPPeerHeader      bgpPeerHeader `xml:"bgp-peer-header"`
 	LocalInterfaceName string        `xml:"local-interface-name"`
+	LocalAddress       string        `xml:"local-address"`
+	PeerGroup          bgpText       `xml:"peer-group"`
 	PeerAs             bgpText       `xml:"peer-as"`
 	InputMessages      bgpText       `xml:"input-messages"`
 	OutputMessages     bgpText       `xml:"output-messages"`
@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBGPCollectorInstanceCounts(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{
+			// Counted from the peers of all instances by their peer-cfg-rti.
+			name: "instance all",
+			dir:  "bgp",
+			want: `
+# HELP junos_bgp_down_peers Number of Peers that are Down.
+# TYPE junos_bgp_down_peers gauge
+junos_bgp_down_peers{routing_instance="VRF1"} 0
+junos_bgp_down_peers{routing_instance="master"} 1
+# HELP junos_bgp_groups Number of Configured Groups.
+# TYPE junos_bgp_groups gauge
+junos_bgp_groups{routing_instance="VRF1"} 1
+junos_bgp_groups{routing_instance="master"} 1
+# HELP junos_bgp_peers Number of Configured Peers.
+# TYPE junos_bgp_peers gauge
+junos_bgp_peers{routing_instance="VRF1"} 1
+junos_bgp_peers{routing_instance="master"} 2
+# HELP junos_bgp_rib_total_prefixes Total Number of Prefixes in the RIB.
+# TYPE junos_bgp_rib_total_prefixes gauge
+junos_bgp_rib_total_prefixes{routing_instance="VRF1"} 20
+junos_bgp_rib_total_prefixes{routing_instance="master"} 100
+# HELP junos_collector_up Whether the collector's last scrape was successful (1 = successful, 0 = unsuccessful).
+# TYPE junos_collector_up gauge
+junos_collector_up{collector="bgp"} 1
+`,
+		},
+		{
+			// instance all is rejected with an rpc-error, the counts being those of the summary of each instance.
+			name: "per-instance summaries",
+			dir:  "bgp-legacy",
+			want: `
+# HELP junos_bgp_down_peers Number of Peers that are Down.
+# TYPE junos_bgp_down_peers gauge
+junos_bgp_down_peers{routing_instance="VRF1"} 0
+junos_bgp_down_peers{routing_instance="master"} 1
+# HELP junos_bgp_groups Number of Configured Groups.
+# TYPE junos_bgp_groups gauge
+junos_bgp_groups{routing_instance="VRF1"} 1
+junos_bgp_groups{routing_instance="master"} 2
+# HELP junos_bgp_peers Number of Configured Peers.
+# TYPE junos_bgp_peers gauge
+junos_bgp_peers{routing_instance="VRF1"} 1
+junos_bgp_peers{routing_instance="master"} 3
+# HELP junos_bgp_rib_total_prefixes Total Number of Prefixes in the RIB.
+# TYPE junos_bgp_rib_total_prefixes gauge
+junos_bgp_rib_total_prefixes{routing_instance="VRF1"} 20
+junos_bgp_rib_total_prefixes{routing_instance="master"} 100
+# HELP junos_collector_up Whether the collector's last scrape was successful (1 = successful, 0 = unsuccessful).
+# TYPE junos_collector_up gauge
+junos_collector_up{collector="bgp"} 1
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(replayExporter(t, tc.dir, Config{}, NewBGPCollector(log.NewNopLogger())))
+			err := testutil.GatherAndCompare(registry, strings.NewReader(tc.want),
+				"junos_bgp_groups", "junos_bgp_peers", "junos_bgp_down_peers", "junos_bgp_rib_total_prefixes", "junos_collector_up")
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestBGPCollectorPeers(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(replayExporter(t, "bgp", Config{}, NewBGPCollector(log.NewNopLogger())))
+	want := `
+# HELP junos_bgp_peer_up State of the Peer. (1 = Established, 0 = Down).
+# TYPE junos_bgp_peer_up gauge
+junos_bgp_peer_up{interface="",peer="10.0.0.1",peer_address_family="",routing_instance="VRF1"} 1
+junos_bgp_peer_up{interface="",peer="192.0.2.1",peer_address_family="",routing_instance="master"} 1
+junos_bgp_peer_up{interface="",peer="192.0.2.3",peer_address_family="",routing_instance="master"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "junos_bgp_peer_up"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	captureDesc = promDesc("parse_capture_info", "Capture of the RPC replies of the last scrape of a collector that failed to parse, with the path of the capture in the file label.", []string{"collector", "file"})
+
+	// Values of elements whose name contains any of the words are redacted from captured replies.
+	captureRedactRegexp = regexp.MustCompile(`(<[\w:-]*(?:password|secret|key|community|psk)[\w:-]*(?:\s[^>]*)?>)[^<]*`)
+	// Characters of targets replaced in the file names of captures.
+	captureFileRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+	capturesMu sync.Mutex
+	// Path of the last capture (value) per target and collector (key).
+	captures = map[string]map[string]string{}
+)
+
+// captureReplies writes the RPC replies recorded by capture for the collector to a file in dir, when the collector
+// failed to parse a reply. Replies longer than maxBytes are truncated. The previous capture of the target and
+// collector is removed, keeping one capture per target and collector.
+func captureReplies(dir string, maxBytes int, target string, collectorName string, capture *RPCCapture, errs []error, logger log.Logger) {
+	parseErr := false
+	for _, err := range errs {
+		if errorReason(err, false) == "parse_error" {
+			parseErr = true
+			break
+		}
+	}
+	if !parseErr {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- target: %s, collector: %s, time: %s -->\n", target, collectorName, time.Now().UTC().Format(time.RFC3339))
+	for _, err := range errs {
+		fmt.Fprintf(&b, "<!-- error: %s -->\n", strings.ReplaceAll(err.Error(), "--", "- -"))
+	}
+	for _, rpc := range capture.RPCs() {
+		if rpc.Collector != collectorName {
+			continue
+		}
+		reply := captureRedactRegexp.ReplaceAllString(rpc.Reply, "${1}[redacted]")
+		if maxBytes > 0 && len(reply) > maxBytes {
+			reply = reply[:maxBytes] + fmt.Sprintf("\n<!-- truncated %d bytes -->", len(reply)-maxBytes)
+		}
+		fmt.Fprintf(&b, "<!-- rpc: %s -->\n%s\n", strings.ReplaceAll(rpc.RPC, "--", "- -"), reply)
+	}
+
+	name := fmt.Sprintf("%s_%s_%d.xml", captureFileRegexp.ReplaceAllString(target, "_"), collectorName, time.Now().Unix())
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		level.Error(logger).Log("msg", "could not write capture of rpc replies", "path", path, "err", err)
+		return
+	}
+	level.Info(logger).Log("msg", "captured rpc replies that could not be parsed", "collector", collectorName, "path", path)
+
+	capturesMu.Lock()
+	defer capturesMu.Unlock()
+	if captures[target] == nil {
+		captures[target] = map[string]string{}
+	}
+	if previous, ok := captures[target][collectorName]; ok && previous != path {
+		os.Remove(previous)
+	}
+	captures[target][collectorName] = path
+}
+
+// collectCaptures sends the info metrics of the last captures of the target.
+func collectCaptures(target string, ch chan<- prometheus.Metric) {
+	capturesMu.Lock()
+	defer capturesMu.Unlock()
+	for collectorName, path := range captures[target] {
+		ch <- prometheus.MustNewConstMetric(captureDesc, prometheus.GaugeValue, 1, collectorName, path)
+	}
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clockSubsystem = "clock"
+
+	clockDesc = map[string]*prometheus.Desc{
+		"DeviceTime": colPromDesc(clockSubsystem, "device_time_seconds", "Current time of the device in seconds since the epoch.", nil),
+		"Offset":     colPromDesc(clockSubsystem, "offset_seconds", "Difference between the clock of the device and the clock of the exporter, positive when the device is ahead.", nil),
+	}
+)
+
+// ClockCollector collects the current time of the device on each scrape, implemented as per the Collector interface.
+type ClockCollector struct {
+	logger log.Logger
+}
+
+// NewClockCollector returns a new ClockCollector.
+func NewClockCollector(logger log.Logger) *ClockCollector {
+	return &ClockCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*ClockCollector) Name() string {
+	return clockSubsystem
+}
+
+// RPCs executed by the collector.
+func (*ClockCollector) RPCs() []string {
+	return []string{"get-system-uptime-information"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *ClockCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	// show system uptime | display xml
+	device, local, err := deviceTime(ctx, conf.Session)
+	if err != nil {
+		errors = append(errors, err)
+		return errors
+	}
+	ch <- prometheus.MustNewConstMetric(clockDesc["DeviceTime"], prometheus.GaugeValue, float64(device.Unix()))
+	ch <- prometheus.MustNewConstMetric(clockDesc["Offset"], prometheus.GaugeValue, device.Sub(local).Seconds())
+	return errors
+}
@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	multiREItemRegexp = regexp.MustCompile(`(?s)<multi-routing-engine-item>.*?</multi-routing-engine-item>`)
+	reNameRegexp      = regexp.MustCompile(`(?s)<re-name>(.*?)</re-name>`)
+)
+
+// clusterNodeKey is the context key of the clusterPass of the node of a chassis cluster collected by a collector.
+type clusterNodeKey struct{}
+
+// clusterPass runs a collector once for each node of a chassis cluster, each run seeing only the replies of its node.
+// The replies are kept across the runs of the collector, so that each RPC is executed once per scrape.
+type clusterPass struct {
+	nodes []string
+	node  string
+
+	mu      sync.Mutex
+	replies map[string]clusterReply
+}
+
+type clusterReply struct {
+	reply *netconf.RPCReply
+	err   error
+}
+
+// multiREItemName returns the re-name of a multi-routing-engine-item, such as node0.
+func multiREItemName(item string) string {
+	if match := reNameRegexp.FindStringSubmatch(item); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return ""
+}
+
+// exec returns the reply to methods executed using exec, executing them on the first run of the collector only.
+func (p *clusterPass) exec(methods []netconf.RPCMethod, exec func() (*netconf.RPCReply, error)) (*netconf.RPCReply, error) {
+	var key strings.Builder
+	for _, method := range methods {
+		key.WriteString(method.MarshalMethod())
+	}
+	p.mu.Lock()
+	r, ok := p.replies[key.String()]
+	p.mu.Unlock()
+	if !ok {
+		r.reply, r.err = exec()
+		p.mu.Lock()
+		p.replies[key.String()] = r
+		p.mu.Unlock()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return p.nodeReply(r.reply), nil
+}
+
+// nodeReply returns reply keeping only the multi-routing-engine-item of the node of the run. Replies that are not
+// split by node are seen by the run of the first node only, the others seeing an empty reply.
+func (p *clusterPass) nodeReply(reply *netconf.RPCReply) *netconf.RPCReply {
+	nodeReply := *reply
+	if !multiREItemRegexp.MatchString(reply.RawReply) {
+		if p.node != p.nodes[0] {
+			nodeReply.RawReply, nodeReply.Data = "<rpc-reply></rpc-reply>", ""
+		}
+		return &nodeReply
+	}
+	filter := func(item string) string {
+		if multiREItemName(item) == p.node {
+			return item
+		}
+		return ""
+	}
+	nodeReply.RawReply = multiREItemRegexp.ReplaceAllStringFunc(reply.RawReply, filter)
+	nodeReply.Data = multiREItemRegexp.ReplaceAllStringFunc(reply.Data, filter)
+	return &nodeReply
+}
+
+// collectClusterNodes runs get once for each node of the chassis cluster, sending the metrics of each run to ch with
+// the node as the cluster_node label. The errors of all runs are returned, those repeated by the runs only once.
+func collectClusterNodes(ctx context.Context, ch chan<- prometheus.Metric, nodes []string, get func(context.Context, chan<- prometheus.Metric) []error) []error {
+	pass := &clusterPass{nodes: nodes, replies: map[string]clusterReply{}}
+	var errors []error
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		pass.node = node
+		nodeCh, closeLabels := clusterNodeLabel(ch, node)
+		for _, err := range get(context.WithValue(ctx, clusterNodeKey{}, pass), nodeCh) {
+			if !seen[err.Error()] {
+				seen[err.Error()] = true
+				errors = append(errors, err)
+			}
+		}
+		closeLabels()
+	}
+	return errors
+}
+
+// clusterNodeLabel sends the metrics received on the returned channel to ch with node as their cluster_node label.
+// The returned function must be called once no more metrics are sent.
+func clusterNodeLabel(ch chan<- prometheus.Metric, node string) (chan<- prometheus.Metric, func()) {
+	labeled := make(chan prometheus.Metric)
+	// Values that could not be converted are recorded by the tracker of ch, if any.
+	if tracker, ok := parseTrackers.Load(ch); ok {
+		parseTrackers.Store((chan<- prometheus.Metric)(labeled), tracker)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Labeled descriptions by the string of the original description, shared by all metrics of a description.
+		descs := map[string]*prometheus.Desc{}
+		for metric := range labeled {
+			ch <- labelMetric(metric, node, descs)
+		}
+	}()
+	return labeled, func() {
+		parseTrackers.Delete((chan<- prometheus.Metric)(labeled))
+		close(labeled)
+		<-done
+	}
+}
+
+func labelMetric(metric prometheus.Metric, node string, descs map[string]*prometheus.Desc) prometheus.Metric {
+	m := &dto.Metric{}
+	if err := metric.Write(m); err != nil {
+		return metric
+	}
+	var valueType prometheus.ValueType
+	var value float64
+	switch {
+	case m.Counter != nil:
+		valueType, value = prometheus.CounterValue, m.Counter.GetValue()
+	case m.Gauge != nil:
+		valueType, value = prometheus.GaugeValue, m.Gauge.GetValue()
+	case m.Untyped != nil:
+		valueType, value = prometheus.UntypedValue, m.Untyped.GetValue()
+	default:
+		return metric
+	}
+
+	labelNames := make([]string, 0, len(m.Label))
+	labelValues := make([]string, 0, len(m.Label))
+	for _, label := range m.Label {
+		labelNames = append(labelNames, label.GetName())
+		labelValues = append(labelValues, label.GetValue())
+	}
+	key := metric.Desc().String()
+	desc, ok := descs[key]
+	if !ok {
+		desc = prometheus.NewDesc(descName(metric.Desc()), descHelp(metric.Desc()), labelNames, prometheus.Labels{"cluster_node": node})
+		descs[key] = desc
+	}
+	labeledMetric, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		return metric
+	}
+	if m.TimestampMs != nil {
+		return prometheus.NewMetricWithTimestamp(time.UnixMilli(m.GetTimestampMs()), labeledMetric)
+	}
+	return labeledMetric
+}
@@ -1,12 +1,21 @@
 package collector
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Juniper/go-netconf/netconf"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 
@@ -18,43 +27,206 @@ import (
 const namespace = "junos"
 
 var (
-	junosTotalScrapeCount = 0.0
+	junosTotalScrapeCount atomic.Uint64
 	junosLabels           = []string{"collector"}
 	junosDesc             = map[string]*prometheus.Desc{
 		"ScrapesTotal":   promDesc("scrapes_total", "Total number of times Junos has been scraped.", nil),
-		"ScrapeErrTotal": promDesc("scrape_errors_total", "Total number of errors from a collector.", junosLabels),
 		"ScrapeDuration": promDesc("scrape_duration_seconds", "Time it took for a collector's scrape to complete.", junosLabels),
 		"CollectorUp":    promDesc("collector_up", "Whether the collector's last scrape was successful (1 = successful, 0 = unsuccessful).", junosLabels),
+		"ScrapeError":    promDesc("scrape_error", "Reason the collector's last scrape was unsuccessful, only exported for unsuccessful scrapes.", []string{"collector", "reason"}),
 	}
+
+	// Errors of each collector per target, kept across scrapes.
+	scrapeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scrape_errors_total",
+		Help:      "Total number of errors from a collector.",
+	}, []string{"target", "collector"})
+
+	// Time (value) of the last scrape of each target (key), of which the series kept across scrapes are removed once
+	// the target is stale.
+	lastScrapes sync.Map
 )
 
 // Collector is the interface a collector has to implement.
 type Collector interface {
 	// Returns the name of the collector.
 	Name() string
-	// Gets metrics and sends to the Prometheus.Metric channel.
-	Get(ch chan<- prometheus.Metric, config Config) ([]error, float64)
+	// Gets metrics and sends to the Prometheus.Metric channel. RPCs must be aborted once ctx is done.
+	Get(ctx context.Context, ch chan<- prometheus.Metric, config Config) []error
+}
+
+// RPCLister is implemented by collectors that list the NETCONF RPCs they execute.
+type RPCLister interface {
+	// Returns the names of the NETCONF RPCs executed by the collector.
+	RPCs() []string
+}
+
+// PlatformLimiter is implemented by collectors that do not work on some platforms, which are skipped on targets of
+// those platforms when platform detection is enabled.
+type PlatformLimiter interface {
+	// Returns the platforms the collector does not work on, such as srx.
+	UnsupportedPlatforms() []string
 }
 
 // Config required by the collectors.
 type Config struct {
+	// Name of the config of the target in the configuration file, the sessions of a config to a target being shared.
+	ConfigName      string
 	SSHClientConfig *ssh.ClientConfig
-	SSHTarget       string
+	// SSH configurations tried in order when authenticating with SSHClientConfig fails.
+	SSHFallbackConfigs []*ssh.ClientConfig
+	TLSConfig          *tls.Config
+	SSHTarget          string
+	// Port used for targets that do not include a port, the default NETCONF port of the transport when 0.
+	Port int
+	// Proxy used to connect to the target, connecting directly when nil.
+	ProxyURL *url.URL
+	// Directory of the XML files replayed as the replies of the target instead of connecting to it, when set.
+	ReplayDir       string
 	IfaceDescrKeys  []string
 	IfaceMetricKeys []string
-	BGPTypeKeys     []string
+	// Whether the description of interfaces is added as a label to their up metric.
+	IfaceDescrLabel bool
+	// Whether the values of IfaceDescrKeys are added as labels to all interface metrics.
+	IfaceDescrKeyLabels bool
+	IfaceDetail         string
+	// Types of interfaces collected, physical or logical, both when empty.
+	IfaceTypes string
+	// Whether the routing instance of each logical interface is exported.
+	IfaceInstances bool
+	// Whether the utilization of interfaces relative to their speed is exported.
+	IfaceUtilization bool
+	BGPTypeKeys      []string
+	// Whether the SMART health of the disks of the route engine is collected, using smartctl in the shell.
+	REDiskHealth bool
+	// Peers of which the routes advertised to and received from are counted.
+	BGPMonitoredPeers []string
+	// Whether the traffic statistics of each active IPsec tunnel are collected.
+	IpsecTunnelStats bool
+	// Routing instances of which the BGP group, peer and RIB counts are exported, all instances when empty.
+	BGPInstances        []string
+	DisableBGPInstances bool
+	RPCTimeout          time.Duration
+	// RPC timeouts per collector, overriding RPCTimeout.
+	CollectorRPCTimeouts map[string]time.Duration
+	// Time a collector may take within a scrape before it is reported down and the scrape completes without it, no
+	// limit when 0.
+	CollectorTimeout time.Duration
+	// Collector timeouts per collector, overriding CollectorTimeout.
+	CollectorTimeouts map[string]time.Duration
+	// Number of times a failed RPC is retried and the wait before the first retry, doubled for each further retry.
+	RPCRetries      int
+	RPCRetryBackoff time.Duration
+	// Maximum number of independent RPCs of a collector executed concurrently, each on its own session.
+	RPCParallelism int
+	// Maximum number of sessions open at a time using the config, 0 means no limit.
+	MaxSessions   int
+	Connections   *ConnectionManager
+	Session       *Session
+	GNMI          *GNMIClient
+	MetricFilters map[string]*MetricFilter
+	// Operational commands run by the command collector.
+	Commands []Command
+	// Filters of the routes counted by the route collector.
+	RouteFilters []RouteFilter
+	// Whether values that could not be converted to a number fail the collector, instead of only being skipped.
+	StrictParsing bool
+	// Directory the RPC replies of collectors that failed to parse are written to, disabled when empty. Replies longer
+	// than CaptureMaxBytes are truncated.
+	CaptureDir      string
+	CaptureMaxBytes int
+	// Whether the metrics of collectors are timestamped with the time of the device, unless the clock of the device is
+	// off by more than DeviceTimestampSkew.
+	DeviceTimestamps    bool
+	DeviceTimestampSkew time.Duration
+	// Whether the platform of the target is detected on its first scrape to skip the collectors that do not work on it.
+	PlatformDetection bool
+	// Nodes of the chassis cluster of the target, detected on its first scrape, of which the metrics are collected
+	// separately and labeled with the node.
+	ClusterNodes []string
+	// Whether metrics are exported under names following the Prometheus naming conventions instead of their legacy names.
+	PrometheusNaming bool
+	// Whether the metrics of the target shared by its collectors, such as those of the session pool and the RPC
+	// durations, are left out, to be sent once using CollectTarget when collectors collected apart are served together.
+	OmitTargetMetrics bool
+}
+
+// MetricFilter selects the metrics of a collector that are exported by their name. A metric is exported when it
+// matches any Allow expression, or Allow is empty, and does not match any Deny expression.
+type MetricFilter struct {
+	Allow []*regexp.Regexp
+	Deny  []*regexp.Regexp
+}
+
+// NewMetricFilter returns a MetricFilter from the passed regular expressions, which are anchored to the full name.
+func NewMetricFilter(allow []string, deny []string) (*MetricFilter, error) {
+	f := &MetricFilter{}
+	for _, expr := range allow {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, err
+		}
+		f.Allow = append(f.Allow, re)
+	}
+	for _, expr := range deny {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, err
+		}
+		f.Deny = append(f.Deny, re)
+	}
+	return f, nil
+}
+
+func (f *MetricFilter) allowed(name string) bool {
+	for _, re := range f.Deny {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, re := range f.Allow {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter sends the metrics received on the returned channel that are allowed by f to ch. The returned function must
+// be called once no more metrics are sent.
+func (f *MetricFilter) filter(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	filtered := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range filtered {
+			if f.allowed(descName(metric.Desc())) {
+				ch <- metric
+			}
+		}
+	}()
+	return filtered, func() {
+		close(filtered)
+		<-done
+	}
 }
 
 // Exporter collects all exporter metrics, implemented as per the prometheus.Collector interface.
 type Exporter struct {
+	ctx        context.Context
 	Collectors []Collector
 	config     Config
 	logger     log.Logger
 }
 
-// NewExporter returns a new Exporter.
-func NewExporter(collectors []Collector, config Config, logger log.Logger) (*Exporter, error) {
+// NewExporter returns a new Exporter. Collection is aborted once ctx is done.
+func NewExporter(ctx context.Context, collectors []Collector, config Config, logger log.Logger) (*Exporter, error) {
 	return &Exporter{
+		ctx:        ctx,
 		Collectors: collectors,
 		config:     config,
 		logger:     logger,
@@ -63,29 +235,231 @@ func NewExporter(collectors []Collector, config Config, logger log.Logger) (*Exp
 
 // Collect implemented as per the prometheus.Collector interface.
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
-	junosTotalScrapeCount++
-	ch <- prometheus.MustNewConstMetric(junosDesc["ScrapesTotal"], prometheus.CounterValue, junosTotalScrapeCount)
+	if e.config.PrometheusNaming {
+		// Deferred first to rename the metrics sent by all other deferred functions.
+		var closeRename func()
+		ch, closeRename = renameMetrics(ch)
+		defer closeRename()
+	}
+
+	scrapes := junosTotalScrapeCount.Add(1)
+	if !e.config.OmitTargetMetrics {
+		ch <- prometheus.MustNewConstMetric(junosDesc["ScrapesTotal"], prometheus.CounterValue, float64(scrapes))
+	}
+	lastScrapes.Store(e.config.SSHTarget, time.Now())
+
+	status := newScrapeStatus(e.config.SSHTarget)
+	defer status.done()
+
+	var session *Session
+	defer func() {
+		if !e.config.OmitTargetMetrics {
+			collectTarget(ch, e.config, session)
+		}
+	}()
+
+	config := e.config
+	if config.GNMI == nil {
+		// A single session is used by all collectors of a scrape.
+		s, err := e.config.Connections.Get(e.ctx, e.config)
+		if err != nil {
+			level.Error(e.logger).Log("msg", "could not connect to target", "target", e.config.SSHTarget, "err", err)
+			reason := errorReason(err, true)
+			for _, collector := range e.Collectors {
+				ch <- prometheus.MustNewConstMetric(junosDesc["CollectorUp"], prometheus.GaugeValue, 0, collector.Name())
+				ch <- prometheus.MustNewConstMetric(junosDesc["ScrapeError"], prometheus.GaugeValue, 1, collector.Name(), reason)
+				status.collector(collector.Name(), []error{err})
+				errorCounter := scrapeErrors.WithLabelValues(e.config.SSHTarget, collector.Name())
+				errorCounter.Inc()
+				ch <- errorCounter
+			}
+			return
+		}
+		defer e.config.Connections.Release(s)
+		s.rpcTimeout = e.config.RPCTimeout
+		s.retries = e.config.RPCRetries
+		s.backoff = e.config.RPCRetryBackoff
+		config.Session = s
+		session = s
+	}
+
+	collectorsCh := ch
+	if config.DeviceTimestamps && config.Session != nil {
+		skew, err := deviceClockSkew(e.ctx, config.Session)
+		if err != nil {
+			clockSkews.Delete(e.config.SSHTarget)
+		} else {
+			clockSkews.Store(e.config.SSHTarget, skew)
+		}
+		switch {
+		case err != nil:
+			level.Warn(e.logger).Log("msg", "could not get device time, metrics are not timestamped", "target", e.config.SSHTarget, "err", err)
+		case skew > config.DeviceTimestampSkew || skew < -config.DeviceTimestampSkew:
+			level.Warn(e.logger).Log("msg", "device clock skew exceeds the maximum, metrics are not timestamped", "target", e.config.SSHTarget, "skew", skew)
+		default:
+			var closeTimestamps func()
+			collectorsCh, closeTimestamps = deviceTimestamps(ch, skew)
+			defer closeTimestamps()
+		}
+	}
+
+	enabledCollectors := e.Collectors
+	if config.Session != nil {
+		// The platform is also detected without platform detection to find the nodes of chassis clusters.
+		platform, err := targetPlatform(e.ctx, config.Session, e.config.SSHTarget)
+		switch {
+		case err != nil && config.PlatformDetection:
+			level.Warn(e.logger).Log("msg", "could not detect platform, running all collectors", "target", e.config.SSHTarget, "err", err)
+		case err != nil:
+			level.Debug(e.logger).Log("msg", "could not detect chassis cluster", "target", e.config.SSHTarget, "err", err)
+		default:
+			config.ClusterNodes = platform.Nodes
+			if !config.PlatformDetection {
+				break
+			}
+			var skipped []string
+			enabledCollectors, skipped = platformCollectors(enabledCollectors, platform.Name)
+			if len(skipped) > 0 {
+				level.Debug(e.logger).Log("msg", "skipping collectors not supported by the platform", "target", e.config.SSHTarget, "platform", platform.Name, "collectors", strings.Join(skipped, ","))
+			}
+		}
+	}
 
 	wg := &sync.WaitGroup{}
-	for _, collector := range e.Collectors {
+	for _, collector := range enabledCollectors {
 		wg.Add(1)
-		go e.runCollector(ch, collector, wg, e.logger)
+		go e.runCollector(collectorsCh, collector, config, status, wg, e.logger)
 	}
 	wg.Wait()
 }
 
-func (e *Exporter) runCollector(ch chan<- prometheus.Metric, collector Collector, wg *sync.WaitGroup, logger log.Logger) {
+// ForgetStaleTargets removes the series kept across scrapes of the targets not scraped within staleAfter, such as
+// their error counters and RPC durations, along with their session statistics in connections when set.
+func ForgetStaleTargets(staleAfter time.Duration, connections *ConnectionManager) {
+	lastScrapes.Range(func(key, value any) bool {
+		target := key.(string)
+		if time.Since(value.(time.Time)) <= staleAfter || !lastScrapes.CompareAndDelete(key, value) {
+			return true
+		}
+		scrapeErrors.DeletePartialMatch(prometheus.Labels{"target": target})
+		parseErrors.vecs.Delete(target)
+		rpcDurations.vecs.Delete(target)
+		dialDurations.vecs.Delete(target)
+		clockSkews.Delete(target)
+		capturesMu.Lock()
+		delete(captures, target)
+		capturesMu.Unlock()
+		platformsMu.Lock()
+		delete(platforms, target)
+		platformsMu.Unlock()
+		if connections != nil {
+			connections.forget(target)
+		}
+		return true
+	})
+}
+
+// CollectTarget sends the metrics of the target shared by its collectors, left out by the Exporters collecting them
+// with OmitTargetMetrics set.
+func CollectTarget(ch chan<- prometheus.Metric, config Config) {
+	if config.PrometheusNaming {
+		var closeRename func()
+		ch, closeRename = renameMetrics(ch)
+		defer closeRename()
+	}
+	collectTarget(ch, config, nil)
+}
+
+// collectTarget sends the metrics of the target shared by its collectors, and the age of the session s used by the
+// scrape when set.
+func collectTarget(ch chan<- prometheus.Metric, config Config, s *Session) {
+	rpcDurations.collect(config.SSHTarget, ch)
+	parseErrors.collect(config.SSHTarget, ch)
+	collectCaptures(config.SSHTarget, ch)
+	dialDurations.collect(config.SSHTarget, ch)
+	if config.Connections != nil {
+		config.Connections.collect(config.SSHTarget, ch, s)
+	}
+	if skew, ok := clockSkews.Load(config.SSHTarget); ok && config.DeviceTimestamps {
+		ch <- prometheus.MustNewConstMetric(clockSkewDesc, prometheus.GaugeValue, skew.(time.Duration).Seconds())
+	}
+	if config.PlatformDetection {
+		platformsMu.Lock()
+		platform, ok := platforms[config.SSHTarget]
+		platformsMu.Unlock()
+		if ok {
+			sendPlatform(ch, platform)
+		}
+	}
+}
+
+func (e *Exporter) runCollector(ch chan<- prometheus.Metric, collector Collector, config Config, status *scrapeStatus, wg *sync.WaitGroup, logger log.Logger) {
 	defer wg.Done()
 	collectorName := collector.Name()
 
 	startTime := time.Now()
-	errors, totalErrors := collector.Get(ch, e.config)
+	var errors []error
+	ctx := context.WithValue(e.ctx, collectorKey{}, collectorName)
+	if timeout, ok := config.CollectorRPCTimeouts[collectorName]; ok {
+		ctx = context.WithValue(ctx, rpcTimeoutKey{}, timeout)
+	}
+	timeout := collectorTimeout(config, collectorName)
+	if deadline, ok := e.ctx.Deadline(); ok {
+		// Collectors are abandoned once the collection is aborted, even when stuck outside of an RPC.
+		if remaining := max(time.Until(deadline), time.Nanosecond); timeout == 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout > 0 {
+		// The RPCs of the collector are aborted as well, freeing the session for the other collectors.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var capture *RPCCapture
+	if config.CaptureDir != "" {
+		ctx, capture = CaptureRPCs(ctx)
+	}
+	collectorCh := ch
+	if filter, ok := config.MetricFilters[collectorName]; ok {
+		var closeFilter func()
+		collectorCh, closeFilter = filter.filter(ch)
+		defer closeFilter()
+	}
+	collectorCh, parseErrs := trackParseErrors(collectorCh, config.SSHTarget, collectorName)
+	get := func(ctx context.Context, ch chan<- prometheus.Metric) []error {
+		if gnmiCollector, ok := collector.(GNMICollector); ok && config.GNMI != nil {
+			return gnmiCollector.GetGNMI(ctx, ch, config)
+		}
+		if config.Session == nil {
+			// Only the collectors supporting gNMI can collect targets without a NETCONF session.
+			return []error{fmt.Errorf("collector %s does not support the gnmi transport", collectorName)}
+		}
+		return collector.Get(ctx, ch, config)
+	}
+	errors = watchdog(collectorCh, timeout, func(ch chan<- prometheus.Metric) []error {
+		// The nodes of chassis clusters reply with the same names, which would otherwise collide.
+		if len(config.ClusterNodes) > 1 {
+			return collectClusterNodes(ctx, ch, config.ClusterNodes, get)
+		}
+		return get(ctx, ch)
+	})
+	if errs := parseErrs(); config.StrictParsing {
+		errors = append(errors, errs...)
+	}
+	if capture != nil {
+		captureReplies(config.CaptureDir, config.CaptureMaxBytes, config.SSHTarget, collectorName, capture, errors, logger)
+	}
 
 	ch <- prometheus.MustNewConstMetric(junosDesc["ScrapeDuration"], prometheus.GaugeValue, float64(time.Since(startTime).Seconds()), collectorName)
-	ch <- prometheus.MustNewConstMetric(junosDesc["ScrapeErrTotal"], prometheus.GaugeValue, totalErrors, collectorName)
+	errorCounter := scrapeErrors.WithLabelValues(config.SSHTarget, collectorName)
+	errorCounter.Add(float64(len(errors)))
+	ch <- errorCounter
+	status.collector(collectorName, errors)
 
 	if len(errors) > 0 {
 		ch <- prometheus.MustNewConstMetric(junosDesc["CollectorUp"], prometheus.GaugeValue, 0, collector.Name())
+		ch <- prometheus.MustNewConstMetric(junosDesc["ScrapeError"], prometheus.GaugeValue, 1, collectorName, errorReason(errors[0], false))
 		for _, err := range errors {
 			level.Error(logger).Log("msg", "collector scrape failed", "collector", collectorName, "err", err)
 		}
@@ -99,6 +473,47 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	for _, desc := range junosDesc {
 		ch <- desc
 	}
+	scrapeErrors.Describe(ch)
+	for _, desc := range poolDesc {
+		ch <- desc
+	}
+	rpcDurations.describe(ch)
+	parseErrors.describe(ch)
+	ch <- captureDesc
+	ch <- clockSkewDesc
+	ch <- platformDesc
+	dialDurations.describe(ch)
+}
+
+// errorReason classifies the error of an unsuccessful scrape for the scrape_error metric. dialing is set for errors
+// connecting to the target.
+func errorReason(err error, dialing bool) string {
+	var rpcErr *netconf.RPCError
+	var netErr net.Error
+	var paused *pausedError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, errCollectorTimeout):
+		return "collector_timeout"
+	case errors.As(err, &paused):
+		return "dial_paused"
+	case dialing && (errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()):
+		return "dial_timeout"
+	case dialing && strings.Contains(err.Error(), "unable to authenticate"):
+		return "auth_failed"
+	case dialing && strings.Contains(err.Error(), "host key"):
+		return "host_key_error"
+	case dialing:
+		return "dial_error"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "rpc_timeout"
+	case errors.As(err, &rpcErr):
+		return "rpc_error"
+	case strings.HasPrefix(err.Error(), "could not unmarshal"), strings.HasPrefix(err.Error(), "could not parse"):
+		return "parse_error"
+	}
+	return "session_error"
 }
 
 func promDesc(metricName string, metricDescription string, labels []string) *prometheus.Desc {
@@ -109,34 +524,136 @@ func colPromDesc(subsystem string, metricName string, metricDescription string,
 	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, metricName), metricDescription, labels, nil)
 }
 
-func newGauge(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, labels ...string) {
-	if metric != "" {
-		i, err := strconv.ParseFloat(strings.TrimSpace(metric), 64)
+// descName returns the fully-qualified name of desc, which is only exposed by its String method.
+func descName(desc *prometheus.Desc) string {
+	name := strings.TrimPrefix(desc.String(), `Desc{fqName: "`)
+	if i := strings.IndexByte(name, '"'); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// decodeElements streams reply and decodes each element named name into a new T, passing it to fn before
+// decoding the next, so that large replies are never unmarshalled into memory as a whole.
+func decodeElements[T any](reply *netconf.RPCReply, name string, fn func(*T) error) error {
+	d := xml.NewDecoder(strings.NewReader(reply.RawReply))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			level.Error(logger).Log("msg", "could not convert metric to float64", "err", err)
+			return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != name {
+			continue
+		}
+		var v T
+		if err := d.DecodeElement(&v, &start); err != nil {
+			return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+		}
+		if err := fn(&v); err != nil {
+			return err
 		}
-		ch <- prometheus.MustNewConstMetric(descName, prometheus.GaugeValue, i, labels...)
 	}
 }
 
-func newCounter(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, labels ...string) {
-	if metric != "" {
-		i, err := strconv.ParseFloat(strings.TrimSpace(metric), 64)
+// decodeMemberElements decodes each element named name like decodeElements, passing fn the member of a virtual chassis
+// or chassis cluster the element belongs to, which is the re-name of the multi-routing-engine-item of the member in
+// the replies listing each member separately, such as fpc1 or node0, and empty otherwise.
+func decodeMemberElements[T any](reply *netconf.RPCReply, name string, fn func(member string, v *T) error) error {
+	d := xml.NewDecoder(strings.NewReader(reply.RawReply))
+	member := ""
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			level.Error(logger).Log("msg", "could not convert metric to float64", "err", err)
+			return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "re-name":
+				var reName string
+				if err := d.DecodeElement(&reName, &t); err != nil {
+					return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+				}
+				member = strings.TrimSpace(reName)
+			case name:
+				var v T
+				if err := d.DecodeElement(&v, &t); err != nil {
+					return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+				}
+				if err := fn(member, &v); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "multi-routing-engine-item" {
+				member = ""
+			}
 		}
+	}
+}
+
+// memberLabels returns a new slice of the labels following prefix, such as the member label of the metrics of a member
+// of a virtual chassis or chassis cluster.
+func memberLabels(prefix []string, labels ...string) []string {
+	return append(append([]string{}, prefix...), labels...)
+}
+
+// newGauge sends the value of metric as a gauge, skipping placeholders of values that are not available, such as N/A.
+func newGauge(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, labels ...string) {
+	i, skip, err := parseValue(metric)
+	if err != nil {
+		parseFailed(logger, ch, descName, metric, err)
+		return
+	}
+	if !skip {
+		ch <- prometheus.MustNewConstMetric(descName, prometheus.GaugeValue, i, labels...)
+	}
+}
+
+// newCounter sends the value of metric as a counter like newGauge, negative values failing to parse as counters
+// cannot be negative.
+func newCounter(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, labels ...string) {
+	i, skip, err := parseValue(metric)
+	if err == nil && i < 0 {
+		err = errNegativeCounter
+	}
+	if err != nil {
+		parseFailed(logger, ch, descName, metric, err)
+		return
+	}
+	if !skip {
 		ch <- prometheus.MustNewConstMetric(descName, prometheus.CounterValue, i, labels...)
 	}
 }
 
-func newGaugeMB(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, labels ...string) {
-	if metric != "" {
-		re := regexp.MustCompile("[0-9]+")
-		i, err := strconv.ParseFloat(strings.TrimSpace(re.FindString(metric)), 64)
-		if err != nil {
-			level.Error(logger).Log("msg", "could not convert metric to float64", "err", err)
-		}
+// newGaugeSize sends the bytes of a size such as "32703 MB" or "1.5 GB" as a gauge, sizes without a unit being in unit
+// bytes.
+func newGaugeSize(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, unit float64, labels ...string) {
+	i, skip, err := parseSize(metric, unit)
+	if err != nil {
+		parseFailed(logger, ch, descName, metric, err)
+		return
+	}
+	if !skip {
+		ch <- prometheus.MustNewConstMetric(descName, prometheus.GaugeValue, i, labels...)
+	}
+}
 
-		ch <- prometheus.MustNewConstMetric(descName, prometheus.GaugeValue, i*1000000, labels...)
+// newGaugeBlocks converts a count of 512 byte blocks to bytes.
+func newGaugeBlocks(logger log.Logger, ch chan<- prometheus.Metric, descName *prometheus.Desc, metric string, labels ...string) {
+	i, skip, err := parseValue(metric)
+	if err != nil {
+		parseFailed(logger, ch, descName, metric, err)
+		return
+	}
+	if !skip {
+		ch <- prometheus.MustNewConstMetric(descName, prometheus.GaugeValue, i*512, labels...)
 	}
 }
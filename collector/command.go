@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var commandSubsystem = "command"
+
+// Command is an operational command executed by the command collector, and the metrics mapped from its XML output.
+type Command struct {
+	Command string
+	Metrics []CommandMetric
+}
+
+// CommandMetric maps the elements of the XML output of a command to a metric.
+type CommandMetric struct {
+	// Name of the metric, exported as junos_command_<name>.
+	Name string
+	Help string
+	// Counter or gauge, gauge when empty.
+	Type string
+	// Slash separated path of the elements below the rpc-reply element, each element is a sample of the metric.
+	Items string
+	// Path of the value of the sample relative to the item, the item itself when ".".
+	Value string
+	// Paths of the values of the labels (key) of the sample relative to the item.
+	Labels map[string]string
+}
+
+// CommandCollector collects metrics from the XML output of the operational commands of a config, for commands without
+// an RPC, implemented as per the Collector interface.
+type CommandCollector struct {
+	logger log.Logger
+}
+
+// NewCommandCollector returns a new CommandCollector.
+func NewCommandCollector(logger log.Logger) *CommandCollector {
+	return &CommandCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*CommandCollector) Name() string {
+	return commandSubsystem
+}
+
+// RPCs executed by the collector.
+func (*CommandCollector) RPCs() []string {
+	return []string{"command"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *CommandCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	for _, command := range conf.Commands {
+		var escaped strings.Builder
+		xml.EscapeText(&escaped, []byte(command.Command))
+		reply, err := conf.Session.Exec(ctx, netconf.RawMethod(fmt.Sprintf(`<command format="xml">%s</command>`, escaped.String())))
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call for command %q: %w", command.Command, err))
+			continue
+		}
+		if err := processCommandNetconfReply(reply, command, ch, c.logger); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
+func processCommandNetconfReply(reply *netconf.RPCReply, command Command, ch chan<- prometheus.Metric, logger log.Logger) error {
+	root, err := parseXMLNode(reply.RawReply)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml of command %q: %s", command.Command, err)
+	}
+	for _, metric := range command.Metrics {
+		labelNames := make([]string, 0, len(metric.Labels))
+		for name := range metric.Labels {
+			labelNames = append(labelNames, name)
+		}
+		sort.Strings(labelNames)
+		help := metric.Help
+		if help == "" {
+			help = fmt.Sprintf("Value of %s of the output of the command %q.", metric.Value, command.Command)
+		}
+		desc := colPromDesc(commandSubsystem, metric.Name, help, labelNames)
+		for _, item := range root.find(metric.Items) {
+			labels := make([]string, 0, len(labelNames))
+			for _, name := range labelNames {
+				labels = append(labels, item.text(metric.Labels[name]))
+			}
+			if metric.Type == "counter" {
+				newCounter(logger, ch, desc, item.text(metric.Value), labels...)
+			} else {
+				newGauge(logger, ch, desc, item.text(metric.Value), labels...)
+			}
+		}
+	}
+	return nil
+}
+
+// xmlNode is an element of an XML document of which the structure is not known in advance.
+type xmlNode struct {
+	name     string
+	content  string
+	children []*xmlNode
+}
+
+// parseXMLNode parses an XML document into a tree of xmlNodes, returning its root element.
+func parseXMLNode(doc string) (*xmlNode, error) {
+	d := xml.NewDecoder(strings.NewReader(doc))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.content += string(t)
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root.children[0], nil
+}
+
+// find returns the elements below n matching the slash separated path of element names.
+func (n *xmlNode) find(path string) []*xmlNode {
+	nodes := []*xmlNode{n}
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		var next []*xmlNode
+		for _, node := range nodes {
+			for _, child := range node.children {
+				if child.name == name {
+					next = append(next, child)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// text returns the trimmed text of the first element below n matching path, empty when there is none.
+func (n *xmlNode) text(path string) string {
+	nodes := n.find(path)
+	if len(nodes) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(nodes[0].content)
+}
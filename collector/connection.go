@@ -0,0 +1,969 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	poolDesc = map[string]*prometheus.Desc{
+		"SessionsOpen":      promDesc("sessions_open", "Number of open NETCONF sessions to the target.", nil),
+		"SessionDials":      promDesc("session_dials_total", "Total number of attempts to establish a NETCONF session to the target.", nil),
+		"SessionDialErrors": promDesc("session_dial_errors_total", "Total number of failed attempts to establish a NETCONF session to the target.", nil),
+		"SessionReuses":     promDesc("session_reuses_total", "Total number of scrapes that reused an idle NETCONF session.", nil),
+		"SessionEvictions":  promDesc("session_evictions_total", "Total number of idle NETCONF sessions closed, by reason.", []string{"reason"}),
+		"SessionAge":        promDesc("session_age_seconds", "Time since the NETCONF session used by the scrape was established.", nil),
+		"SessionCredential": promDesc("session_credential_index", "Index of the SSH credentials of the config that authenticated the last NETCONF session established to the target, starting at 0.", nil),
+	}
+
+	rpcDurations = &targetHistograms{
+		opts: prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_duration_seconds",
+			Help:      "Time it took for a NETCONF RPC to complete, including retries.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		labels: []string{"collector", "rpc"},
+	}
+	dialDurations = &targetHistograms{
+		opts: prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "dial_duration_seconds",
+			Help:      "Time it took to connect to the target and establish a NETCONF session.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+	}
+)
+
+// targetHistograms keeps a HistogramVec per target, the series of a target are only exported when scraping it.
+type targetHistograms struct {
+	opts   prometheus.HistogramOpts
+	labels []string
+	vecs   sync.Map
+}
+
+func (h *targetHistograms) observe(target string, d time.Duration, labels ...string) {
+	vec, ok := h.vecs.Load(target)
+	if !ok {
+		vec, _ = h.vecs.LoadOrStore(target, prometheus.NewHistogramVec(h.opts, h.labels))
+	}
+	vec.(*prometheus.HistogramVec).WithLabelValues(labels...).Observe(d.Seconds())
+}
+
+func (h *targetHistograms) collect(target string, ch chan<- prometheus.Metric) {
+	if vec, ok := h.vecs.Load(target); ok {
+		vec.(*prometheus.HistogramVec).Collect(ch)
+	}
+}
+
+func (h *targetHistograms) describe(ch chan<- *prometheus.Desc) {
+	prometheus.NewHistogramVec(h.opts, h.labels).Describe(ch)
+}
+
+// collectorKey is the context key of the name of the collector executing an RPC.
+type collectorKey struct{}
+
+// rpcName returns the name of the first RPC of methods, such as get-interface-information.
+func rpcName(methods []netconf.RPCMethod) string {
+	if len(methods) == 0 {
+		return ""
+	}
+	name := strings.TrimSpace(methods[0].MarshalMethod())
+	name = strings.TrimPrefix(name, "<")
+	if i := strings.IndexAny(name, " \t\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// rpcCaptureKey is the context key of the RPCCapture recording the RPCs executed with the context.
+type rpcCaptureKey struct{}
+
+// CapturedRPC is an RPC recorded by an RPCCapture.
+type CapturedRPC struct {
+	Collector string
+	RPC       string
+	Reply     string
+	Err       error
+}
+
+// RPCCapture records the RPCs executed with a context returned by CaptureRPCs and their raw replies.
+type RPCCapture struct {
+	mu   sync.Mutex
+	rpcs []CapturedRPC
+	// Capture of ctx passed to CaptureRPCs, which also records the RPCs.
+	parent *RPCCapture
+}
+
+// CaptureRPCs returns a context that records the RPCs executed with it in the returned RPCCapture, as well as in the
+// RPCCapture of ctx.
+func CaptureRPCs(ctx context.Context) (context.Context, *RPCCapture) {
+	capture := &RPCCapture{}
+	capture.parent, _ = ctx.Value(rpcCaptureKey{}).(*RPCCapture)
+	return context.WithValue(ctx, rpcCaptureKey{}, capture), capture
+}
+
+func (c *RPCCapture) record(collectorName string, methods []netconf.RPCMethod, reply *netconf.RPCReply, err error) {
+	rpc := CapturedRPC{Collector: collectorName, Err: err}
+	for _, method := range methods {
+		rpc.RPC += method.MarshalMethod()
+	}
+	if reply != nil {
+		rpc.Reply = reply.RawReply
+	}
+	c.mu.Lock()
+	c.rpcs = append(c.rpcs, rpc)
+	c.mu.Unlock()
+	if c.parent != nil {
+		c.parent.record(collectorName, methods, reply, err)
+	}
+}
+
+// RPCs returns the recorded RPCs in the order they completed.
+func (c *RPCCapture) RPCs() []CapturedRPC {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CapturedRPC(nil), c.rpcs...)
+}
+
+// connKey identifies the sessions that can be shared, those to a target using the same config in the configuration
+// file. Configs are identified by their name, as their SSH and TLS configurations are rebuilt when the configuration is
+// reloaded or their credentials are refreshed.
+type connKey struct {
+	config    string
+	target    string
+	port      int
+	proxyURL  string
+	replayDir string
+	tls       bool
+}
+
+// dialConfig holds the configurations the sessions of a connKey are established with.
+type dialConfig struct {
+	sshConfig *ssh.ClientConfig
+	// SSH configurations tried in order when authenticating with sshConfig fails.
+	fallbacks []*ssh.ClientConfig
+	tlsConfig *tls.Config
+	proxyURL  *url.URL
+}
+
+// Session is an authenticated NETCONF session handed out by a ConnectionManager. A session is shared by all
+// collectors of a scrape, RPCs are serialized as a NETCONF session processes one RPC at a time.
+type Session struct {
+	mu        sync.Mutex
+	netconf   *netconf.Session
+	transport netconfTransport
+	key       connKey
+	dial      dialConfig
+	lastUsed  time.Time
+	// broken is set by the RPCs of the scrape using the session and by keepalives, and read by the manager.
+	broken  atomic.Bool
+	created time.Time
+	manager *ConnectionManager
+	// closed is guarded by the mutex of the manager.
+	closed bool
+	// slots are the session limits the session counts towards, a slot of each is freed when the session is closed.
+	// maxSessions is the limit of the sessions of the config of the session.
+	slots       []chan struct{}
+	maxSessions int
+	// missedKeepalives is the number of consecutive unanswered keepalives, guarded by the mutex of the manager.
+	missedKeepalives int
+
+	// rpcTimeout is the deadline applied to each RPC, 0 means RPCs are only bound by the scrape context.
+	rpcTimeout time.Duration
+	// retries is the number of times a failed RPC is retried, waiting backoff before the first retry and doubling it
+	// before each further retry.
+	retries int
+	backoff time.Duration
+}
+
+// rpcTimeoutKey is the context key of the RPC timeout of a collector, overriding the RPC timeout of the session.
+type rpcTimeoutKey struct{}
+
+// Exec executes the RPC methods on the session. A session that returns an error other than a NETCONF rpc-error is
+// considered broken and will not be reused. When ctx is done or the RPC timeout expires before a reply is received,
+// the session is closed to abort the RPC. Transient failures are retried when retries are configured, reconnecting the
+// session when it is broken.
+func (s *Session) Exec(ctx context.Context, methods ...netconf.RPCMethod) (reply *netconf.RPCReply, err error) {
+	if pass, ok := ctx.Value(clusterNodeKey{}).(*clusterPass); ok {
+		return pass.exec(methods, func() (*netconf.RPCReply, error) {
+			return s.Exec(context.WithValue(ctx, clusterNodeKey{}, nil), methods...)
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		collectorName, _ := ctx.Value(collectorKey{}).(string)
+		rpcDurations.observe(s.key.target, time.Since(start), collectorName, rpcName(methods))
+		if capture, ok := ctx.Value(rpcCaptureKey{}).(*RPCCapture); ok {
+			capture.record(collectorName, methods, reply, err)
+		}
+	}()
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		reply, err = nil, nil
+		if s.broken.Load() {
+			if s.retries == 0 {
+				return nil, fmt.Errorf("session to %q is closed", s.key.target)
+			}
+			err = s.reconnect(ctx)
+		}
+		if err == nil {
+			reply, err = s.exec(ctx, methods...)
+		}
+		if err == nil || attempt >= s.retries || !retryable(err) || ctx.Err() != nil {
+			return reply, err
+		}
+	}
+}
+
+func (s *Session) exec(ctx context.Context, methods ...netconf.RPCMethod) (*netconf.RPCReply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	timeout := s.rpcTimeout
+	if t, ok := ctx.Value(rpcTimeoutKey{}).(time.Duration); ok {
+		timeout = t
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var reply *netconf.RPCReply
+	var err error
+	done := make(chan struct{})
+	go func() {
+		reply, err = s.netconf.Exec(methods...)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.broken.Store(true)
+		s.transport.Close()
+		<-done
+		return nil, ctx.Err()
+	}
+
+	if err != nil {
+		if _, ok := err.(*netconf.RPCError); !ok {
+			s.broken.Store(true)
+		}
+	}
+	return reply, err
+}
+
+// execAll executes each of methods as a separate RPC and returns their replies and errors in the same order. When
+// the RPC parallelism of conf is greater than 1, up to that many RPCs are executed concurrently, using additional
+// sessions to the target next to the session of the scrape. An RPC failing does not prevent the others from being
+// executed, so that collectors can export the metrics of the RPCs that succeeded, the reply of a failed RPC being nil.
+func execAll(ctx context.Context, conf Config, methods ...netconf.RPCMethod) ([]*netconf.RPCReply, []error) {
+	replies := make([]*netconf.RPCReply, len(methods))
+	errs := make([]error, len(methods))
+
+	workers := conf.RPCParallelism
+	if workers > len(methods) {
+		workers = len(methods)
+	}
+	if workers <= 1 || conf.Connections == nil {
+		for i, method := range methods {
+			replies[i], errs[i] = conf.Session.Exec(ctx, method)
+		}
+		return replies, errs
+	}
+
+	var mu sync.Mutex
+	next := 0
+	// job returns the index of the next method to execute, or false when all were started.
+	job := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= len(methods) {
+			return 0, false
+		}
+		next++
+		return next - 1, true
+	}
+	run := func(s *Session) {
+		for i, ok := job(); ok; i, ok = job() {
+			replies[i], errs[i] = s.Exec(ctx, methods[i])
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for w := 1; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			done := next >= len(methods)
+			mu.Unlock()
+			if done {
+				return
+			}
+			// The remaining RPCs are left to the session of the scrape when no additional session can be established.
+			s, err := conf.Connections.get(ctx, conf, false)
+			if err != nil {
+				level.Debug(conf.Connections.logger).Log("msg", "could not establish additional session", "target", conf.SSHTarget, "err", err)
+				return
+			}
+			defer conf.Connections.Release(s)
+			s.rpcTimeout = conf.RPCTimeout
+			s.retries = conf.RPCRetries
+			s.backoff = conf.RPCRetryBackoff
+			run(s)
+		}()
+	}
+	run(conf.Session)
+	wg.Wait()
+	return replies, errs
+}
+
+// reconnect replaces the broken connection of the session with a new one.
+func (s *Session) reconnect(ctx context.Context) error {
+	s.Close()
+	slots, err := s.manager.acquire(ctx, s.key, s.maxSessions, true)
+	if err != nil {
+		return err
+	}
+	t, ns, err := s.manager.dialCredentials(ctx, s.key, s.dial)
+	s.manager.recordDial(ctx, s.key.target, err)
+	if err != nil {
+		s.manager.free(slots)
+		return err
+	}
+	s.manager.mu.Lock()
+	s.slots = slots
+	s.closed = false
+	s.manager.mu.Unlock()
+	s.transport = t
+	s.netconf = ns
+	s.broken.Store(false)
+	s.created = time.Now()
+	return nil
+}
+
+// retryable returns whether an RPC that failed with err may succeed when retried. RPCs that timed out are not
+// retried, as the device is likely still busy processing them.
+func retryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var rpcErr *netconf.RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Tag {
+		case "in-use", "lock-denied", "resource-denied":
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// Close closes the session and the underlying SSH connection.
+func (s *Session) Close() error {
+	if m := s.manager; m != nil {
+		m.mu.Lock()
+		if s.closed {
+			m.mu.Unlock()
+			return nil
+		}
+		s.closed = true
+		m.targetStats(s.key.target).open--
+		m.mu.Unlock()
+		m.free(s.slots)
+	}
+	s.netconf.Close()
+	return s.transport.Close()
+}
+
+// ConnectionManager keeps NETCONF sessions open per target so they can be reused across scrapes.
+type ConnectionManager struct {
+	mu        sync.Mutex
+	idle      map[connKey][]*Session
+	maxIdle   time.Duration
+	keepalive time.Duration
+	logger    log.Logger
+	// Number of consecutive unanswered keepalives after which an idle session is closed.
+	keepaliveMaxMissed int
+
+	// Consecutive connection failures per target, connections to a target are not attempted for cooldown once
+	// failureThreshold is reached.
+	failures         map[string]*targetFailures
+	failureThreshold int
+	cooldown         time.Duration
+
+	stats map[string]*poolStats
+
+	// Slots of the sessions open overall and per config, nil when not limited. waiting is the number of dials waiting
+	// for a slot.
+	sessionSlots chan struct{}
+	configSlots  map[string]chan struct{}
+	waiting      int
+
+	// Index of the SSH credentials that last authenticated per key, which are tried first by later dials.
+	credentials map[connKey]int
+}
+
+// poolStats are the session statistics of a target.
+type poolStats struct {
+	open       int
+	dials      float64
+	dialErrors float64
+	reuses     float64
+	evictions  map[string]float64
+	// Index of the SSH credentials that authenticated the last session, -1 before a session authenticated.
+	credential int
+}
+
+type targetFailures struct {
+	count     int
+	openUntil time.Time
+}
+
+// errKeepaliveTimeout is the error of a keepalive that was not answered within the keepalive interval.
+var errKeepaliveTimeout = errors.New("keepalive timed out")
+
+// errSessionLimit is returned when no session can be opened without exceeding a session limit.
+var errSessionLimit = errors.New("session limit reached")
+
+// pausedError is returned when connections to a target are paused after consecutive connection failures.
+type pausedError struct {
+	target    string
+	remaining time.Duration
+	failures  int
+}
+
+func (e *pausedError) Error() string {
+	return fmt.Sprintf("not connecting to %q for %s after %d consecutive connection failures", e.target, e.remaining.Round(time.Second), e.failures)
+}
+
+// NewConnectionManager returns a new ConnectionManager. Sessions unused for longer than maxIdle are closed, a
+// maxIdle of 0 disables reuse. Idle sessions are sent an SSH keepalive every keepalive interval, 0 disables keepalives,
+// and are closed once keepaliveMaxMissed consecutive keepalives were not answered within the interval. Once
+// failureThreshold consecutive connections to a target failed, further connections fail immediately until cooldown
+// has passed, a failureThreshold of 0 disables this. At most maxSessions sessions are open at a time, 0 means no
+// limit.
+func NewConnectionManager(maxIdle time.Duration, keepalive time.Duration, keepaliveMaxMissed int, failureThreshold int, cooldown time.Duration, maxSessions int, logger log.Logger) *ConnectionManager {
+	m := &ConnectionManager{
+		idle:               map[connKey][]*Session{},
+		maxIdle:            maxIdle,
+		keepalive:          keepalive,
+		keepaliveMaxMissed: keepaliveMaxMissed,
+		logger:             logger,
+		failures:           map[string]*targetFailures{},
+		failureThreshold:   failureThreshold,
+		cooldown:           cooldown,
+		stats:              map[string]*poolStats{},
+		configSlots:        map[string]chan struct{}{},
+		credentials:        map[connKey]int{},
+	}
+	if maxSessions > 0 {
+		m.sessionSlots = make(chan struct{}, maxSessions)
+	}
+	if maxIdle > 0 {
+		go m.run()
+	}
+	return m
+}
+
+// Get returns an idle session to the target of conf or dials a new one. NETCONF over TLS is used when conf has a
+// TLS configuration, otherwise NETCONF over SSH. A new session waits for a slot when the limit of open sessions
+// overall or of conf is reached.
+func (m *ConnectionManager) Get(ctx context.Context, conf Config) (*Session, error) {
+	return m.get(ctx, conf, true)
+}
+
+// get returns a session like Get, failing with errSessionLimit rather than waiting for a slot unless wait is set.
+func (m *ConnectionManager) get(ctx context.Context, conf Config, wait bool) (*Session, error) {
+	key := connKey{config: conf.ConfigName, target: conf.SSHTarget, port: conf.Port, replayDir: conf.ReplayDir, tls: conf.TLSConfig != nil}
+	if conf.ProxyURL != nil {
+		key.proxyURL = conf.ProxyURL.String()
+	}
+	dc := dialConfig{sshConfig: conf.SSHClientConfig, fallbacks: conf.SSHFallbackConfigs, tlsConfig: conf.TLSConfig, proxyURL: conf.ProxyURL}
+
+	m.mu.Lock()
+	for len(m.idle[key]) > 0 {
+		sessions := m.idle[key]
+		s := sessions[len(sessions)-1]
+		m.idle[key] = sessions[:len(sessions)-1]
+		if time.Since(s.lastUsed) < m.maxIdle && !s.broken.Load() {
+			m.targetStats(key.target).reuses++
+			m.mu.Unlock()
+			return s, nil
+		}
+		if s.closed {
+			continue
+		}
+		if s.broken.Load() {
+			m.targetStats(key.target).evictions["broken"]++
+		} else {
+			m.targetStats(key.target).evictions["idle_timeout"]++
+		}
+		go s.Close()
+	}
+	if f, ok := m.failures[key.target]; ok && time.Now().Before(f.openUntil) {
+		m.mu.Unlock()
+		return nil, &pausedError{target: key.target, remaining: time.Until(f.openUntil), failures: f.count}
+	}
+	m.mu.Unlock()
+
+	slots, err := m.acquire(ctx, key, conf.MaxSessions, wait)
+	if err != nil {
+		return nil, err
+	}
+	t, ns, err := m.dialCredentials(ctx, key, dc)
+	m.recordDial(ctx, key.target, err)
+	if err != nil {
+		m.free(slots)
+		return nil, err
+	}
+	return &Session{netconf: ns, transport: t, key: key, dial: dc, created: time.Now(), manager: m, slots: slots, maxSessions: conf.MaxSessions}, nil
+}
+
+// acquire takes a slot of the limit of open sessions overall and of the config of key, which allows
+// maxSessions sessions. Idle sessions are closed to free a slot before waiting for a session in use to be closed, or
+// failing with errSessionLimit unless wait is set.
+func (m *ConnectionManager) acquire(ctx context.Context, key connKey, maxSessions int, wait bool) ([]chan struct{}, error) {
+	m.mu.Lock()
+	var slots []chan struct{}
+	if m.sessionSlots != nil {
+		slots = append(slots, m.sessionSlots)
+	}
+	if maxSessions > 0 {
+		configSlots, ok := m.configSlots[key.config]
+		if !ok || cap(configSlots) != maxSessions {
+			configSlots = make(chan struct{}, maxSessions)
+			m.configSlots[key.config] = configSlots
+		}
+		slots = append(slots, configSlots)
+	}
+	m.mu.Unlock()
+
+	for i, slot := range slots {
+		config := ""
+		if i > 0 || m.sessionSlots == nil {
+			config = key.config
+		}
+		if err := m.take(ctx, slot, config, wait); err != nil {
+			m.free(slots[:i])
+			return nil, err
+		}
+	}
+	return slots, nil
+}
+
+// take takes a slot of slots, closing the least recently used idle session of config, or any config when empty, while
+// no slot is free.
+func (m *ConnectionManager) take(ctx context.Context, slots chan struct{}, config string, wait bool) error {
+	for {
+		select {
+		case slots <- struct{}{}:
+			return nil
+		default:
+		}
+		if !m.evictIdle(config) {
+			break
+		}
+	}
+	if !wait {
+		return errSessionLimit
+	}
+
+	m.mu.Lock()
+	m.waiting++
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.waiting--
+		m.mu.Unlock()
+	}()
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// evictIdle closes the least recently used idle session of config, or of any config when empty, returning false when
+// there is none.
+func (m *ConnectionManager) evictIdle(config string) bool {
+	m.mu.Lock()
+	var oldest *Session
+	var oldestKey connKey
+	oldestIndex := 0
+	for key, sessions := range m.idle {
+		if config != "" && key.config != config {
+			continue
+		}
+		for i, s := range sessions {
+			if !s.closed && (oldest == nil || s.lastUsed.Before(oldest.lastUsed)) {
+				oldest, oldestKey, oldestIndex = s, key, i
+			}
+		}
+	}
+	if oldest == nil {
+		m.mu.Unlock()
+		return false
+	}
+	sessions := m.idle[oldestKey]
+	m.idle[oldestKey] = append(sessions[:oldestIndex:oldestIndex], sessions[oldestIndex+1:]...)
+	m.targetStats(oldestKey.target).evictions["session_limit"]++
+	m.mu.Unlock()
+	oldest.Close()
+	return true
+}
+
+// free gives back the slots taken by acquire.
+func (m *ConnectionManager) free(slots []chan struct{}) {
+	for _, slot := range slots {
+		<-slot
+	}
+}
+
+// recordDial tracks the sessions and consecutive connection failures of target.
+func (m *ConnectionManager) recordDial(ctx context.Context, target string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.targetStats(target)
+	stats.dials++
+	if err == nil {
+		stats.open++
+	} else {
+		stats.dialErrors++
+	}
+
+	if m.failureThreshold <= 0 || (err != nil && ctx.Err() != nil) {
+		return
+	}
+	if err == nil {
+		delete(m.failures, target)
+		return
+	}
+	f, ok := m.failures[target]
+	if !ok {
+		f = &targetFailures{}
+		m.failures[target] = f
+	}
+	f.count++
+	if f.count >= m.failureThreshold {
+		f.openUntil = time.Now().Add(m.cooldown)
+		level.Debug(m.logger).Log("msg", "target unreachable, pausing connections", "target", target, "failures", f.count, "cooldown", m.cooldown)
+	}
+}
+
+// dialCredentials dials the target of key with the SSH configuration of dc and then each of its fallbacks while
+// authentication fails, starting with the configuration that last authenticated to the target.
+func (m *ConnectionManager) dialCredentials(ctx context.Context, key connKey, dc dialConfig) (netconfTransport, *netconf.Session, error) {
+	if key.replayDir != "" || key.tls {
+		return dial(ctx, key, dc, dc.sshConfig)
+	}
+	configs := append([]*ssh.ClientConfig{dc.sshConfig}, dc.fallbacks...)
+
+	m.mu.Lock()
+	first := m.credentials[key]
+	m.mu.Unlock()
+	if first >= len(configs) {
+		first = 0
+	}
+	order := []int{first}
+	for i := range configs {
+		if i != first {
+			order = append(order, i)
+		}
+	}
+
+	var err error
+	for _, i := range order {
+		var t netconfTransport
+		var ns *netconf.Session
+		t, ns, err = dial(ctx, key, dc, configs[i])
+		if err == nil {
+			m.mu.Lock()
+			m.credentials[key] = i
+			m.targetStats(key.target).credential = i
+			m.mu.Unlock()
+			return t, ns, nil
+		}
+		if !strings.Contains(err.Error(), "unable to authenticate") {
+			return nil, nil, err
+		}
+		if len(configs) > 1 {
+			level.Debug(m.logger).Log("msg", "could not authenticate, trying next credentials", "target", key.target, "credential", i, "err", err)
+		}
+	}
+	return nil, nil, err
+}
+
+// dial connects to the target of key using the TLS configuration and proxy of dc, or config for SSH, and establishes a
+// NETCONF session.
+func dial(ctx context.Context, key connKey, dc dialConfig, config *ssh.ClientConfig) (netconfTransport, *netconf.Session, error) {
+	start := time.Now()
+	defer func() { dialDurations.observe(key.target, time.Since(start)) }()
+
+	port := netconfPort
+	if key.tls {
+		port = netconfTLSPort
+	}
+	if key.port != 0 {
+		port = strconv.Itoa(key.port)
+	}
+	addr := targetAddress(key.target, port)
+
+	var t netconfTransport
+	var err error
+	if key.replayDir != "" {
+		t = newReplayTransport(key.replayDir, key.target)
+	} else if key.tls {
+		t, err = dialTLSTransport(ctx, addr, dc.proxyURL, dc.tlsConfig, config.Timeout)
+	} else {
+		t, err = dialSSHTransport(ctx, addr, dc.proxyURL, config)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { t.Close() })
+	ns, err := newNetconfSession(t)
+	if !stop() {
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		t.Close()
+		return nil, nil, err
+	}
+	t.SetDeadline(time.Time{})
+	return t, ns, nil
+}
+
+// targetStats returns the session statistics of target. m.mu must be held.
+func (m *ConnectionManager) targetStats(target string) *poolStats {
+	stats, ok := m.stats[target]
+	if !ok {
+		stats = &poolStats{evictions: map[string]float64{}, credential: -1}
+		m.stats[target] = stats
+	}
+	return stats
+}
+
+// forget removes the session statistics of target, unless sessions to it are open.
+func (m *ConnectionManager) forget(target string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stats, ok := m.stats[target]; ok && stats.open == 0 {
+		delete(m.stats, target)
+	}
+}
+
+// collect sends the session statistics of target, and the age of the session s used by the scrape when set.
+func (m *ConnectionManager) collect(target string, ch chan<- prometheus.Metric, s *Session) {
+	m.mu.Lock()
+	stats, ok := m.stats[target]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(poolDesc["SessionsOpen"], prometheus.GaugeValue, float64(stats.open)),
+		prometheus.MustNewConstMetric(poolDesc["SessionDials"], prometheus.CounterValue, stats.dials),
+		prometheus.MustNewConstMetric(poolDesc["SessionDialErrors"], prometheus.CounterValue, stats.dialErrors),
+		prometheus.MustNewConstMetric(poolDesc["SessionReuses"], prometheus.CounterValue, stats.reuses),
+	}
+	for reason, evictions := range stats.evictions {
+		metrics = append(metrics, prometheus.MustNewConstMetric(poolDesc["SessionEvictions"], prometheus.CounterValue, evictions, reason))
+	}
+	if stats.credential >= 0 {
+		metrics = append(metrics, prometheus.MustNewConstMetric(poolDesc["SessionCredential"], prometheus.GaugeValue, float64(stats.credential)))
+	}
+	m.mu.Unlock()
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+	if s != nil {
+		ch <- prometheus.MustNewConstMetric(poolDesc["SessionAge"], prometheus.GaugeValue, time.Since(s.created).Seconds())
+	}
+}
+
+// Release returns a session to the pool, or closes it if it is broken, reuse is disabled or new sessions are waiting for
+// a slot.
+func (m *ConnectionManager) Release(s *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.broken.Load() || m.maxIdle <= 0 || (m.waiting > 0 && len(s.slots) > 0) {
+		go s.Close()
+		return
+	}
+	s.lastUsed = time.Now()
+	m.idle[s.key] = append(m.idle[s.key], s)
+}
+
+// Responsive returns whether the mutex of the pool could be locked within timeout, which fails when the pool is stuck,
+// for the health checks of the exporter.
+func (m *ConnectionManager) Responsive(timeout time.Duration) bool {
+	locked := make(chan struct{})
+	go func() {
+		m.mu.Lock()
+		m.mu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// RetainConfigs closes the idle sessions and removes the session limits of the configs not in names, such as the
+// configs removed from the configuration file when it is reloaded.
+func (m *ConnectionManager) RetainConfigs(names []string) {
+	retained := map[string]bool{}
+	for _, name := range names {
+		retained[name] = true
+	}
+	m.mu.Lock()
+	var sessions []*Session
+	for key, idle := range m.idle {
+		if !retained[key.config] {
+			sessions = append(sessions, idle...)
+			delete(m.idle, key)
+		}
+	}
+	for key := range m.credentials {
+		if !retained[key.config] {
+			delete(m.credentials, key)
+		}
+	}
+	for name := range m.configSlots {
+		if !retained[name] {
+			delete(m.configSlots, name)
+		}
+	}
+	m.mu.Unlock()
+	for _, s := range sessions {
+		s.Close()
+	}
+}
+
+// Close closes all idle sessions.
+func (m *ConnectionManager) Close() {
+	m.mu.Lock()
+	var sessions []*Session
+	for key, idle := range m.idle {
+		sessions = append(sessions, idle...)
+		delete(m.idle, key)
+	}
+	m.mu.Unlock()
+	for _, s := range sessions {
+		s.Close()
+	}
+}
+
+// run evicts sessions that have been idle for longer than maxIdle and sends keepalives to the rest, which are taken
+// out of the pool while the keepalive is outstanding so that they are not handed to a scrape meanwhile.
+func (m *ConnectionManager) run() {
+	interval := m.keepalive
+	if interval <= 0 || interval > m.maxIdle {
+		interval = m.maxIdle
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		var alive []*Session
+		m.mu.Lock()
+		for key, sessions := range m.idle {
+			var keep []*Session
+			for _, s := range sessions {
+				if s.closed {
+					continue
+				}
+				if time.Since(s.lastUsed) >= m.maxIdle {
+					m.targetStats(key.target).evictions["idle_timeout"]++
+					go s.Close()
+					continue
+				}
+				keep = append(keep, s)
+			}
+			if len(keep) == 0 || m.keepalive > 0 {
+				delete(m.idle, key)
+			} else {
+				m.idle[key] = keep
+			}
+			alive = append(alive, keep...)
+		}
+		m.mu.Unlock()
+
+		if m.keepalive <= 0 {
+			continue
+		}
+		for _, s := range alive {
+			go m.sendKeepalive(s)
+		}
+	}
+}
+
+// sendKeepalive sends a keepalive on the idle session s, taken out of the pool, and returns it to the pool. The
+// session is closed instead when the keepalive fails, or when keepaliveMaxMissed consecutive keepalives were not
+// answered within the keepalive interval.
+func (m *ConnectionManager) sendKeepalive(s *Session) {
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.transport.keepalive() }()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-time.After(m.keepalive):
+		err = errKeepaliveTimeout
+	}
+
+	m.mu.Lock()
+	if err == nil {
+		s.missedKeepalives = 0
+		m.idle[s.key] = append(m.idle[s.key], s)
+		m.mu.Unlock()
+		return
+	}
+	if err == errKeepaliveTimeout {
+		s.missedKeepalives++
+		if missed := s.missedKeepalives; missed < m.keepaliveMaxMissed {
+			m.idle[s.key] = append(m.idle[s.key], s)
+			m.mu.Unlock()
+			level.Debug(m.logger).Log("msg", "keepalive not answered", "target", s.key.target, "missed", missed)
+			return
+		}
+	}
+	s.broken.Store(true)
+	m.targetStats(s.key.target).evictions["keepalive_failed"]++
+	m.mu.Unlock()
+	level.Debug(m.logger).Log("msg", "keepalive failed, closing session", "target", s.key.target, "err", err)
+	s.Close()
+}
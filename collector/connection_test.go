@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"golang.org/x/crypto/ssh"
+)
+
+// fakeTransport is a netconfTransport answering each RPC with the reply returned by reply.
+type fakeTransport struct {
+	reply   func(rpc string) string
+	request string
+}
+
+func (t *fakeTransport) Send(b []byte) error {
+	t.request = string(b)
+	return nil
+}
+
+func (t *fakeTransport) Receive() ([]byte, error) {
+	return []byte(t.reply(t.request)), nil
+}
+
+func (t *fakeTransport) Close() error {
+	return nil
+}
+
+func (t *fakeTransport) ReceiveHello() (*netconf.HelloMessage, error) {
+	return &netconf.HelloMessage{}, nil
+}
+
+func (t *fakeTransport) SendHello(*netconf.HelloMessage) error {
+	return nil
+}
+
+func (t *fakeTransport) SetVersion(string) {}
+
+func (t *fakeTransport) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (t *fakeTransport) keepalive() error {
+	return nil
+}
+
+// fakeSession returns a session to target of which the RPCs are answered by reply.
+func fakeSession(target string, reply func(rpc string) string) *Session {
+	t := &fakeTransport{reply: reply}
+	return &Session{netconf: &netconf.Session{Transport: t}, transport: t, key: connKey{target: target}, created: time.Now()}
+}
+
+// echoReply replies with the method of the RPC, or with an rpc-error for get-system-uptime-information.
+func echoReply(rpc string) string {
+	method := rpc[strings.Index(rpc, "<rpc ")+1:]
+	method = method[strings.Index(method, ">")+1 : strings.LastIndex(method, "</rpc>")]
+	if strings.Contains(method, "get-system-uptime-information") {
+		return `<rpc-reply><rpc-error><error-severity>error</error-severity><error-message>syntax error</error-message></rpc-error></rpc-reply>`
+	}
+	return "<rpc-reply>" + method + "</rpc-reply>"
+}
+
+func TestExecAll(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		methods     []string
+		parallelism int
+		// Index of the RPC failing with an rpc-error, -1 when none fails.
+		failed int
+		dials  float64
+	}{
+		{name: "sequential", methods: []string{"<get-bgp-summary-information/>", "<get-instance-information/>", "<get-route-engine-information/>"}, parallelism: 1, failed: -1},
+		{name: "sequential rpc-error", methods: []string{"<get-bgp-summary-information/>", "<get-system-uptime-information/>", "<get-instance-information/>"}, parallelism: 1, failed: 1},
+		// The RPCs are left to the session of the scrape as the additional sessions cannot be established.
+		{name: "parallel", methods: []string{"<get-bgp-summary-information/>", "<get-instance-information/>", "<get-route-engine-information/>"}, parallelism: 3, failed: -1, dials: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			connections := NewConnectionManager(0, 0, 0, 0, 0, 0, log.NewNopLogger())
+			conf := Config{
+				SSHTarget:       "127.0.0.1:1",
+				SSHClientConfig: &ssh.ClientConfig{Timeout: time.Second},
+				RPCParallelism:  tc.parallelism,
+				Connections:     connections,
+				Session:         fakeSession("127.0.0.1:1", echoReply),
+			}
+			var methods []netconf.RPCMethod
+			for _, m := range tc.methods {
+				methods = append(methods, netconf.RawMethod(m))
+			}
+
+			replies, errs := execAll(context.Background(), conf, methods...)
+			for i, want := range tc.methods {
+				switch {
+				case i == tc.failed:
+					var rpcErr *netconf.RPCError
+					if !errors.As(errs[i], &rpcErr) || replies[i] != nil {
+						t.Errorf("RPC %d: reply %v and error %v, want an rpc-error", i, replies[i], errs[i])
+					}
+				case errs[i] != nil:
+					t.Errorf("RPC %d: unexpected error %v", i, errs[i])
+				case !strings.Contains(replies[i].RawReply, want):
+					t.Errorf("RPC %d: reply %s, want %s", i, replies[i].RawReply, want)
+				}
+			}
+			connections.mu.Lock()
+			defer connections.mu.Unlock()
+			if dials := connections.targetStats("127.0.0.1:1").dials; dials > tc.dials {
+				t.Errorf("dialed %v sessions, want at most %v", dials, tc.dials)
+			}
+		})
+	}
+}
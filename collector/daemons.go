@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// Daemons of the route engine of which the restarts and CPU time are reported.
+	reDaemons = []string{"chassisd", "rpd", "dcd", "mgd"}
+
+	reDaemonsMu sync.Mutex
+	// Last process ID and restarts of each daemon (key) of each route engine of each target (key).
+	reDaemonStates = map[string]map[string]*reDaemonState{}
+)
+
+type reDaemonState struct {
+	pid      string
+	restarts float64
+}
+
+func getREDaemonDesc() (map[string]*prometheus.Desc, map[string]*prometheus.Desc) {
+	labels := []string{"daemon"}
+	multiRELabels := append(labels, "name")
+	return createREDaemonDesc(labels), createREDaemonDesc(multiRELabels)
+}
+
+func createREDaemonDesc(labels []string) map[string]*prometheus.Desc {
+	return map[string]*prometheus.Desc{
+		"restarts": colPromDesc(reSubsystem, "daemon_restarts", "Number of times the daemon was seen running under a new process ID since the exporter started.", labels),
+		"cpu":      colPromDesc(reSubsystem, "daemon_cpu_seconds", "CPU time used by the daemon since it started in seconds.", labels),
+	}
+}
+
+// reDaemonProcess is a daemon in the process list of show system processes.
+type reDaemonProcess struct {
+	pid        string
+	cpuSeconds float64
+}
+
+// parseREDaemons returns the daemons of reDaemons found in the output of show system processes, of which the columns
+// are PID, TT, STAT, TIME and COMMAND, such as "1698  -  S  2:53.51 /usr/sbin/chassisd -N".
+func parseREDaemons(output string) map[string]reDaemonProcess {
+	daemons := map[string]reDaemonProcess{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			continue
+		}
+		name := path.Base(fields[4])
+		for _, daemon := range reDaemons {
+			if name != daemon {
+				continue
+			}
+			// The first process is the daemon itself, others are its children.
+			if _, ok := daemons[daemon]; !ok {
+				daemons[daemon] = reDaemonProcess{pid: fields[0], cpuSeconds: parseCPUTime(fields[3])}
+			}
+		}
+	}
+	return daemons
+}
+
+// parseCPUTime returns the seconds of a CPU time formatted as [hours:]minutes:seconds, -1 when it cannot be parsed.
+func parseCPUTime(cpuTime string) float64 {
+	seconds, err := parseDuration(cpuTime)
+	if err != nil {
+		return -1
+	}
+	return seconds
+}
+
+// sendREDaemonMetrics sends the metrics of the daemons of the route engine, identified by key within the target, and
+// counts the daemons of which the process ID changed since the last scrape of the target as restarted.
+func sendREDaemonMetrics(ch chan<- prometheus.Metric, desc map[string]*prometheus.Desc, target string, key string, output string, labels ...string) {
+	reDaemonsMu.Lock()
+	defer reDaemonsMu.Unlock()
+
+	stateKey := target + "/" + key
+	states, ok := reDaemonStates[stateKey]
+	if !ok {
+		states = map[string]*reDaemonState{}
+		reDaemonStates[stateKey] = states
+	}
+	for daemon, process := range parseREDaemons(output) {
+		state, ok := states[daemon]
+		if !ok {
+			state = &reDaemonState{pid: process.pid}
+			states[daemon] = state
+		}
+		if state.pid != process.pid {
+			state.pid = process.pid
+			state.restarts++
+		}
+		daemonLabels := append([]string{daemon}, labels...)
+		ch <- prometheus.MustNewConstMetric(desc["restarts"], prometheus.CounterValue, state.restarts, daemonLabels...)
+		if process.cpuSeconds >= 0 {
+			ch <- prometheus.MustNewConstMetric(desc["cpu"], prometheus.CounterValue, process.cpuSeconds, daemonLabels...)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Characters of description keys that are not valid in label and metric names.
+var descrKeyInvalidRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// parseDescrJSON returns the keys of a JSON formatted description, nil when the description is not a JSON object.
+// Numbers are kept as json.Number so they are formatted as written in the description.
+func parseDescrJSON(description string) map[string]interface{} {
+	d := json.NewDecoder(strings.NewReader(description))
+	d.UseNumber()
+	var keys map[string]interface{}
+	if err := d.Decode(&keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// descrValue returns the value of the key at path in the parsed JSON description, where the keys of nested objects
+// are separated by dots, such as svc.cktid. Keys containing dots are matched as a whole first. Values that are not
+// strings are converted to their JSON representation. false is returned when the key does not exist or is null.
+func descrValue(keys map[string]interface{}, path string) (string, bool) {
+	if value, ok := keys[path]; ok {
+		return descrString(value)
+	}
+	for i := strings.IndexByte(path, '.'); i >= 0; i = nextDot(path, i) {
+		if nested, ok := keys[path[:i]].(map[string]interface{}); ok {
+			if value, ok := descrValue(nested, path[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+func nextDot(path string, i int) int {
+	j := strings.IndexByte(path[i+1:], '.')
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+func descrString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		var b bytes.Buffer
+		e := json.NewEncoder(&b)
+		e.SetEscapeHTML(false)
+		if err := e.Encode(v); err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(b.String()), true
+	}
+}
+
+func descrLabelNames(keys []string) []string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, descrLabelName(key))
+	}
+	return names
+}
+
+// descrLabelName returns the label or metric name of a description key, replacing the dots of nested keys and other
+// characters that are not allowed with underscores.
+func descrLabelName(key string) string {
+	return descrKeyInvalidRegexp.ReplaceAllString(key, "_")
+}
@@ -1,7 +1,7 @@
 package collector
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"strings"
 
@@ -13,10 +13,17 @@ import (
 var (
 	envSubsystem = "environment"
 
-	envLabels = []string{"module"}
-	envDesc   = map[string]*prometheus.Desc{
-		"Status":            colPromDesc(envSubsystem, "module_state", "Module Environmental State (1 = OK, 0 = Not OK).", envLabels),
-		"Temp":              colPromDesc(envSubsystem, "module_temperature_celsius", "Module Temperature in Celsius", envLabels),
+	envDesc = createEnvDesc(nil)
+	// Descriptions of the metrics of each member of virtual chassis and chassis clusters, labeled with the member.
+	envMemberDesc = createEnvDesc([]string{"member"})
+)
+
+func createEnvDesc(prefix []string) map[string]*prometheus.Desc {
+	envLabels := memberLabels(prefix, "module")
+	envItemLabels := memberLabels(envLabels, "class", "airflow")
+	return map[string]*prometheus.Desc{
+		"Status":            colPromDesc(envSubsystem, "module_state", "Module Environmental State (1 = OK, 0 = Not OK).", envItemLabels),
+		"Temp":              colPromDesc(envSubsystem, "module_temperature_celsius", "Module Temperature in Celsius", envItemLabels),
 		"FanNormalSpeed":    colPromDesc(envSubsystem, "module_fan_normal_speed_temperature_celsius", "Fan Normal Speed Temperature Threshold", envLabels),
 		"FanHighSpeed":      colPromDesc(envSubsystem, "module_fan_high_speed_temperature_celsius", "Fan High Speed Temperature Threshold", envLabels),
 		"BadFanYellowAlarm": colPromDesc(envSubsystem, "module_bad_fan_yellow_alarm_temperature_celsius", "Bad Fan Yellow Alarm Temperature Threshold", envLabels),
@@ -25,9 +32,7 @@ var (
 		"RedAlarm":          colPromDesc(envSubsystem, "module_red_alarm_temperature_celsius", "Red Alarm Temperature Threshold", envLabels),
 		"FireShutdown":      colPromDesc(envSubsystem, "module_fire_shutdown_temperature_celsius", "Fire Shutdown Temperature Threshold", envLabels),
 	}
-
-	totalEnvErrors = 0.0
-)
+}
 
 // EnvCollector collects environment metrics, implemented as per the Collector interface.
 type EnvCollector struct {
@@ -44,39 +49,50 @@ func (*EnvCollector) Name() string {
 	return envSubsystem
 }
 
+// RPCs executed by the collector.
+func (*EnvCollector) RPCs() []string {
+	return []string{"get-environment-information", "get-temperature-threshold-information"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *EnvCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *EnvCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
-	if err != nil {
-		totalEnvErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalEnvErrors
+	replies, errs := execAll(ctx, conf,
+		// show chassis environment
+		netconf.RawMethod(`<get-environment-information/>`),
+		// show chassis temperature-threshold
+		netconf.RawMethod(`<get-temperature-threshold-information/>`),
+	)
+	// The metrics of the RPC that succeeded are still exported when the other failed, leaving its reply nil.
+	for _, err := range errs {
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		}
 	}
-	defer s.Close()
 
-	// show chassis environment
-	replyEnv, err := s.Exec(netconf.RawMethod(`<get-environment-information/>`))
-	if err != nil {
-		totalEnvErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalEnvErrors
+	if err := processEnvNetconfReply(replies[0], replies[1], ch, c.logger); err != nil {
+		errors = append(errors, err)
 	}
 
-	// show chassis temperature-threshold
-	replyEnvTempThreshold, err := s.Exec(netconf.RawMethod(`<get-temperature-threshold-information/>`))
+	return errors
+}
+
+// GetGNMI gets metrics from the OpenConfig platform model and sends to the Prometheus.Metric channel.
+func (c *EnvCollector) GetGNMI(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+
+	leaves, err := conf.GNMI.Get(ctx, conf.SSHTarget, "/components/component/state/temperature")
 	if err != nil {
-		totalEnvErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalEnvErrors
+		errors = append(errors, fmt.Errorf("could not execute gnmi get: %w", err))
+		return errors
 	}
-
-	if err := processEnvNetconfReply(replyEnv, replyEnvTempThreshold, ch, c.logger); err != nil {
-		totalEnvErrors++
-		errors = append(errors, err)
+	for _, leaf := range leaves {
+		name, ok := leaf.key("component", "name")
+		if ok && leaf.parent() == "temperature" && leaf.name() == "instant" {
+			newGauge(c.logger, ch, envDesc["Temp"], leaf.value, name, "Temp", envAirflow(name))
+		}
 	}
-
-	return errors, totalEnvErrors
+	return errors
 }
 
 func processEnvNetconfReply(
@@ -85,57 +101,90 @@ func processEnvNetconfReply(
 	ch chan<- prometheus.Metric,
 	logger log.Logger,
 ) error {
-	var netconfEnvReply envRPCReply
-	var netconfEnvTempThresholdReply envTempThresholdRPCReply
-
 	// ** unmarshal show chassis environment <get-environment-information> START ** //
-	if err := xml.Unmarshal([]byte(replyEnv.RawReply), &netconfEnvReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
-	}
-	for _, envData := range netconfEnvReply.EnvInformation.EnvironmentItem {
-		labels := []string{strings.TrimSpace(envData.Name.Text)}
-		envStatus := 0.0
-		if envData.Status.Text == "OK" {
-			envStatus = 1.0
+	// Either reply is nil when its RPC failed. Virtual chassis and chassis clusters reply for each member.
+	if replyEnv != nil {
+		err := decodeMemberElements(replyEnv, "environment-information", func(member string, info *envInformation) error {
+			desc, prefix := envMemberDescs(member)
+			// The class is only set on the first item of each class, such as Temp, Fans or Power.
+			class := ""
+			for _, envData := range info.EnvironmentItem {
+				if c := strings.TrimSpace(envData.Class.Text); c != "" {
+					class = c
+				}
+				name := strings.TrimSpace(envData.Name.Text)
+				labels := memberLabels(prefix, name, class, envAirflow(name))
+				envStatus := 0.0
+				if envData.Status.Text == "OK" {
+					envStatus = 1.0
+				}
+				ch <- prometheus.MustNewConstMetric(desc["Status"], prometheus.GaugeValue, envStatus, labels...)
+				newGauge(logger, ch, desc["Temp"], envData.Temperature.Temp, labels...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
-		ch <- prometheus.MustNewConstMetric(envDesc["Status"], prometheus.GaugeValue, envStatus, labels...)
-		newGauge(logger, ch, envDesc["Temp"], envData.Temperature.Temp, labels...)
 	}
 	// ** unmarshal show chassis temperature-thresholds <get-environment-information> END ** //
 
 	// ** unmarshal show chassis temperature-thresholds <get-temperature-threshold-information> START ** //
-	if err := xml.Unmarshal([]byte(replyEnvTempThreshold.RawReply), &netconfEnvTempThresholdReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
-	}
-
-	for _, envTempThresholdData := range netconfEnvTempThresholdReply.EnvTempThresholdInformation.EnvTempThreshold {
-		labels := []string{strings.TrimSpace(envTempThresholdData.Name.Text)}
-
-		newGauge(logger, ch, envDesc["FanNormalSpeed"], envTempThresholdData.FanNormalSpeed.Text, labels...)
-		newGauge(logger, ch, envDesc["FanHighSpeed"], envTempThresholdData.FanHighSpeed.Text, labels...)
-		newGauge(logger, ch, envDesc["BadFanYellowAlarm"], envTempThresholdData.BadFanYellowAlarm.Text, labels...)
-		newGauge(logger, ch, envDesc["BadFanRedAlarm"], envTempThresholdData.BadFanRedAlarm.Text, labels...)
-		newGauge(logger, ch, envDesc["YellowAlarm"], envTempThresholdData.YellowAlarm.Text, labels...)
-		newGauge(logger, ch, envDesc["RedAlarm"], envTempThresholdData.RedAlarm.Text, labels...)
-		newGauge(logger, ch, envDesc["FireShutdown"], envTempThresholdData.FireShutdown.Text, labels...)
+	if replyEnvTempThreshold != nil {
+		err := decodeMemberElements(replyEnvTempThreshold, "temperature-threshold-information", func(member string, info *EnvTempThresholdInformation) error {
+			desc, prefix := envMemberDescs(member)
+			for _, envTempThresholdData := range info.EnvTempThreshold {
+				labels := memberLabels(prefix, strings.TrimSpace(envTempThresholdData.Name.Text))
+
+				newGauge(logger, ch, desc["FanNormalSpeed"], envTempThresholdData.FanNormalSpeed.Text, labels...)
+				newGauge(logger, ch, desc["FanHighSpeed"], envTempThresholdData.FanHighSpeed.Text, labels...)
+				newGauge(logger, ch, desc["BadFanYellowAlarm"], envTempThresholdData.BadFanYellowAlarm.Text, labels...)
+				newGauge(logger, ch, desc["BadFanRedAlarm"], envTempThresholdData.BadFanRedAlarm.Text, labels...)
+				newGauge(logger, ch, desc["YellowAlarm"], envTempThresholdData.YellowAlarm.Text, labels...)
+				newGauge(logger, ch, desc["RedAlarm"], envTempThresholdData.RedAlarm.Text, labels...)
+				newGauge(logger, ch, desc["FireShutdown"], envTempThresholdData.FireShutdown.Text, labels...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	// ** unmarshal show chassis temperature-thresholds <get-temperature-threshold-information> END ** //
 	return nil
 }
 
-// ********************* show chassis environment START ********************* //
-type envRPCReply struct {
-	EnvInformation envInformation `xml:"environment-information"`
+// envAirflow returns whether the sensor named name measures the ambient temperature of the intake or exhaust air of
+// the chassis, such as "FPC 0 Intake" or "CB 0 Exhaust A", and empty for other sensors.
+func envAirflow(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "intake"), strings.Contains(name, "inlet"):
+		return "intake"
+	case strings.Contains(name, "exhaust"), strings.Contains(name, "outlet"):
+		return "exhaust"
+	}
+	return ""
+}
+
+// envMemberDescs returns the descriptions of the metrics of member, along with the member label, or envDesc without
+// labels when the device has no members.
+func envMemberDescs(member string) (map[string]*prometheus.Desc, []string) {
+	if member == "" {
+		return envDesc, nil
+	}
+	return envMemberDesc, []string{member}
 }
 
+// ********************* show chassis environment START ********************* //
 type envInformation struct {
 	EnvironmentItem []envItem `xml:"environment-item"`
 }
 
 type envItem struct {
-	Name envText `xml:"name"`
-	// Class       envText `xml:"class"`
+	Name        envText `xml:"name"`
+	Class       envText `xml:"class"`
 	Status      envText `xml:"status"`
 	Temperature envTemp `xml:"temperature"`
 	// Comment     envText `xml:"comment"`
@@ -152,10 +201,6 @@ type envTemp struct {
 // ********************* show chassis environment END ********************* //
 
 // ********************* show chassis temperature-thresholds START ********************* //
-type envTempThresholdRPCReply struct {
-	EnvTempThresholdInformation EnvTempThresholdInformation `xml:"temperature-threshold-information"`
-}
-
 type EnvTempThresholdInformation struct {
 	EnvTempThreshold []EnvTempThreshold `xml:"temperature-threshold"`
 }
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	flowSubsystem = "security_flow"
+
+	flowLabels      = []string{"spu"}
+	flowStateLabels = []string{"spu", "state"}
+	flowDesc        = map[string]*prometheus.Desc{
+		"Gates":             colPromDesc(flowSubsystem, "gates", "Number of gates of the SPU per state.", flowStateLabels),
+		"Sessions":          colPromDesc(flowSubsystem, "sessions", "Number of sessions of the SPU per state.", flowStateLabels),
+		"ActiveSessions":    colPromDesc(flowSubsystem, "active_sessions", "Number of sessions in use by the SPU.", flowLabels),
+		"UnicastSessions":   colPromDesc(flowSubsystem, "unicast_sessions", "Number of active unicast sessions of the SPU.", flowLabels),
+		"MulticastSessions": colPromDesc(flowSubsystem, "multicast_sessions", "Number of active multicast sessions of the SPU.", flowLabels),
+		"FailedSessions":    colPromDesc(flowSubsystem, "failed_sessions", "Number of sessions the SPU failed to create.", flowLabels),
+		"MaxSessions":       colPromDesc(flowSubsystem, "max_sessions", "Maximum number of sessions of the SPU.", flowLabels),
+	}
+)
+
+// FlowCollector collects the gates and sessions of the flow module of each SPU of SRX devices, implemented as per the
+// Collector interface.
+type FlowCollector struct {
+	logger log.Logger
+}
+
+// NewFlowCollector returns a new FlowCollector.
+func NewFlowCollector(logger log.Logger) *FlowCollector {
+	return &FlowCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*FlowCollector) Name() string {
+	return flowSubsystem
+}
+
+// RPCs executed by the collector.
+func (*FlowCollector) RPCs() []string {
+	return []string{"get-flow-gate-information", "get-flow-session-information"}
+}
+
+// UnsupportedPlatforms returns the platforms without security flows, as per the PlatformLimiter interface.
+func (*FlowCollector) UnsupportedPlatforms() []string {
+	return []string{"ex", "qfx", "ptx", "acx", "evo"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *FlowCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	replies, errs := execAll(ctx, conf,
+		// show security flow gate summary
+		netconf.RawMethod(`<get-flow-gate-information><summary/></get-flow-gate-information>`),
+		// show security flow session summary
+		netconf.RawMethod(`<get-flow-session-information><summary/></get-flow-session-information>`),
+	)
+	for i, reply := range replies {
+		if errs[i] != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", errs[i]))
+			continue
+		}
+		if err := processFlowNetconfReply(reply, ch, c.logger); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
+// processFlowNetconfReply sends the gate and session summaries of a reply. The summary of each SPU follows the
+// flow-fpc-pic-id element naming the SPU, such as "on FPC0 PIC1:", which is absent on branch devices with a single SPU.
+// The SPUs of each node of a chassis cluster are prefixed with the name of the node.
+func processFlowNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	root, err := parseXMLNode(reply.RawReply)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+	var node, spu string
+	var walk func(n *xmlNode)
+	walk = func(n *xmlNode) {
+		for _, child := range n.children {
+			switch {
+			case child.name == "re-name":
+				node, spu = strings.TrimSpace(child.content), ""
+			case child.name == "flow-fpc-pic-id":
+				spu = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(child.content), "on "), ":")
+			case child.name == "flow-session-summary-information":
+				sendFlowSessions(ch, child, flowSPULabel(node, spu), logger)
+			case strings.HasPrefix(child.name, "flow-gate-summary"):
+				sendFlowGates(ch, child, flowSPULabel(node, spu), logger)
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+	return nil
+}
+
+func flowSPULabel(node string, spu string) string {
+	if node == "" {
+		return spu
+	}
+	if spu == "" {
+		return node
+	}
+	return node + " " + spu
+}
+
+// sendFlowGates sends the number of gates of each state of the summary, such as valid-gates, the total being their sum.
+func sendFlowGates(ch chan<- prometheus.Metric, summary *xmlNode, spu string, logger log.Logger) {
+	for _, child := range summary.children {
+		state := strings.TrimSuffix(child.name, "-gates")
+		if state == child.name || state == "total" {
+			continue
+		}
+		newGauge(logger, ch, flowDesc["Gates"], strings.TrimSpace(child.content), spu, state)
+	}
+}
+
+// sendFlowSessions sends the session summary, the sessions in use being split per state by the active-session-<state>
+// elements, such as active-session-valid.
+func sendFlowSessions(ch chan<- prometheus.Metric, summary *xmlNode, spu string, logger log.Logger) {
+	for _, child := range summary.children {
+		if state := strings.TrimPrefix(child.name, "active-session-"); state != child.name {
+			newGauge(logger, ch, flowDesc["Sessions"], strings.TrimSpace(child.content), spu, state)
+		}
+	}
+	newGauge(logger, ch, flowDesc["ActiveSessions"], summary.text("active-sessions"), spu)
+	newGauge(logger, ch, flowDesc["UnicastSessions"], summary.text("active-unicast-sessions"), spu)
+	newGauge(logger, ch, flowDesc["MulticastSessions"], summary.text("active-multicast-sessions"), spu)
+	newCounter(logger, ch, flowDesc["FailedSessions"], summary.text("failed-sessions"), spu)
+	newGauge(logger, ch, flowDesc["MaxSessions"], summary.text("max-sessions"), spu)
+}
@@ -1,7 +1,7 @@
 package collector
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"strings"
 
@@ -13,11 +13,21 @@ import (
 var (
 	fpcSubsystem = "fpc"
 
-	fpcLabels    = []string{"slot"}
-	fpcCPULabels = append(fpcLabels, "timespan")
+	fpcDesc = createFPCDesc(nil)
+	// Descriptions of the metrics of each member of virtual chassis and chassis clusters, labeled with the member.
+	fpcMemberDesc = createFPCDesc([]string{"member"})
+)
+
+func createFPCDesc(prefix []string) map[string]*prometheus.Desc {
+	fpcLabels := memberLabels(prefix, "slot")
+	fpcStateLabels := memberLabels(fpcLabels, "state")
+	fpcCPULabels := memberLabels(fpcLabels, "timespan")
+	fpcPFELabels := memberLabels(fpcLabels, "pfe")
+	fpcPFEExceptLabels := memberLabels(fpcPFELabels, "reason", "type")
 
-	fpcDesc = map[string]*prometheus.Desc{
-		"State":            colPromDesc(fpcSubsystem, "state", "State (0 = Offline, 1 = Online, 2 = Empty, 4 = Other).", fpcLabels),
+	return map[string]*prometheus.Desc{
+		"State":            colPromDesc(fpcSubsystem, "state", "State (0 = Offline, 1 = Online, 3 = Empty or Other).", fpcLabels),
+		"StateInfo":        colPromDesc(fpcSubsystem, "state_info", "State of the slot as a label, always 1.", fpcStateLabels),
 		"Temp":             colPromDesc(fpcSubsystem, "temperature_celsius", "Temperature in Celsius", fpcLabels),
 		"CPUTotal":         colPromDesc(fpcSubsystem, "cpu_total", "Total CPU utilization.", fpcLabels),
 		"CPUInterrupt":     colPromDesc(fpcSubsystem, "cpu_interrupt", "CPU Interrupt utilization.", fpcLabels),
@@ -25,10 +35,16 @@ var (
 		"MemoryDramSize":   colPromDesc(fpcSubsystem, "memory_dram_size", "Memory DRAM Size.", fpcLabels),
 		"MemoryHeapUtil":   colPromDesc(fpcSubsystem, "memory_heap_utilization", "Memory heap utilization.", fpcLabels),
 		"MemoryBufferUtil": colPromDesc(fpcSubsystem, "memory_buffer_utilization", "Memory buffer utilization.", fpcLabels),
+		"Uptime":           colPromDesc(fpcSubsystem, "uptime_seconds", "Uptime in seconds.", fpcLabels),
+		"CPUDRAM":          colPromDesc(fpcSubsystem, "memory_cpu_dram_bytes", "Total CPU DRAM in bytes.", fpcLabels),
+		"RLDRAM":           colPromDesc(fpcSubsystem, "memory_rldram_bytes", "Total RLDRAM in bytes.", fpcLabels),
+		"DDRDRAM":          colPromDesc(fpcSubsystem, "memory_ddr_dram_bytes", "Total DDR DRAM in bytes.", fpcLabels),
+		"MaxPower":         colPromDesc(fpcSubsystem, "max_power_consumption_watts", "Maximum power consumption in Watts.", fpcLabels),
+		"PFEHeapUtil":      colPromDesc(fpcSubsystem, "pfe_memory_heap_utilization", "PFE ukern memory heap utilization.", fpcPFELabels),
+		"PFEExcPackets":    colPromDesc(fpcSubsystem, "pfe_exception_packets", "Number of packets handled as a PFE exception.", fpcPFEExceptLabels),
+		"PFEExcBytes":      colPromDesc(fpcSubsystem, "pfe_exception_bytes", "Number of bytes handled as a PFE exception.", fpcPFEExceptLabels),
 	}
-
-	totalFPCErrors = 0.0
-)
+}
 
 // FPCCollector collects environment metrics, implemented as per the Collector interface.
 type FPCCollector struct {
@@ -45,71 +61,119 @@ func (*FPCCollector) Name() string {
 	return fpcSubsystem
 }
 
+// RPCs executed by the collector.
+func (*FPCCollector) RPCs() []string {
+	return []string{"get-fpc-information", "get-pfe-exceptions-statistics"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *FPCCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *FPCCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-fpc-information/>`))
 	if err != nil {
-		totalFPCErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalFPCErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processFPCNetconfReply(reply, ch); err != nil {
+		errors = append(errors, err)
 	}
-	defer s.Close()
 
-	reply, err := s.Exec(netconf.RawMethod(`<get-fpc-information/>`))
+	// show chassis fpc detail
+	replyDetail, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-fpc-information><detail/></get-fpc-information>`))
 	if err != nil {
-		totalFPCErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalFPCErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processFPCDetailNetconfReply(replyDetail, ch, c.logger); err != nil {
+		errors = append(errors, err)
 	}
 
-	if err := processFPCNetconfReply(reply, ch); err != nil {
-		totalFPCErrors++
+	// show pfe statistics exceptions
+	replyExceptions, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-pfe-exceptions-statistics/>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processPFEExceptionsNetconfReply(replyExceptions, ch, c.logger); err != nil {
 		errors = append(errors, err)
 	}
 
-	return errors, totalFPCErrors
+	return errors
 }
 
+// processFPCNetconfReply sends the metrics of the FPCs of each member of virtual chassis and chassis clusters, which
+// reply for each member.
 func processFPCNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
-	var netconfReply fpcRPCReply
+	return decodeMemberElements(reply, "fpc-information", func(member string, info *fpcInformation) error {
+		desc, prefix := fpcMemberDescs(member)
+		for _, data := range info.FPCItem {
+			labels := memberLabels(prefix, strings.TrimSpace(data.Slot))
+			state := 3.0
+			if strings.ToLower(data.State) == "offline" {
+				state = 0.0
+			} else if strings.ToLower(data.State) == "online" {
+				state = 1.0
+				ch <- prometheus.MustNewConstMetric(desc["Temp"], prometheus.GaugeValue, data.Temperature, labels...)
+				ch <- prometheus.MustNewConstMetric(desc["CPUTotal"], prometheus.GaugeValue, data.CPUTotal, labels...)
+				ch <- prometheus.MustNewConstMetric(desc["CPUInterrupt"], prometheus.GaugeValue, data.CPUInterrupt, labels...)
+
+				label1m := append(labels, "1m")
+				ch <- prometheus.MustNewConstMetric(desc["CPUAvg"], prometheus.GaugeValue, data.CPU1MAvg, label1m...)
+
+				label5m := append(labels, "5m")
+				ch <- prometheus.MustNewConstMetric(desc["CPUAvg"], prometheus.GaugeValue, data.CPU5MinAvg, label5m...)
+
+				label15m := append(labels, "15m")
+				ch <- prometheus.MustNewConstMetric(desc["CPUAvg"], prometheus.GaugeValue, data.CPU15MinAvg, label15m...)
+
+				ch <- prometheus.MustNewConstMetric(desc["MemoryDramSize"], prometheus.GaugeValue, data.MemoryDRAMSize, labels...)
+				ch <- prometheus.MustNewConstMetric(desc["MemoryHeapUtil"], prometheus.GaugeValue, data.MemoryHeapUtilization, labels...)
+				ch <- prometheus.MustNewConstMetric(desc["MemoryBufferUtil"], prometheus.GaugeValue, data.MemoryBufferUtilization, labels...)
+			} else if strings.ToLower(data.State) == "empty" {
+				state = 3.0
+			}
+			ch <- prometheus.MustNewConstMetric(desc["State"], prometheus.GaugeValue, state, labels...)
+			ch <- prometheus.MustNewConstMetric(desc["StateInfo"], prometheus.GaugeValue, 1, append(labels, strings.TrimSpace(data.State))...)
+		}
+		return nil
+	})
+}
 
-	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
-	}
-	for _, data := range netconfReply.FPCInformation.FPCItem {
-		labels := []string{strings.TrimSpace(data.Slot)}
-		state := 3.0
-		if strings.ToLower(data.State) == "offline" {
-			state = 0.0
-		} else if strings.ToLower(data.State) == "online" {
-			state = 1.0
-			ch <- prometheus.MustNewConstMetric(fpcDesc["Temp"], prometheus.GaugeValue, data.Temperature, labels...)
-			ch <- prometheus.MustNewConstMetric(fpcDesc["CPUTotal"], prometheus.GaugeValue, data.CPUTotal, labels...)
-			ch <- prometheus.MustNewConstMetric(fpcDesc["CPUInterrupt"], prometheus.GaugeValue, data.CPUInterrupt, labels...)
-
-			label1m := append(labels, "1m")
-			ch <- prometheus.MustNewConstMetric(fpcDesc["CPUAvg"], prometheus.GaugeValue, data.CPU1MAvg, label1m...)
-
-			label5m := append(labels, "5m")
-			ch <- prometheus.MustNewConstMetric(fpcDesc["CPUAvg"], prometheus.GaugeValue, data.CPU5MinAvg, label5m...)
-
-			label15m := append(labels, "15m")
-			ch <- prometheus.MustNewConstMetric(fpcDesc["CPUAvg"], prometheus.GaugeValue, data.CPU15MinAvg, label15m...)
-
-			ch <- prometheus.MustNewConstMetric(fpcDesc["MemoryDramSize"], prometheus.GaugeValue, data.MemoryDRAMSize, labels...)
-			ch <- prometheus.MustNewConstMetric(fpcDesc["MemoryHeapUtil"], prometheus.GaugeValue, data.MemoryHeapUtilization, labels...)
-			ch <- prometheus.MustNewConstMetric(fpcDesc["MemoryBufferUtil"], prometheus.GaugeValue, data.MemoryBufferUtilization, labels...)
-		} else if strings.ToLower(data.State) == "empty" {
-			state = 3.0
+func processFPCDetailNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	return decodeMemberElements(reply, "fpc-information", func(member string, info *fpcDetailInformation) error {
+		desc, prefix := fpcMemberDescs(member)
+		for _, data := range info.FPCItem {
+			labels := memberLabels(prefix, strings.TrimSpace(data.Slot))
+			newGauge(logger, ch, desc["Uptime"], data.UpTime.Seconds, labels...)
+			newGaugeSize(logger, ch, desc["CPUDRAM"], data.MemoryDRAMSize, megabyte, labels...)
+			newGaugeSize(logger, ch, desc["RLDRAM"], data.MemoryRLDRAMSize, megabyte, labels...)
+			newGaugeSize(logger, ch, desc["DDRDRAM"], data.MemoryDDRDRAMSize, megabyte, labels...)
+			newGauge(logger, ch, desc["MaxPower"], data.MaxPowerConsumption, labels...)
+			for _, pfe := range data.PFE {
+				pfeLabels := append(labels, strings.TrimSpace(pfe.Slot))
+				newGauge(logger, ch, desc["PFEHeapUtil"], pfe.MemoryHeapUtilization, pfeLabels...)
+			}
 		}
-		ch <- prometheus.MustNewConstMetric(fpcDesc["State"], prometheus.GaugeValue, state, labels...)
-	}
-	return nil
+		return nil
+	})
+}
+
+func processPFEExceptionsNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	return decodeMemberElements(reply, "pfe-exceptions-statistics-information", func(member string, info *pfeExceptionsInformation) error {
+		desc, prefix := fpcMemberDescs(member)
+		for _, pfe := range info.PFEItem {
+			pfeLabels := memberLabels(prefix, strings.TrimSpace(pfe.FPCSlot), strings.TrimSpace(pfe.PFESlot))
+			for _, exception := range pfe.Exception {
+				labels := memberLabels(pfeLabels, strings.TrimSpace(exception.Reason), strings.TrimSpace(exception.Type))
+				newCounter(logger, ch, desc["PFEExcPackets"], exception.Packets, labels...)
+				newCounter(logger, ch, desc["PFEExcBytes"], exception.Bytes, labels...)
+			}
+		}
+		return nil
+	})
 }
 
-type fpcRPCReply struct {
-	FPCInformation fpcInformation `xml:"fpc-information"`
+// fpcMemberDescs returns the descriptions of the metrics of member, along with the member label, or fpcDesc without
+// labels when the device has no members.
+func fpcMemberDescs(member string) (map[string]*prometheus.Desc, []string) {
+	if member == "" {
+		return fpcDesc, nil
+	}
+	return fpcMemberDesc, []string{member}
 }
 
 type fpcInformation struct {
@@ -129,3 +193,50 @@ type fpcItem struct {
 	MemoryHeapUtilization   float64 `xml:"memory-heap-utilization"`
 	MemoryBufferUtilization float64 `xml:"memory-buffer-utilization"`
 }
+
+// ********************* show chassis fpc detail START ********************* //
+type fpcDetailInformation struct {
+	FPCItem []fpcDetailItem `xml:"fpc"`
+}
+
+type fpcDetailItem struct {
+	Slot                string       `xml:"slot"`
+	State               string       `xml:"state"`
+	UpTime              fpcSeconds   `xml:"up-time"`
+	MemoryDRAMSize      string       `xml:"memory-dram-size"`
+	MemoryRLDRAMSize    string       `xml:"memory-rldram-size"`
+	MemoryDDRDRAMSize   string       `xml:"memory-ddr-dram-size"`
+	MaxPowerConsumption string       `xml:"max-power-consumption"`
+	PFE                 []fpcPFEItem `xml:"pfe-information"`
+}
+
+type fpcPFEItem struct {
+	Slot                  string `xml:"pfe-slot"`
+	MemoryHeapUtilization string `xml:"memory-heap-utilization"`
+}
+
+type fpcSeconds struct {
+	Seconds string `xml:"seconds,attr"`
+}
+
+// ********************* show chassis fpc detail END ********************* //
+
+// ********************* show pfe statistics exceptions START ********************* //
+type pfeExceptionsInformation struct {
+	PFEItem []pfeExceptionsItem `xml:"pfe-exceptions-statistics"`
+}
+
+type pfeExceptionsItem struct {
+	FPCSlot   string         `xml:"fpc-slot"`
+	PFESlot   string         `xml:"pfe-slot"`
+	Exception []pfeException `xml:"pfe-exception"`
+}
+
+type pfeException struct {
+	Reason  string `xml:"exception-reason"`
+	Type    string `xml:"exception-type"`
+	Packets string `xml:"exception-packets"`
+	Bytes   string `xml:"exception-bytes"`
+}
+
+// ********************* show pfe statistics exceptions END ********************* //
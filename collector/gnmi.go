@@ -0,0 +1,404 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// GNMICollector is implemented by collectors that can also collect their metrics using gNMI.
+type GNMICollector interface {
+	// Gets metrics using gNMI and sends to the Prometheus.Metric channel.
+	GetGNMI(ctx context.Context, ch chan<- prometheus.Metric, config Config) []error
+}
+
+// GNMIClient collects the state of OpenConfig paths using gNMI Get RPCs, or Subscribe RPCs in ONCE mode, keeping a
+// gRPC connection to each target.
+type GNMIClient struct {
+	tlsConfig *tls.Config
+	port      string
+	username  string
+	password  string
+	subscribe bool
+
+	mu     sync.Mutex
+	conns  map[string]*grpc.ClientConn
+	closed bool
+}
+
+// NewGNMIClient returns a new GNMIClient. gNMI is always used over TLS. The state is collected using Subscribe RPCs
+// when subscribe is set, for devices that do not support Get for state paths, otherwise using Get RPCs.
+func NewGNMIClient(tlsConfig *tls.Config, port int, username string, password string, subscribe bool) *GNMIClient {
+	return &GNMIClient{
+		tlsConfig: tlsConfig,
+		port:      strconv.Itoa(port),
+		username:  username,
+		password:  password,
+		subscribe: subscribe,
+		conns:     map[string]*grpc.ClientConn{},
+	}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials, sending the username and password as the metadata of
+// each RPC as expected by Junos.
+func (c *GNMIClient) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"username": c.username, "password": c.password}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c *GNMIClient) RequireTransportSecurity() bool {
+	return true
+}
+
+// client returns a gNMI client using the connection to target, which is established on the first RPC to the target.
+func (c *GNMIClient) client(target string) (gnmi.GNMIClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, fmt.Errorf("gnmi client is closed")
+	}
+	if conn, ok := c.conns[target]; ok {
+		return gnmi.NewGNMIClient(conn), nil
+	}
+	conn, err := grpc.NewClient(net.JoinHostPort(targetHost(target), c.port),
+		grpc.WithTransportCredentials(credentials.NewTLS(c.tlsConfig)),
+		grpc.WithPerRPCCredentials(c),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[target] = conn
+	return gnmi.NewGNMIClient(conn), nil
+}
+
+// Close closes the connections to all targets, after which RPCs fail.
+func (c *GNMIClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for target, conn := range c.conns {
+		conn.Close()
+		delete(c.conns, target)
+	}
+}
+
+// gnmiPathElem is an element of a gNMI path, such as interface[name=ge-0/0/0].
+type gnmiPathElem struct {
+	name string
+	keys map[string]string
+}
+
+// gnmiLeaf is a single value returned by a gNMI Get, with JSON values flattened to their leaves.
+type gnmiLeaf struct {
+	path  []gnmiPathElem
+	value string
+}
+
+// name returns the name of the leaf.
+func (l gnmiLeaf) name() string {
+	if len(l.path) == 0 {
+		return ""
+	}
+	return l.path[len(l.path)-1].name
+}
+
+// parent returns the name of the container holding the leaf.
+func (l gnmiLeaf) parent() string {
+	if len(l.path) < 2 {
+		return ""
+	}
+	return l.path[len(l.path)-2].name
+}
+
+// key returns the value of key for the first element of the path named elem.
+func (l gnmiLeaf) key(elem string, key string) (string, bool) {
+	for _, e := range l.path {
+		if e.name == elem {
+			v, ok := e.keys[key]
+			return v, ok
+		}
+	}
+	return "", false
+}
+
+// has returns whether the path contains an element named elem.
+func (l gnmiLeaf) has(elem string) bool {
+	for _, e := range l.path {
+		if e.name == elem {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the leaves of the state of paths on target.
+func (c *GNMIClient) Get(ctx context.Context, target string, paths ...string) ([]gnmiLeaf, error) {
+	var gnmiPaths []*gnmi.Path
+	for _, p := range paths {
+		elems, err := parseGNMIPath(p)
+		if err != nil {
+			return nil, err
+		}
+		gnmiPaths = append(gnmiPaths, encodeGNMIPath(elems))
+	}
+	client, err := c.client(target)
+	if err != nil {
+		return nil, err
+	}
+	if c.subscribe {
+		return subscribeOnce(ctx, client, gnmiPaths)
+	}
+
+	resp, err := client.Get(ctx, &gnmi.GetRequest{
+		Path:     gnmiPaths,
+		Type:     gnmi.GetRequest_STATE,
+		Encoding: gnmi.Encoding_JSON_IETF,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var leaves []gnmiLeaf
+	for _, notification := range resp.GetNotification() {
+		l, err := notificationLeaves(notification)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, l...)
+	}
+	return leaves, nil
+}
+
+// subscribeOnce returns the leaves of paths sent by a Subscribe RPC in ONCE mode, until the target signals that all
+// the updates were sent.
+func subscribeOnce(ctx context.Context, client gnmi.GNMIClient, paths []*gnmi.Path) ([]gnmiLeaf, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.Subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []*gnmi.Subscription
+	for _, p := range paths {
+		subscriptions = append(subscriptions, &gnmi.Subscription{Path: p})
+	}
+	if err := stream.Send(&gnmi.SubscribeRequest{
+		Request: &gnmi.SubscribeRequest_Subscribe{
+			Subscribe: &gnmi.SubscriptionList{
+				Subscription: subscriptions,
+				Mode:         gnmi.SubscriptionList_ONCE,
+				Encoding:     gnmi.Encoding_PROTO,
+			},
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	var leaves []gnmiLeaf
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return leaves, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch r := resp.GetResponse().(type) {
+		case *gnmi.SubscribeResponse_Update:
+			l, err := notificationLeaves(r.Update)
+			if err != nil {
+				return nil, err
+			}
+			leaves = append(leaves, l...)
+		case *gnmi.SubscribeResponse_SyncResponse:
+			return leaves, nil
+		}
+	}
+}
+
+// parseGNMIPath parses a path in the form /interfaces/interface[name=*]/state.
+func parseGNMIPath(path string) ([]gnmiPathElem, error) {
+	var elems []gnmiPathElem
+	var elem strings.Builder
+	inKey := false
+	path = strings.TrimPrefix(path, "/")
+	for i := 0; i <= len(path); i++ {
+		if i < len(path) {
+			switch {
+			case path[i] == '[':
+				inKey = true
+			case path[i] == ']':
+				inKey = false
+			}
+			if inKey || path[i] != '/' {
+				elem.WriteByte(path[i])
+				continue
+			}
+		}
+		if inKey {
+			return nil, fmt.Errorf("unterminated key in gnmi path %q", path)
+		}
+		e, err := parseGNMIPathElem(elem.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid gnmi path %q: %s", path, err)
+		}
+		elems = append(elems, e)
+		elem.Reset()
+	}
+	return elems, nil
+}
+
+func parseGNMIPathElem(s string) (gnmiPathElem, error) {
+	name, keys, _ := strings.Cut(s, "[")
+	e := gnmiPathElem{name: name, keys: map[string]string{}}
+	if name == "" {
+		return e, fmt.Errorf("empty element")
+	}
+	if keys == "" {
+		return e, nil
+	}
+	for _, kv := range strings.Split(strings.TrimSuffix(keys, "]"), "][") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return e, fmt.Errorf("invalid key %q", kv)
+		}
+		e.keys[k] = v
+	}
+	return e, nil
+}
+
+// encodeGNMIPath returns the gNMI path of elems.
+func encodeGNMIPath(elems []gnmiPathElem) *gnmi.Path {
+	path := &gnmi.Path{}
+	for _, e := range elems {
+		elem := &gnmi.PathElem{Name: e.name}
+		if len(e.keys) > 0 {
+			elem.Key = e.keys
+		}
+		path.Elem = append(path.Elem, elem)
+	}
+	return path
+}
+
+// decodeGNMIPath returns the elements of a gNMI path.
+func decodeGNMIPath(path *gnmi.Path) []gnmiPathElem {
+	var elems []gnmiPathElem
+	for _, e := range path.GetElem() {
+		keys := map[string]string{}
+		for k, v := range e.GetKey() {
+			keys[k] = v
+		}
+		elems = append(elems, gnmiPathElem{name: e.GetName(), keys: keys})
+	}
+	return elems
+}
+
+// notificationLeaves returns the leaves of the updates of a notification.
+func notificationLeaves(notification *gnmi.Notification) ([]gnmiLeaf, error) {
+	prefix := decodeGNMIPath(notification.GetPrefix())
+	var leaves []gnmiLeaf
+	for _, update := range notification.GetUpdate() {
+		path := append(append([]gnmiPathElem{}, prefix...), decodeGNMIPath(update.GetPath())...)
+		l, err := typedValueLeaves(path, update.GetVal())
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, l...)
+	}
+	return leaves, nil
+}
+
+// typedValueLeaves returns the leaves of the value of path, a single leaf unless the value is JSON encoded.
+func typedValueLeaves(path []gnmiPathElem, value *gnmi.TypedValue) ([]gnmiLeaf, error) {
+	var s string
+	switch v := value.GetValue().(type) {
+	case *gnmi.TypedValue_StringVal:
+		s = v.StringVal
+	case *gnmi.TypedValue_AsciiVal:
+		s = v.AsciiVal
+	case *gnmi.TypedValue_IntVal:
+		s = strconv.FormatInt(v.IntVal, 10)
+	case *gnmi.TypedValue_UintVal:
+		s = strconv.FormatUint(v.UintVal, 10)
+	case *gnmi.TypedValue_BoolVal:
+		s = strconv.FormatBool(v.BoolVal)
+	case *gnmi.TypedValue_FloatVal:
+		s = strconv.FormatFloat(float64(v.FloatVal), 'f', -1, 32)
+	case *gnmi.TypedValue_DoubleVal:
+		s = strconv.FormatFloat(v.DoubleVal, 'f', -1, 64)
+	case *gnmi.TypedValue_DecimalVal:
+		s = strconv.FormatFloat(float64(v.DecimalVal.GetDigits())/math.Pow10(int(v.DecimalVal.GetPrecision())), 'f', -1, 64)
+	case *gnmi.TypedValue_JsonVal:
+		return flattenGNMIJSON(path, v.JsonVal)
+	case *gnmi.TypedValue_JsonIetfVal:
+		return flattenGNMIJSON(path, v.JsonIetfVal)
+	default:
+		return nil, nil
+	}
+	return []gnmiLeaf{{path: path, value: s}}, nil
+}
+
+// gnmiListKeys are the keys used to identify the entries of OpenConfig lists returned as JSON.
+var gnmiListKeys = []string{"name", "neighbor-address", "afi-safi-name", "identifier", "index", "address"}
+
+// flattenGNMIJSON converts a JSON encoded container to its leaves.
+func flattenGNMIJSON(path []gnmiPathElem, data []byte) ([]gnmiLeaf, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("could not unmarshal gnmi json value: %s", err)
+	}
+	var leaves []gnmiLeaf
+	var flatten func(path []gnmiPathElem, v interface{})
+	flatten = func(path []gnmiPathElem, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for name, child := range v {
+				// JSON IETF prefixes names with their module when the module changes, such as openconfig-interfaces:state.
+				if i := strings.Index(name, ":"); i >= 0 {
+					name = name[i+1:]
+				}
+				childPath := append(append([]gnmiPathElem{}, path...), gnmiPathElem{name: name, keys: map[string]string{}})
+				if list, ok := child.([]interface{}); ok {
+					for _, entry := range list {
+						entryPath := append([]gnmiPathElem{}, childPath...)
+						entryPath[len(entryPath)-1] = gnmiPathElem{name: name, keys: gnmiJSONListKeys(entry)}
+						flatten(entryPath, entry)
+					}
+					continue
+				}
+				flatten(childPath, child)
+			}
+		case nil:
+		default:
+			leaves = append(leaves, gnmiLeaf{path: path, value: fmt.Sprint(v)})
+		}
+	}
+	flatten(path, v)
+	return leaves, nil
+}
+
+func gnmiJSONListKeys(entry interface{}) map[string]string {
+	keys := map[string]string{}
+	if m, ok := entry.(map[string]interface{}); ok {
+		for _, k := range gnmiListKeys {
+			if v, ok := m[k]; ok {
+				keys[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return keys
+}
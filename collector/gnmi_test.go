@@ -0,0 +1,175 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeGNMIServer replies to Subscribe RPCs with notifications, followed by a sync response.
+type fakeGNMIServer struct {
+	gnmi.UnimplementedGNMIServer
+	notifications []*gnmi.Notification
+	request       *gnmi.SubscribeRequest
+}
+
+func (s *fakeGNMIServer) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.request = req
+	for _, n := range s.notifications {
+		if err := stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}})
+}
+
+// fakeGNMIClient returns a client of server over an in-memory connection.
+func fakeGNMIClient(t *testing.T, server gnmi.GNMIServer) gnmi.GNMIClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	gnmi.RegisterGNMIServer(s, server)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gnmi.NewGNMIClient(conn)
+}
+
+// leafStrings returns the leaves as sorted path=value strings.
+func leafStrings(leaves []gnmiLeaf) []string {
+	var s []string
+	for _, l := range leaves {
+		var path []string
+		for _, e := range l.path {
+			var keys []string
+			for k, v := range e.keys {
+				keys = append(keys, "["+k+"="+v+"]")
+			}
+			sort.Strings(keys)
+			path = append(path, e.name+strings.Join(keys, ""))
+		}
+		s = append(s, "/"+strings.Join(path, "/")+"="+l.value)
+	}
+	sort.Strings(s)
+	return s
+}
+
+func TestSubscribeOnce(t *testing.T) {
+	prefix, err := parseGNMIPath("/interfaces/interface[name=ge-0/0/0]/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeGNMIServer{notifications: []*gnmi.Notification{
+		{
+			Prefix: encodeGNMIPath(prefix),
+			Update: []*gnmi.Update{
+				{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "oper-status"}}}, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "UP"}}},
+				{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "mtu"}}}, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 1514}}},
+			},
+		},
+		{
+			Update: []*gnmi.Update{{
+				Path: encodeGNMIPath([]gnmiPathElem{{name: "interfaces"}}),
+				Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: []byte(
+					`{"openconfig-interfaces:interface": [{"name": "xe-0/0/1", "state": {"counters": {"in-octets": "42"}}}]}`,
+				)}},
+			}},
+		},
+	}}
+
+	paths := []*gnmi.Path{encodeGNMIPath(prefix)}
+	leaves, err := subscribeOnce(context.Background(), fakeGNMIClient(t, server), paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/interfaces/interface[name=ge-0/0/0]/state/mtu=1514",
+		"/interfaces/interface[name=ge-0/0/0]/state/oper-status=UP",
+		"/interfaces/interface[name=xe-0/0/1]/name=xe-0/0/1",
+		"/interfaces/interface[name=xe-0/0/1]/state/counters/in-octets=42",
+	}
+	if got := leafStrings(leaves); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got leaves\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	list := server.request.GetSubscribe()
+	if list.GetMode() != gnmi.SubscriptionList_ONCE || len(list.GetSubscription()) != 1 {
+		t.Errorf("unexpected subscription %v", list)
+	}
+}
+
+func TestTypedValueLeaves(t *testing.T) {
+	path := []gnmiPathElem{{name: "value"}}
+	for _, tc := range []struct {
+		value *gnmi.TypedValue
+		want  string
+	}{
+		{value: &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: -3}}, want: "-3"},
+		{value: &gnmi.TypedValue{Value: &gnmi.TypedValue_BoolVal{BoolVal: true}}, want: "true"},
+		{value: &gnmi.TypedValue{Value: &gnmi.TypedValue_DoubleVal{DoubleVal: 0.25}}, want: "0.25"},
+		{value: &gnmi.TypedValue{Value: &gnmi.TypedValue_DecimalVal{DecimalVal: &gnmi.Decimal64{Digits: 4250, Precision: 2}}}, want: "42.5"},
+		{value: &gnmi.TypedValue{Value: &gnmi.TypedValue_AsciiVal{AsciiVal: "abc"}}, want: "abc"},
+	} {
+		leaves, err := typedValueLeaves(path, tc.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(leaves) != 1 || leaves[0].value != tc.want {
+			t.Errorf("typedValueLeaves(%v) = %v, want %q", tc.value, leaves, tc.want)
+		}
+	}
+}
+
+func TestParseGNMIPath(t *testing.T) {
+	elems, err := parseGNMIPath("/network-instances/network-instance[name=VRF1]/protocols/protocol[identifier=BGP][name=bgp]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := encodeGNMIPath(elems)
+	if len(path.Elem) != 4 || path.Elem[1].Key["name"] != "VRF1" || path.Elem[3].Key["identifier"] != "BGP" || path.Elem[3].Key["name"] != "bgp" {
+		t.Errorf("unexpected path %v", path)
+	}
+
+	for _, p := range []string{"/interfaces/interface[name=ge-0/0/0", "/interfaces//state", "/interfaces/interface[name]"} {
+		if _, err := parseGNMIPath(p); err == nil {
+			t.Errorf("parseGNMIPath(%q) succeeded, want error", p)
+		}
+	}
+}
+
+func TestNonGNMICollectorDown(t *testing.T) {
+	conf := Config{SSHTarget: "router1", GNMI: NewGNMIClient(nil, 32767, "", "", false), OmitTargetMetrics: true}
+	exporter, err := NewExporter(context.Background(), []Collector{NewOSPFCollector(log.NewNopLogger())}, conf, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `
+# HELP junos_collector_up Whether the collector's last scrape was successful (1 = successful, 0 = unsuccessful).
+# TYPE junos_collector_up gauge
+junos_collector_up{collector="ospf"} 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(want), "junos_collector_up"); err != nil {
+		t.Error(err)
+	}
+}
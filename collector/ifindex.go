@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// IfIndex is the SNMP ifIndex of an interface of a target.
+type IfIndex struct {
+	Interface string `json:"interface"`
+	IfIndex   int    `json:"ifindex"`
+}
+
+var (
+	ifIndexesMu sync.Mutex
+	// SNMP ifIndex (value) per interface (key) of each target (key) from its last interface scrape.
+	ifIndexes = map[string]map[string]int{}
+)
+
+// IfIndexes returns the SNMP ifIndex of each interface of each target (key) collected by the last scrape of the
+// interface collector of the target, sorted by ifIndex.
+func IfIndexes() map[string][]IfIndex {
+	ifIndexesMu.Lock()
+	defer ifIndexesMu.Unlock()
+
+	targets := map[string][]IfIndex{}
+	for target, indexes := range ifIndexes {
+		list := []IfIndex{}
+		for iface, index := range indexes {
+			list = append(list, IfIndex{Interface: iface, IfIndex: index})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].IfIndex == list[j].IfIndex {
+				return list[i].Interface < list[j].Interface
+			}
+			return list[i].IfIndex < list[j].IfIndex
+		})
+		targets[target] = list
+	}
+	return targets
+}
+
+// ifIndexRecorder collects the SNMP ifIndex of the interfaces of a scrape, which replace those of the previous scrape
+// of the target once stored.
+type ifIndexRecorder map[string]int
+
+func (r ifIndexRecorder) add(iface string, index string) {
+	i, err := strconv.Atoi(strings.TrimSpace(index))
+	if err != nil || i <= 0 {
+		return
+	}
+	r[strings.TrimSpace(iface)] = i
+}
+
+func (r ifIndexRecorder) store(target string) {
+	ifIndexesMu.Lock()
+	defer ifIndexesMu.Unlock()
+	ifIndexes[target] = r
+}
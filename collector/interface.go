@@ -1,7 +1,7 @@
 package collector
 
 import (
-	"encoding/json"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strconv"
@@ -13,12 +13,15 @@ import (
 )
 
 var (
-	ifaceSubsystem   = "interface"
-	totalIfaceErrors = 0.0
+	ifaceSubsystem = "interface"
 )
 
-func getInterfaceDesc(ifaceDescrKeys, ifaceMetricKeys []string) map[string]*prometheus.Desc {
+func getInterfaceDesc(conf Config) map[string]*prometheus.Desc {
+	ifaceDescrKeys, ifaceMetricKeys := conf.IfaceDescrKeys, conf.IfaceMetricKeys
 	var ifacePhysicalLabels = []string{"interface"}
+	if conf.IfaceDescrKeyLabels {
+		ifacePhysicalLabels = append(ifacePhysicalLabels, descrLabelNames(ifaceDescrKeys)...)
+	}
 	var ifacePRECLClass = append(ifacePhysicalLabels, "class")
 
 	ifaceDesc := map[string]*prometheus.Desc{
@@ -32,6 +35,10 @@ func getInterfaceDesc(ifaceDescrKeys, ifaceMetricKeys []string) map[string]*prom
 		"OutputBps":                                colPromDesc(ifaceSubsystem, "output_bps", "Output BPS.", ifacePhysicalLabels),
 		"InputPps":                                 colPromDesc(ifaceSubsystem, "input_pps", "Input PPS.", ifacePhysicalLabels),
 		"OutputPps":                                colPromDesc(ifaceSubsystem, "output_pps", "Output PPS.", ifacePhysicalLabels),
+		"LocalInputBytes":                          colPromDesc(ifaceSubsystem, "local_input_bytes", "Input Bytes Destined to the Routing Engine.", ifacePhysicalLabels),
+		"LocalOutputBytes":                         colPromDesc(ifaceSubsystem, "local_output_bytes", "Output Bytes Sent by the Routing Engine.", ifacePhysicalLabels),
+		"LocalInputPackets":                        colPromDesc(ifaceSubsystem, "local_input_packets", "Input Packets Destined to the Routing Engine.", ifacePhysicalLabels),
+		"LocalOutputPackets":                       colPromDesc(ifaceSubsystem, "local_output_packets", "Output Packets Sent by the Routing Engine.", ifacePhysicalLabels),
 		"V6InputBytes":                             colPromDesc(ifaceSubsystem, "ipv6_input_bytes", "Input IPv6 Bytes.", ifacePhysicalLabels),
 		"V6OutputBytes":                            colPromDesc(ifaceSubsystem, "ipv6_output_bytes", "Output IPv6 Bytes.", ifacePhysicalLabels),
 		"V6InputPackets":                           colPromDesc(ifaceSubsystem, "ipv6_input_packets", "Input IPv6 Packets.", ifacePhysicalLabels),
@@ -153,13 +160,19 @@ func getInterfaceDesc(ifaceDescrKeys, ifaceMetricKeys []string) map[string]*prom
 		"FlowInputPolicyBytes":                     colPromDesc(ifaceSubsystem, "flow_input_policy_bytes", "Flow Input Policy Bytes.", ifacePhysicalLabels),
 		"FlowInputConnections":                     colPromDesc(ifaceSubsystem, "flow_input_connections", "Flow Input Connections.", ifacePhysicalLabels),
 		"SpeedBytes":                               colPromDesc(ifaceSubsystem, "speed_bytes", "Speed of the Interface in Bytes per Second", ifacePhysicalLabels),
+		"UtilizationRatio":                         colPromDesc(ifaceSubsystem, "utilization_ratio", "Traffic rate of the interface relative to its speed, by direction.", append(append([]string{}, ifacePhysicalLabels...), "direction")),
+		"ErrorDisabled":                            colPromDesc(ifaceSubsystem, "error_disabled", "Whether the interface is disabled by an error (1 = disabled, 0 = not disabled), such as BPDU protection, by error and the reason reported by the device.", append(append([]string{}, ifacePhysicalLabels...), "error", "reason")),
 		"SnmpIndex":                                colPromDesc(ifaceSubsystem, "snmp_index", "SNMP Index for the interface", ifacePhysicalLabels),
+		"RoutingInstance":                          colPromDesc(ifaceSubsystem, "routing_instance_info", "Routing instance of the logical interface, always 1.", []string{"interface", "routing_instance"}),
+	}
+	if conf.IfaceDescrLabel {
+		ifaceDesc["Up"] = colPromDesc(ifaceSubsystem, "up", "Whether the interface is up (1 = up, 0 = down).", append(append([]string{}, ifacePhysicalLabels...), "description"))
 	}
 	if len(ifaceDescrKeys) > 0 {
-		ifaceDesc["InterfaceDescription"] = colPromDesc(ifaceSubsystem, "description", "Interface description keys", append([]string{"interface"}, ifaceDescrKeys...))
+		ifaceDesc["InterfaceDescription"] = colPromDesc(ifaceSubsystem, "description", "Interface description keys", append([]string{"interface"}, descrLabelNames(ifaceDescrKeys)...))
 	}
 	for _, metricKey := range ifaceMetricKeys {
-		ifaceDesc[metricKey] = colPromDesc(ifaceSubsystem, strings.ToLower(metricKey), "User-defined Metric from Description Key", []string{"interface"})
+		ifaceDesc[metricKey] = colPromDesc(ifaceSubsystem, strings.ToLower(descrLabelName(metricKey)), "User-defined Metric from Description Key", ifacePhysicalLabels)
 	}
 	return ifaceDesc
 }
@@ -179,29 +192,63 @@ func (*InterfaceCollector) Name() string {
 	return ifaceSubsystem
 }
 
+// RPCs executed by the collector.
+func (*InterfaceCollector) RPCs() []string {
+	return []string{"get-interface-information", "get-instance-information"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *InterfaceCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *InterfaceCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
 
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
+	detail := conf.IfaceDetail
+	if detail == "" {
+		detail = "extensive"
+	}
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(fmt.Sprintf(`<get-interface-information><%s/></get-interface-information>`, detail)))
 	if err != nil {
-		totalIfaceErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalIfaceErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processIfaceNetconfReply(reply, ch, conf, c.logger); err != nil {
+		errors = append(errors, err)
 	}
-	defer s.Close()
 
-	reply, err := s.Exec(netconf.RawMethod(`<get-interface-information><extensive/></get-interface-information>`))
-	if err != nil {
-		totalIfaceErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalIfaceErrors
+	if conf.IfaceInstances {
+		// show route instance detail
+		replyInstances, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-instance-information><detail/></get-instance-information>`))
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		} else if err := processIfaceInstancesNetconfReply(replyInstances, ch, conf); err != nil {
+			errors = append(errors, err)
+		}
 	}
-	if err := processIfaceNetconfReply(reply, ch, conf.IfaceDescrKeys, conf.IfaceMetricKeys, c.logger); err != nil {
-		totalIfaceErrors++
-		errors = append(errors, err)
+	return errors
+}
+
+func processIfaceInstancesNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, conf Config) error {
+	var netconfReply routeInstanceRPCReply
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf route instance reply: %s", err)
+	}
+	desc := getInterfaceDesc(conf)["RoutingInstance"]
+	for _, instance := range netconfReply.InstanceInformation.InstanceCore {
+		for _, iface := range instance.InstanceInterface {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, strings.TrimSpace(iface.InterfaceName), strings.TrimSpace(instance.InstanceName))
+		}
+	}
+	return nil
+}
+
+// GetGNMI gets metrics from the OpenConfig interfaces model and sends to the Prometheus.Metric channel.
+func (c *InterfaceCollector) GetGNMI(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+
+	leaves, err := conf.GNMI.Get(ctx, conf.SSHTarget, "/interfaces/interface/state")
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute gnmi get: %w", err))
+		return errors
 	}
-	return errors, totalIfaceErrors
+	processIfaceGNMILeaves(leaves, ch, conf, c.logger)
+	return errors
 }
 
 func (c *BoolIfPresent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -213,272 +260,480 @@ func (c *BoolIfPresent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) err
 	return nil
 }
 
-func processIfaceNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, ifaceDescrKeys, ifaceMetricKeys []string, logger log.Logger) error {
-	var netconfReply ifaceRPCReply
-	r := strings.NewReader(reply.RawReply)
-	d := xml.NewDecoder(r)
-	if err := d.Decode(&netconfReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
-	}
+// ifaceLAG is the speed of the up member links and the traffic rate of an aggregated interface.
+type ifaceLAG struct {
+	seen        bool
+	labels      []string
+	memberSpeed float64
+	inputBps    string
+	outputBps   string
+}
 
-	for _, ifaceData := range netconfReply.InterfaceInformation.PhysicalInterface {
-		ifaceDesc := getInterfaceDesc(ifaceDescrKeys, ifaceMetricKeys)
-		ifaceLabels := []string{strings.TrimSpace(ifaceData.Name.Text)}
+func processIfaceNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, conf Config, logger log.Logger) error {
+	ifaceDesc := getInterfaceDesc(conf)
 
-		if strings.TrimSpace(ifaceData.AdminStatus.Text) == "up" {
-			if strings.TrimSpace(ifaceData.OperStatus.Text) == "up" {
-				ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 1.0, ifaceLabels...)
-			} else {
-				ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 0.0, ifaceLabels...)
-			}
+	// Aggregated interfaces without a speed get the combined speed of their member links, which may be listed
+	// before or after them.
+	lags := map[string]*ifaceLAG{}
+	lag := func(name string) *ifaceLAG {
+		l, ok := lags[name]
+		if !ok {
+			l = &ifaceLAG{}
+			lags[name] = l
 		}
-		if len(ifaceData.Speed.Text) > 0 {
-			if strings.Contains(strings.TrimSpace(ifaceData.Speed.Text), "Gbps") {
-				i, err := strconv.Atoi(strings.TrimRight(strings.TrimSpace(ifaceData.Speed.Text), "Gbps"))
-				if err != nil {
-					return err
-				}
-				ch <- prometheus.MustNewConstMetric(ifaceDesc["SpeedBytes"], prometheus.GaugeValue, float64(i*125000000), ifaceLabels...)
-			} else if strings.Contains(strings.TrimSpace(ifaceData.Speed.Text), "mbps") {
-				i, err := strconv.Atoi(strings.TrimRight(strings.TrimSpace(ifaceData.Speed.Text), "mbps"))
-				if err != nil {
-					return err
+		return l
+	}
+	indexes := ifIndexRecorder{}
+
+	err := decodeElements(reply, "physical-interface", func(ifaceData *ifacePhysical) error {
+		ifaceName := strings.TrimSpace(ifaceData.Name.Text)
+		indexes.add(ifaceName, ifaceData.SnmpIndex.Text)
+		for _, logIface := range ifaceData.LogicalInterfaces {
+			indexes.add(logIface.Name.Text, logIface.SnmpIndex.Text)
+		}
+		speed, hasSpeed := parseIfaceSpeed(ifaceData.Speed.Text)
+		if !hasSpeed && strings.HasPrefix(ifaceName, "ae") {
+			l := lag(ifaceName)
+			l.seen = true
+			l.labels = ifaceLabelValues(ifaceName, ifaceData.Description.Text, conf)
+			l.inputBps = ifaceData.TrafficStatistics.InputBps.Text
+			l.outputBps = ifaceData.TrafficStatistics.OutputBps.Text
+		}
+		if hasSpeed && strings.TrimSpace(ifaceData.OperStatus.Text) == "up" {
+			for _, logIface := range ifaceData.LogicalInterfaces {
+				for _, family := range logIface.AddressFamilies {
+					if bundle := strings.TrimSpace(family.AeBundleName.Text); bundle != "" {
+						lag(strings.SplitN(bundle, ".", 2)[0]).memberSpeed += speed
+					}
 				}
-				ch <- prometheus.MustNewConstMetric(ifaceDesc["SpeedBytes"], prometheus.GaugeValue, float64(i*125000), ifaceLabels...)
 			}
 		}
 
-		var allIfaceDescrKeys map[string]interface{}
+		if conf.IfaceTypes != "logical" {
+			sendIfacePhysical(ch, ifaceDesc, ifaceData, speed, hasSpeed, conf, logger)
+		}
+		if conf.IfaceTypes != "physical" {
+			for _, logIface := range ifaceData.LogicalInterfaces {
+				sendIfaceLogical(ch, ifaceDesc, logIface, conf, logger)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	indexes.store(conf.SSHTarget)
 
-		// Junos OS Evolved produces a different representation of a JSON string in the description field.
-		// Junos OS Evolved XML output: <description>{\&quot;r_name\&quot;:\&quot;my-far-end-device\&quot;}</description>
-		//      XML decoding results in the string: {\\\"r_name\\\":\\\"my-far-end-device\\\"}
-		//
-		// Junos (regular) XML output: <description>{"r_name":"my-far-end-device"}</description>
-		//      XML decoding results in the string: {\"r_name\":\"my-far-end-device\"}
-		//
-		// This line of code sanitizes the output for the Junos OS Evolved XML response format
-		ifaceData.Description.Text = strings.ReplaceAll(ifaceData.Description.Text, "\\\"", "\"")
+	for _, l := range lags {
+		if !l.seen || l.memberSpeed == 0 || conf.IfaceTypes == "logical" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(ifaceDesc["SpeedBytes"], prometheus.GaugeValue, l.memberSpeed, l.labels...)
+		if conf.IfaceUtilization {
+			sendIfaceUtilization(ch, ifaceDesc["UtilizationRatio"], l.labels, l.memberSpeed, l.inputBps, l.outputBps)
+		}
+	}
+	return nil
+}
 
-		if err := json.Unmarshal([]byte(ifaceData.Description.Text), &allIfaceDescrKeys); err != nil {
-			allIfaceDescrKeys = nil
+// sendIfacePhysical sends the metrics of a physical interface, excluding its logical interfaces. speed is the speed of
+// the interface in bytes per second when hasSpeed is set.
+func sendIfacePhysical(ch chan<- prometheus.Metric, ifaceDesc map[string]*prometheus.Desc, ifaceData *ifacePhysical, speed float64, hasSpeed bool, conf Config, logger log.Logger) {
+	ifaceDescrKeys, ifaceMetricKeys := conf.IfaceDescrKeys, conf.IfaceMetricKeys
+	ifaceName := strings.TrimSpace(ifaceData.Name.Text)
+	ifaceLabels := ifaceLabelValues(ifaceName, ifaceData.Description.Text, conf)
+	upLabels := ifaceLabels
+	if conf.IfaceDescrLabel {
+		upLabels = append(append([]string{}, ifaceLabels...), strings.TrimSpace(ifaceData.Description.Text))
+	}
+
+	if strings.TrimSpace(ifaceData.AdminStatus.Text) == "up" {
+		if strings.TrimSpace(ifaceData.OperStatus.Text) == "up" {
+			ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 1.0, upLabels...)
+		} else {
+			ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 0.0, upLabels...)
 		}
-		if len(ifaceDescrKeys) > 0 {
-			ifaceDescrLabels := []string{strings.TrimSpace(ifaceData.Name.Text)}
-			for _, configuredKey := range ifaceDescrKeys {
-				if allIfaceDescrKeys[configuredKey] == nil {
-					ifaceDescrLabels = append(ifaceDescrLabels, "")
-				} else {
-					ifaceDescrLabels = append(ifaceDescrLabels, allIfaceDescrKeys[configuredKey].(string))
-				}
-			}
-			newCounter(logger, ch, ifaceDesc["InterfaceDescription"], "1", ifaceDescrLabels...)
+	}
+	if hasSpeed {
+		ch <- prometheus.MustNewConstMetric(ifaceDesc["SpeedBytes"], prometheus.GaugeValue, speed, ifaceLabels...)
+		if conf.IfaceUtilization {
+			sendIfaceUtilization(ch, ifaceDesc["UtilizationRatio"], ifaceLabels, speed, ifaceData.TrafficStatistics.InputBps.Text, ifaceData.TrafficStatistics.OutputBps.Text)
 		}
-		for _, configuredKey := range ifaceMetricKeys {
-			if allIfaceDescrKeys[configuredKey] != nil {
-				newCounter(logger, ch, ifaceDesc[configuredKey], strings.TrimSpace(allIfaceDescrKeys[configuredKey].(string)), strings.TrimSpace(ifaceData.Name.Text))
-			}
+	}
+
+	sendIfaceErrorDisabled(ch, ifaceDesc["ErrorDisabled"], ifaceData, ifaceLabels)
+
+	allIfaceDescrKeys := parseIfaceDescr(ifaceData.Description.Text)
+	if len(ifaceDescrKeys) > 0 {
+		ifaceDescrLabels := append([]string{ifaceName}, ifaceDescrKeyValues(allIfaceDescrKeys, ifaceDescrKeys)...)
+		newCounter(logger, ch, ifaceDesc["InterfaceDescription"], "1", ifaceDescrLabels...)
+	}
+	for _, configuredKey := range ifaceMetricKeys {
+		if value, ok := descrValue(allIfaceDescrKeys, configuredKey); ok {
+			newCounter(logger, ch, ifaceDesc[configuredKey], strings.TrimSpace(value), ifaceLabels...)
 		}
-		newCounter(logger, ch, ifaceDesc["InterfaceFlapped"], ifaceData.InterfaceFlapped.Seconds, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputBytes"], ifaceData.TrafficStatistics.InputBytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputBytes"], ifaceData.TrafficStatistics.OutputBytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputPackets"], ifaceData.TrafficStatistics.InputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputPackets"], ifaceData.TrafficStatistics.OutputPackets.Text, ifaceLabels...)
-		newGauge(logger, ch, ifaceDesc["InputBps"], ifaceData.TrafficStatistics.InputBps.Text, ifaceLabels...)
-		newGauge(logger, ch, ifaceDesc["OutputBps"], ifaceData.TrafficStatistics.OutputBps.Text, ifaceLabels...)
-		newGauge(logger, ch, ifaceDesc["InputPps"], ifaceData.TrafficStatistics.InputPps.Text, ifaceLabels...)
-		newGauge(logger, ch, ifaceDesc["OutputPps"], ifaceData.TrafficStatistics.OutputPps.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["V6InputBytes"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.InputBytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["V6OutputBytes"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.OutputBytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["V6InputPackets"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.InputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["V6OutputPackets"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.OutputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputErrors"], ifaceData.InputErrorList.InputErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputDrops"], ifaceData.InputErrorList.InputDrops.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FramingErrors"], ifaceData.InputErrorList.FramingErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputRunts"], ifaceData.InputErrorList.InputRunts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputGiants"], ifaceData.InputErrorList.InputGiants.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputDiscards"], ifaceData.InputErrorList.InputDiscards.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputResourceErrors"], ifaceData.InputErrorList.InputResourceErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputL3Incompletes"], ifaceData.InputErrorList.InputL3Incompletes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputL2ChannelErrors"], ifaceData.InputErrorList.InputL2ChannelErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputL2MismatchTimeouts"], ifaceData.InputErrorList.InputL2MismatchTimeouts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputFifoErrors"], ifaceData.InputErrorList.InputFifoErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["CarrierTransitions"], ifaceData.OutputErrorList.CarrierTransitions.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputErrors"], ifaceData.OutputErrorList.OutputErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputDrops"], ifaceData.OutputErrorList.OutputDrops.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MtuErrors"], ifaceData.OutputErrorList.MtuErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputResourceErrors"], ifaceData.OutputErrorList.OutputResourceErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputCollisions"], ifaceData.OutputErrorList.OutputCollisions.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["AgedPackets"], ifaceData.OutputErrorList.AgedPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["HsLinkCrcErrors"], ifaceData.OutputErrorList.HsLinkCrcErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputFifoErrors"], ifaceData.OutputErrorList.OutputFifoErrors.Text, ifaceLabels...)
-		newGauge(logger, ch, ifaceDesc["SnmpIndex"], ifaceData.SnmpIndex.Text, ifaceLabels...)
-		for _, logIface := range ifaceData.LogicalInterfaces {
-			logIfaceLabels := []string{strings.TrimSpace(logIface.Name.Text)}
-			var allIfaceDescrKeys map[string]interface{}
-			if err := json.Unmarshal([]byte(logIface.Description.Text), &allIfaceDescrKeys); err != nil {
-				allIfaceDescrKeys = nil
-			}
-			if logIface.IfConfigFlags.IffUp {
-				ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 1.0, logIfaceLabels...)
-			} else {
-				ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 0.0, logIfaceLabels...)
-			}
-			if len(ifaceDescrKeys) > 0 {
-				ifaceDescrLabels := []string{strings.TrimSpace(logIface.Name.Text)}
-
-				for _, configuredKey := range ifaceDescrKeys {
-					if allIfaceDescrKeys[configuredKey] == nil {
-						ifaceDescrLabels = append(ifaceDescrLabels, "")
-					} else {
-						ifaceDescrLabels = append(ifaceDescrLabels, allIfaceDescrKeys[configuredKey].(string))
-					}
-				}
-				newCounter(logger, ch, ifaceDesc["InterfaceDescription"], "1", ifaceDescrLabels...)
-			}
-			for _, configuredKey := range ifaceMetricKeys {
-				if allIfaceDescrKeys[configuredKey] != nil {
-					newCounter(logger, ch, ifaceDesc[configuredKey], strings.TrimSpace(allIfaceDescrKeys[configuredKey].(string)), strings.TrimSpace(logIface.Name.Text))
-				}
-			}
-			trafficStatsSource := logIface.TransitTrafficStatistics
-			if logIface.LAGTrafficStatistics.LagBundle.InputBps.Text != "" {
-				trafficStatsSource = logIface.LAGTrafficStatistics.LagBundle
-			}
-			newCounter(logger, ch, ifaceDesc["InputBytes"], trafficStatsSource.InputBytes.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["OutputBytes"], trafficStatsSource.OutputBytes.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["InputPackets"], trafficStatsSource.InputPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["OutputPackets"], trafficStatsSource.OutputPackets.Text, logIfaceLabels...)
-			newGauge(logger, ch, ifaceDesc["InputBps"], trafficStatsSource.InputBps.Text, logIfaceLabels...)
-			newGauge(logger, ch, ifaceDesc["OutputBps"], trafficStatsSource.OutputBps.Text, logIfaceLabels...)
-			newGauge(logger, ch, ifaceDesc["InputPps"], trafficStatsSource.InputPps.Text, logIfaceLabels...)
-			newGauge(logger, ch, ifaceDesc["OutputPps"], trafficStatsSource.OutputPps.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["V6InputBytes"], trafficStatsSource.Ipv6TransitStatistics.InputBytes.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["V6OutputBytes"], trafficStatsSource.Ipv6TransitStatistics.OutputBytes.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["V6InputPackets"], trafficStatsSource.Ipv6TransitStatistics.InputPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["V6OutputPackets"], trafficStatsSource.Ipv6TransitStatistics.OutputPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorAddressSpoofing"], logIface.SecurityErrorFlowStatistics.FlowErrorAddressSpoofing.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorAuthenticationFailed"], logIface.SecurityErrorFlowStatistics.FlowErrorAuthenticationFailed.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorIncomingNat"], logIface.SecurityErrorFlowStatistics.FlowErrorIncomingNat.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorInvalidZone"], logIface.SecurityErrorFlowStatistics.FlowErrorInvalidZone.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorMultipleAuth"], logIface.SecurityErrorFlowStatistics.FlowErrorMultipleAuth.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorMultipleIncomingNat"], logIface.SecurityErrorFlowStatistics.FlowErrorMultipleIncomingNat.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoGateParent"], logIface.SecurityErrorFlowStatistics.FlowErrorNoGateParent.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoInterestSelfPacket"], logIface.SecurityErrorFlowStatistics.FlowErrorNoInterestSelfPacket.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoMinorSession"], logIface.SecurityErrorFlowStatistics.FlowErrorNoMinorSession.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoMoreSession"], logIface.SecurityErrorFlowStatistics.FlowErrorNoMoreSession.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoNatGate"], logIface.SecurityErrorFlowStatistics.FlowErrorNoNatGate.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoRoutePresent"], logIface.SecurityErrorFlowStatistics.FlowErrorNoRoutePresent.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoSaForSpi"], logIface.SecurityErrorFlowStatistics.FlowErrorNoSaForSpi.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoTunnel"], logIface.SecurityErrorFlowStatistics.FlowErrorNoTunnel.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNoSessionGate"], logIface.SecurityErrorFlowStatistics.FlowErrorNoSessionGate.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorNullZone"], logIface.SecurityErrorFlowStatistics.FlowErrorNullZone.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorPolicyDenied"], logIface.SecurityErrorFlowStatistics.FlowErrorPolicyDenied.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorSecurityAssociationMissing"], logIface.SecurityErrorFlowStatistics.FlowErrorSecurityAssociationMissing.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorSeqOutsideWindow"], logIface.SecurityErrorFlowStatistics.FlowErrorSeqOutsideWindow.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorSynProtection"], logIface.SecurityErrorFlowStatistics.FlowErrorSynProtection.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowErrorUserAuthentication"], logIface.SecurityErrorFlowStatistics.FlowErrorUserAuthentication.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowInputSelfPackets"], logIface.SecurityInputFlowStatistics.FlowInputSelfPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowInputIcmpPackets"], logIface.SecurityInputFlowStatistics.FlowInputIcmpPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowInputVpnPackets"], logIface.SecurityInputFlowStatistics.FlowInputVpnPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowInputMulticastPackets"], logIface.SecurityInputFlowStatistics.FlowInputMulticastPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowInputPolicyBytes"], logIface.SecurityInputFlowStatistics.FlowInputPolicyBytes.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowInputConnections"], logIface.SecurityInputFlowStatistics.FlowInputConnections.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowOutputMulticastPackets"], logIface.SecurityOutputFlowStatistics.FlowOutputMulticastPackets.Text, logIfaceLabels...)
-			newCounter(logger, ch, ifaceDesc["FlowOutputPolicyBytes"], logIface.SecurityOutputFlowStatistics.FlowOutputPolicyBytes.Text, logIfaceLabels...)
-			newGauge(logger, ch, ifaceDesc["SnmpIndex"], logIface.SnmpIndex.Text, logIfaceLabels...)
+	}
+	newCounter(logger, ch, ifaceDesc["InterfaceFlapped"], ifaceData.InterfaceFlapped.Seconds, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputBytes"], ifaceData.TrafficStatistics.InputBytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputBytes"], ifaceData.TrafficStatistics.OutputBytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputPackets"], ifaceData.TrafficStatistics.InputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputPackets"], ifaceData.TrafficStatistics.OutputPackets.Text, ifaceLabels...)
+	newGauge(logger, ch, ifaceDesc["InputBps"], ifaceData.TrafficStatistics.InputBps.Text, ifaceLabels...)
+	newGauge(logger, ch, ifaceDesc["OutputBps"], ifaceData.TrafficStatistics.OutputBps.Text, ifaceLabels...)
+	newGauge(logger, ch, ifaceDesc["InputPps"], ifaceData.TrafficStatistics.InputPps.Text, ifaceLabels...)
+	newGauge(logger, ch, ifaceDesc["OutputPps"], ifaceData.TrafficStatistics.OutputPps.Text, ifaceLabels...)
+	sendIfacePhysicalLocal(ch, ifaceDesc, ifaceData.LogicalInterfaces, ifaceLabels)
+	newCounter(logger, ch, ifaceDesc["V6InputBytes"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.InputBytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6OutputBytes"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.OutputBytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6InputPackets"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.InputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6OutputPackets"], ifaceData.TrafficStatistics.Ipv6TransitStatistics.OutputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputErrors"], ifaceData.InputErrorList.InputErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputDrops"], ifaceData.InputErrorList.InputDrops.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FramingErrors"], ifaceData.InputErrorList.FramingErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputRunts"], ifaceData.InputErrorList.InputRunts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputGiants"], ifaceData.InputErrorList.InputGiants.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputDiscards"], ifaceData.InputErrorList.InputDiscards.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputResourceErrors"], ifaceData.InputErrorList.InputResourceErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputL3Incompletes"], ifaceData.InputErrorList.InputL3Incompletes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputL2ChannelErrors"], ifaceData.InputErrorList.InputL2ChannelErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputL2MismatchTimeouts"], ifaceData.InputErrorList.InputL2MismatchTimeouts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputFifoErrors"], ifaceData.InputErrorList.InputFifoErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["CarrierTransitions"], ifaceData.OutputErrorList.CarrierTransitions.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputErrors"], ifaceData.OutputErrorList.OutputErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputDrops"], ifaceData.OutputErrorList.OutputDrops.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MtuErrors"], ifaceData.OutputErrorList.MtuErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputResourceErrors"], ifaceData.OutputErrorList.OutputResourceErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputCollisions"], ifaceData.OutputErrorList.OutputCollisions.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["AgedPackets"], ifaceData.OutputErrorList.AgedPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["HsLinkCrcErrors"], ifaceData.OutputErrorList.HsLinkCrcErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputFifoErrors"], ifaceData.OutputErrorList.OutputFifoErrors.Text, ifaceLabels...)
+	newGauge(logger, ch, ifaceDesc["SnmpIndex"], ifaceData.SnmpIndex.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["StpInputBytesDropped"], ifaceData.StpTrafficStatistics.StpInputBytesDropped.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["StpOutputBytesDropped"], ifaceData.StpTrafficStatistics.StpOutputBytesDropped.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["StpInputPacketsDropped"], ifaceData.StpTrafficStatistics.StpInputPacketsDropped.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["StpOutputPacketsDropped"], ifaceData.StpTrafficStatistics.StpOutputPacketsDropped.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["BitErrorSeconds"], ifaceData.EthernetPcsStatistics.BitErrorSeconds.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["ErroredBlocksSeconds"], ifaceData.EthernetPcsStatistics.ErroredBlocksSeconds.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputBytes"], ifaceData.EthernetMacStatistics.InputBytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputBytes"], ifaceData.EthernetMacStatistics.OutputBytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputPackets"], ifaceData.EthernetMacStatistics.InputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputPackets"], ifaceData.EthernetMacStatistics.OutputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputUnicasts"], ifaceData.EthernetMacStatistics.InputUnicasts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputUnicasts"], ifaceData.EthernetMacStatistics.OutputUnicasts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputBroadcasts"], ifaceData.EthernetMacStatistics.InputBroadcasts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputBroadcasts"], ifaceData.EthernetMacStatistics.OutputBroadcasts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputMulticasts"], ifaceData.EthernetMacStatistics.InputMulticasts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputMulticasts"], ifaceData.EthernetMacStatistics.OutputMulticasts.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputCrcErrors"], ifaceData.EthernetMacStatistics.InputCrcErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputCrcErrors"], ifaceData.EthernetMacStatistics.OutputCrcErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputFifoErrors"], ifaceData.EthernetMacStatistics.InputFifoErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputFifoErrors"], ifaceData.EthernetMacStatistics.OutputFifoErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputMacControlFrames"], ifaceData.EthernetMacStatistics.InputMacControlFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputMacControlFrames"], ifaceData.EthernetMacStatistics.OutputMacControlFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputMacPauseFrames"], ifaceData.EthernetMacStatistics.InputMacPauseFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputMacPauseFrames"], ifaceData.EthernetMacStatistics.OutputMacPauseFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputOversizedFrames"], ifaceData.EthernetMacStatistics.InputOversizedFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputJabberFrames"], ifaceData.EthernetMacStatistics.InputJabberFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputFragmentFrames"], ifaceData.EthernetMacStatistics.InputFragmentFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputVlanTaggedFrames"], ifaceData.EthernetMacStatistics.InputVlanTaggedFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputCodeViolations"], ifaceData.EthernetMacStatistics.InputCodeViolations.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACInputTotalErrors"], ifaceData.EthernetMacStatistics.InputTotalErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MACOutputTotalErrors"], ifaceData.EthernetMacStatistics.OutputTotalErrors.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterInputPackets"], ifaceData.EthernetFilterStatistics.InputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterInputRejectCount"], ifaceData.EthernetFilterStatistics.InputRejectCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterInputRejectDestinationAddressCount"], ifaceData.EthernetFilterStatistics.InputRejectDestinationAddressCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterInputRejectSourceAddressCount"], ifaceData.EthernetFilterStatistics.InputRejectSourceAddressCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterOutputPackets"], ifaceData.EthernetFilterStatistics.OutputPackets.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterOutputPacketPadCount"], ifaceData.EthernetFilterStatistics.OutputPacketPadCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterOutputPacketErrorCount"], ifaceData.EthernetFilterStatistics.OutputPacketErrorCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterCamDestinationFilterCount"], ifaceData.EthernetFilterStatistics.CamDestinationFilterCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FilterCamSourceFilterCount"], ifaceData.EthernetFilterStatistics.CamSourceFilterCount.Text, ifaceLabels...)
+	// Some devices can have duplicate traffic class names.
+	existingTrafficClasses := make(map[string]int)
+	for _, preclStats := range ifaceData.PreclStatistics.PreclInformation.PreclPerClassStatistics {
+		trafficClass := strings.TrimSpace(preclStats.PreclTrafficClass.Text)
+		if _, exists := existingTrafficClasses[trafficClass]; exists {
+			existingTrafficClasses[trafficClass]++
+			trafficClass = fmt.Sprintf("%s_%d", trafficClass, existingTrafficClasses[trafficClass])
+		} else {
+			existingTrafficClasses[trafficClass] = 0
 		}
-		newCounter(logger, ch, ifaceDesc["StpInputBytesDropped"], ifaceData.StpTrafficStatistics.StpInputBytesDropped.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["StpOutputBytesDropped"], ifaceData.StpTrafficStatistics.StpOutputBytesDropped.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["StpInputPacketsDropped"], ifaceData.StpTrafficStatistics.StpInputPacketsDropped.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["StpOutputPacketsDropped"], ifaceData.StpTrafficStatistics.StpOutputPacketsDropped.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["BitErrorSeconds"], ifaceData.EthernetPcsStatistics.BitErrorSeconds.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["ErroredBlocksSeconds"], ifaceData.EthernetPcsStatistics.ErroredBlocksSeconds.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputBytes"], ifaceData.EthernetMacStatistics.InputBytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputBytes"], ifaceData.EthernetMacStatistics.OutputBytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputPackets"], ifaceData.EthernetMacStatistics.InputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputPackets"], ifaceData.EthernetMacStatistics.OutputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputUnicasts"], ifaceData.EthernetMacStatistics.InputUnicasts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputUnicasts"], ifaceData.EthernetMacStatistics.OutputUnicasts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputBroadcasts"], ifaceData.EthernetMacStatistics.InputBroadcasts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputBroadcasts"], ifaceData.EthernetMacStatistics.OutputBroadcasts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputMulticasts"], ifaceData.EthernetMacStatistics.InputMulticasts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputMulticasts"], ifaceData.EthernetMacStatistics.OutputMulticasts.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputCrcErrors"], ifaceData.EthernetMacStatistics.InputCrcErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputCrcErrors"], ifaceData.EthernetMacStatistics.OutputCrcErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputFifoErrors"], ifaceData.EthernetMacStatistics.InputFifoErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputFifoErrors"], ifaceData.EthernetMacStatistics.OutputFifoErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputMacControlFrames"], ifaceData.EthernetMacStatistics.InputMacControlFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputMacControlFrames"], ifaceData.EthernetMacStatistics.OutputMacControlFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputMacPauseFrames"], ifaceData.EthernetMacStatistics.InputMacPauseFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputMacPauseFrames"], ifaceData.EthernetMacStatistics.OutputMacPauseFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputOversizedFrames"], ifaceData.EthernetMacStatistics.InputOversizedFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputJabberFrames"], ifaceData.EthernetMacStatistics.InputJabberFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputFragmentFrames"], ifaceData.EthernetMacStatistics.InputFragmentFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputVlanTaggedFrames"], ifaceData.EthernetMacStatistics.InputVlanTaggedFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputCodeViolations"], ifaceData.EthernetMacStatistics.InputCodeViolations.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACInputTotalErrors"], ifaceData.EthernetMacStatistics.InputTotalErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MACOutputTotalErrors"], ifaceData.EthernetMacStatistics.OutputTotalErrors.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterInputPackets"], ifaceData.EthernetFilterStatistics.InputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterInputRejectCount"], ifaceData.EthernetFilterStatistics.InputRejectCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterInputRejectDestinationAddressCount"], ifaceData.EthernetFilterStatistics.InputRejectDestinationAddressCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterInputRejectSourceAddressCount"], ifaceData.EthernetFilterStatistics.InputRejectSourceAddressCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterOutputPackets"], ifaceData.EthernetFilterStatistics.OutputPackets.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterOutputPacketPadCount"], ifaceData.EthernetFilterStatistics.OutputPacketPadCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterOutputPacketErrorCount"], ifaceData.EthernetFilterStatistics.OutputPacketErrorCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterCamDestinationFilterCount"], ifaceData.EthernetFilterStatistics.CamDestinationFilterCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FilterCamSourceFilterCount"], ifaceData.EthernetFilterStatistics.CamSourceFilterCount.Text, ifaceLabels...)
-		// Some devices can have duplicate traffic class names.
-		existingTrafficClasses := make(map[string]int)
-		for _, preclStats := range ifaceData.PreclStatistics.PreclInformation.PreclPerClassStatistics {
-			trafficClass := strings.TrimSpace(preclStats.PreclTrafficClass.Text)
-			if _, exists := existingTrafficClasses[trafficClass]; exists {
-				existingTrafficClasses[trafficClass]++
-				trafficClass = fmt.Sprintf("%s_%d", trafficClass, existingTrafficClasses[trafficClass])
-			} else {
-				existingTrafficClasses[trafficClass] = 0
+		preclLabels := append(ifaceLabels, trafficClass)
+		newCounter(logger, ch, ifaceDesc["PreclRxPackets"], preclStats.PreclRxPackets.Text, preclLabels...)
+		newCounter(logger, ch, ifaceDesc["PreclTxPackets"], preclStats.PreclTxPackets.Text, preclLabels...)
+		newCounter(logger, ch, ifaceDesc["PreclDroppedPackets"], preclStats.PreclDroppedPackets.Text, preclLabels...)
+	}
+	newCounter(logger, ch, ifaceDesc["FecCcwCount"], ifaceData.EthernetFecStatistics.FecCcwCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FecNccwCount"], ifaceData.EthernetFecStatistics.FecNccwCount.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FecCcwErrorRate"], ifaceData.EthernetFecStatistics.FecCcwErrorRate.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FecNccwErrorRate"], ifaceData.EthernetFecStatistics.FecNccwErrorRate.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecTxScProtected"], ifaceData.MacsecStatistics.MacsecTxScProtected.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecTxScEncrypted"], ifaceData.MacsecStatistics.MacsecTxScEncrypted.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecTxScProtectedbytes"], ifaceData.MacsecStatistics.MacsecTxScProtectedbytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecTxScEncryptedbytes"], ifaceData.MacsecStatistics.MacsecTxScEncryptedbytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecRxScOk"], ifaceData.MacsecStatistics.MacsecRxScOk.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecRxScValidatedbytes"], ifaceData.MacsecStatistics.MacsecRxScValidatedbytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["MacsecRxScDecryptedbytes"], ifaceData.MacsecStatistics.MacsecRxScDecryptedbytes.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OversizedFrames"], ifaceData.MultilinkInterfaceErrors.OversizedFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputErrorFrames"], ifaceData.MultilinkInterfaceErrors.InputErrorFrames.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputDisabledBundle"], ifaceData.MultilinkInterfaceErrors.InputDisabledBundle.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputDisabledBundle"], ifaceData.MultilinkInterfaceErrors.OutputDisabledBundle.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["QueuingDrops"], ifaceData.MultilinkInterfaceErrors.QueuingDrops.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["PacketBufferOverflow"], ifaceData.MultilinkInterfaceErrors.PacketBufferOverflow.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FragmentBufferOverflow"], ifaceData.MultilinkInterfaceErrors.FragmentBufferOverflow.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FragmentTimeout"], ifaceData.MultilinkInterfaceErrors.FragmentTimeout.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["SequenceNumberMissing"], ifaceData.MultilinkInterfaceErrors.SequenceNumberMissing.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutOfOrderSequenceNumber"], ifaceData.MultilinkInterfaceErrors.OutOfOrderSequenceNumber.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutOfRangeSequenceNumber"], ifaceData.MultilinkInterfaceErrors.OutOfRangeSequenceNumber.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["DataMemoryError"], ifaceData.MultilinkInterfaceErrors.DataMemoryError.Text, ifaceLabels...)
+	newCounter(logger, ch, ifaceDesc["ControlMemoryError"], ifaceData.MultilinkInterfaceErrors.ControlMemoryError.Text, ifaceLabels...)
+}
+
+// sendIfacePhysicalLocal sends the traffic of a physical interface destined to or sent by the routing engine, which
+// is only reported by its logical interfaces, as the sum of the local traffic of its logical interfaces.
+func sendIfacePhysicalLocal(ch chan<- prometheus.Metric, ifaceDesc map[string]*prometheus.Desc, logIfaces []ifaceLogical, labels []string) {
+	for _, counter := range []struct {
+		desc  string
+		value func(ifaceInOutBytesPkts) string
+	}{
+		{"LocalInputBytes", func(s ifaceInOutBytesPkts) string { return s.InputBytes.Text }},
+		{"LocalOutputBytes", func(s ifaceInOutBytesPkts) string { return s.OutputBytes.Text }},
+		{"LocalInputPackets", func(s ifaceInOutBytesPkts) string { return s.InputPackets.Text }},
+		{"LocalOutputPackets", func(s ifaceInOutBytesPkts) string { return s.OutputPackets.Text }},
+	} {
+		sum, found := 0.0, false
+		for _, logIface := range logIfaces {
+			value, err := strconv.ParseFloat(strings.TrimSpace(counter.value(logIface.LocalTrafficStatistics)), 64)
+			if err != nil {
+				continue
 			}
-			preclLabels := append(ifaceLabels, trafficClass)
-			newCounter(logger, ch, ifaceDesc["PreclRxPackets"], preclStats.PreclRxPackets.Text, preclLabels...)
-			newCounter(logger, ch, ifaceDesc["PreclTxPackets"], preclStats.PreclTxPackets.Text, preclLabels...)
-			newCounter(logger, ch, ifaceDesc["PreclDroppedPackets"], preclStats.PreclDroppedPackets.Text, preclLabels...)
+			sum += value
+			found = true
+		}
+		if found {
+			ch <- prometheus.MustNewConstMetric(ifaceDesc[counter.desc], prometheus.CounterValue, sum, labels...)
 		}
-		newCounter(logger, ch, ifaceDesc["FecCcwCount"], ifaceData.EthernetFecStatistics.FecCcwCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FecNccwCount"], ifaceData.EthernetFecStatistics.FecNccwCount.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FecCcwErrorRate"], ifaceData.EthernetFecStatistics.FecCcwErrorRate.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FecNccwErrorRate"], ifaceData.EthernetFecStatistics.FecNccwErrorRate.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecTxScProtected"], ifaceData.MacsecStatistics.MacsecTxScProtected.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecTxScEncrypted"], ifaceData.MacsecStatistics.MacsecTxScEncrypted.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecTxScProtectedbytes"], ifaceData.MacsecStatistics.MacsecTxScProtectedbytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecTxScEncryptedbytes"], ifaceData.MacsecStatistics.MacsecTxScEncryptedbytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecRxScOk"], ifaceData.MacsecStatistics.MacsecRxScOk.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecRxScValidatedbytes"], ifaceData.MacsecStatistics.MacsecRxScValidatedbytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["MacsecRxScDecryptedbytes"], ifaceData.MacsecStatistics.MacsecRxScDecryptedbytes.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OversizedFrames"], ifaceData.MultilinkInterfaceErrors.OversizedFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputErrorFrames"], ifaceData.MultilinkInterfaceErrors.InputErrorFrames.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["InputDisabledBundle"], ifaceData.MultilinkInterfaceErrors.InputDisabledBundle.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutputDisabledBundle"], ifaceData.MultilinkInterfaceErrors.OutputDisabledBundle.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["QueuingDrops"], ifaceData.MultilinkInterfaceErrors.QueuingDrops.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["PacketBufferOverflow"], ifaceData.MultilinkInterfaceErrors.PacketBufferOverflow.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FragmentBufferOverflow"], ifaceData.MultilinkInterfaceErrors.FragmentBufferOverflow.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["FragmentTimeout"], ifaceData.MultilinkInterfaceErrors.FragmentTimeout.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["SequenceNumberMissing"], ifaceData.MultilinkInterfaceErrors.SequenceNumberMissing.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutOfOrderSequenceNumber"], ifaceData.MultilinkInterfaceErrors.OutOfOrderSequenceNumber.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["OutOfRangeSequenceNumber"], ifaceData.MultilinkInterfaceErrors.OutOfRangeSequenceNumber.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["DataMemoryError"], ifaceData.MultilinkInterfaceErrors.DataMemoryError.Text, ifaceLabels...)
-		newCounter(logger, ch, ifaceDesc["ControlMemoryError"], ifaceData.MultilinkInterfaceErrors.ControlMemoryError.Text, ifaceLabels...)
 	}
-	return nil
 }
 
-type ifaceRPCReply struct {
-	XMLName              xml.Name         `xml:"rpc-reply"`
-	InterfaceInformation ifaceInformation `xml:"interface-information"`
+// sendIfaceLogical sends the metrics of a logical interface.
+func sendIfaceLogical(ch chan<- prometheus.Metric, ifaceDesc map[string]*prometheus.Desc, logIface ifaceLogical, conf Config, logger log.Logger) {
+	ifaceDescrKeys, ifaceMetricKeys := conf.IfaceDescrKeys, conf.IfaceMetricKeys
+	logIfaceName := strings.TrimSpace(logIface.Name.Text)
+	logIfaceLabels := ifaceLabelValues(logIfaceName, logIface.Description.Text, conf)
+	logUpLabels := logIfaceLabels
+	if conf.IfaceDescrLabel {
+		logUpLabels = append(append([]string{}, logIfaceLabels...), strings.TrimSpace(logIface.Description.Text))
+	}
+	allIfaceDescrKeys := parseIfaceDescr(logIface.Description.Text)
+	if logIface.IfConfigFlags.IffUp {
+		ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 1.0, logUpLabels...)
+	} else {
+		ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 0.0, logUpLabels...)
+	}
+	if len(ifaceDescrKeys) > 0 {
+		ifaceDescrLabels := append([]string{logIfaceName}, ifaceDescrKeyValues(allIfaceDescrKeys, ifaceDescrKeys)...)
+		newCounter(logger, ch, ifaceDesc["InterfaceDescription"], "1", ifaceDescrLabels...)
+	}
+	for _, configuredKey := range ifaceMetricKeys {
+		if value, ok := descrValue(allIfaceDescrKeys, configuredKey); ok {
+			newCounter(logger, ch, ifaceDesc[configuredKey], strings.TrimSpace(value), logIfaceLabels...)
+		}
+	}
+	trafficStatsSource := logIface.TransitTrafficStatistics
+	if logIface.LAGTrafficStatistics.LagBundle.InputBps.Text != "" {
+		trafficStatsSource = logIface.LAGTrafficStatistics.LagBundle
+	}
+	newCounter(logger, ch, ifaceDesc["InputBytes"], trafficStatsSource.InputBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputBytes"], trafficStatsSource.OutputBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["InputPackets"], trafficStatsSource.InputPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["OutputPackets"], trafficStatsSource.OutputPackets.Text, logIfaceLabels...)
+	newGauge(logger, ch, ifaceDesc["InputBps"], trafficStatsSource.InputBps.Text, logIfaceLabels...)
+	newGauge(logger, ch, ifaceDesc["OutputBps"], trafficStatsSource.OutputBps.Text, logIfaceLabels...)
+	newGauge(logger, ch, ifaceDesc["InputPps"], trafficStatsSource.InputPps.Text, logIfaceLabels...)
+	newGauge(logger, ch, ifaceDesc["OutputPps"], trafficStatsSource.OutputPps.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["LocalInputBytes"], logIface.LocalTrafficStatistics.InputBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["LocalOutputBytes"], logIface.LocalTrafficStatistics.OutputBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["LocalInputPackets"], logIface.LocalTrafficStatistics.InputPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["LocalOutputPackets"], logIface.LocalTrafficStatistics.OutputPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6InputBytes"], trafficStatsSource.Ipv6TransitStatistics.InputBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6OutputBytes"], trafficStatsSource.Ipv6TransitStatistics.OutputBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6InputPackets"], trafficStatsSource.Ipv6TransitStatistics.InputPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["V6OutputPackets"], trafficStatsSource.Ipv6TransitStatistics.OutputPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorAddressSpoofing"], logIface.SecurityErrorFlowStatistics.FlowErrorAddressSpoofing.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorAuthenticationFailed"], logIface.SecurityErrorFlowStatistics.FlowErrorAuthenticationFailed.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorIncomingNat"], logIface.SecurityErrorFlowStatistics.FlowErrorIncomingNat.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorInvalidZone"], logIface.SecurityErrorFlowStatistics.FlowErrorInvalidZone.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorMultipleAuth"], logIface.SecurityErrorFlowStatistics.FlowErrorMultipleAuth.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorMultipleIncomingNat"], logIface.SecurityErrorFlowStatistics.FlowErrorMultipleIncomingNat.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoGateParent"], logIface.SecurityErrorFlowStatistics.FlowErrorNoGateParent.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoInterestSelfPacket"], logIface.SecurityErrorFlowStatistics.FlowErrorNoInterestSelfPacket.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoMinorSession"], logIface.SecurityErrorFlowStatistics.FlowErrorNoMinorSession.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoMoreSession"], logIface.SecurityErrorFlowStatistics.FlowErrorNoMoreSession.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoNatGate"], logIface.SecurityErrorFlowStatistics.FlowErrorNoNatGate.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoRoutePresent"], logIface.SecurityErrorFlowStatistics.FlowErrorNoRoutePresent.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoSaForSpi"], logIface.SecurityErrorFlowStatistics.FlowErrorNoSaForSpi.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoTunnel"], logIface.SecurityErrorFlowStatistics.FlowErrorNoTunnel.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNoSessionGate"], logIface.SecurityErrorFlowStatistics.FlowErrorNoSessionGate.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorNullZone"], logIface.SecurityErrorFlowStatistics.FlowErrorNullZone.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorPolicyDenied"], logIface.SecurityErrorFlowStatistics.FlowErrorPolicyDenied.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorSecurityAssociationMissing"], logIface.SecurityErrorFlowStatistics.FlowErrorSecurityAssociationMissing.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorSeqOutsideWindow"], logIface.SecurityErrorFlowStatistics.FlowErrorSeqOutsideWindow.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorSynProtection"], logIface.SecurityErrorFlowStatistics.FlowErrorSynProtection.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowErrorUserAuthentication"], logIface.SecurityErrorFlowStatistics.FlowErrorUserAuthentication.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowInputSelfPackets"], logIface.SecurityInputFlowStatistics.FlowInputSelfPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowInputIcmpPackets"], logIface.SecurityInputFlowStatistics.FlowInputIcmpPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowInputVpnPackets"], logIface.SecurityInputFlowStatistics.FlowInputVpnPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowInputMulticastPackets"], logIface.SecurityInputFlowStatistics.FlowInputMulticastPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowInputPolicyBytes"], logIface.SecurityInputFlowStatistics.FlowInputPolicyBytes.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowInputConnections"], logIface.SecurityInputFlowStatistics.FlowInputConnections.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowOutputMulticastPackets"], logIface.SecurityOutputFlowStatistics.FlowOutputMulticastPackets.Text, logIfaceLabels...)
+	newCounter(logger, ch, ifaceDesc["FlowOutputPolicyBytes"], logIface.SecurityOutputFlowStatistics.FlowOutputPolicyBytes.Text, logIfaceLabels...)
+	newGauge(logger, ch, ifaceDesc["SnmpIndex"], logIface.SnmpIndex.Text, logIfaceLabels...)
+}
+
+// parseIfaceSpeed returns the speed in bytes per second of an interface speed such as 10Gbps or 100mbps, and false
+// for speeds without a rate, such as Unlimited or Auto, or that are not a rate.
+func parseIfaceSpeed(text string) (float64, bool) {
+	rate, skip, err := parseRate(text)
+	if err != nil || skip {
+		return 0, false
+	}
+	return rate / 8, true
 }
 
-type ifaceInformation struct {
-	PhysicalInterface []ifacePhysical `xml:"physical-interface"`
+// sendIfaceErrorDisabled sends whether the interface is disabled by each of the errors reported by switches, which
+// report None for errors that did not occur. The Ethernet switching error covers the shutdowns by storm control and
+// MAC move or MAC limit actions, of which the reason is the error reported by the device.
+func sendIfaceErrorDisabled(ch chan<- prometheus.Metric, desc *prometheus.Desc, ifaceData *ifacePhysical, labels []string) {
+	for _, e := range []struct {
+		name  string
+		value string
+	}{
+		{"bpdu", ifaceData.BpduError.Text},
+		{"loop_detect", ifaceData.LdPduError.Text},
+		{"l2pt", ifaceData.L2ptError.Text},
+		{"ethernet_switching", ifaceData.EthSwitchError.Text},
+		{"mac_rewrite", ifaceData.MacRewriteError.Text},
+	} {
+		reason := strings.ToLower(strings.TrimSpace(e.value))
+		switch reason {
+		case "":
+			continue
+		case "none":
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 0, append(append([]string{}, labels...), e.name, "")...)
+		default:
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, append(append([]string{}, labels...), e.name, reason)...)
+		}
+	}
 }
+
+// sendIfaceUtilization sends the input and output utilization of an interface from its traffic rates in bits per
+// second and its speed in bytes per second.
+func sendIfaceUtilization(ch chan<- prometheus.Metric, desc *prometheus.Desc, labels []string, speed float64, inputBps string, outputBps string) {
+	for direction, bps := range map[string]string{"input": inputBps, "output": outputBps} {
+		value, err := strconv.ParseFloat(strings.TrimSpace(bps), 64)
+		if err != nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value/(speed*8), append(append([]string{}, labels...), direction)...)
+	}
+}
+
+// parseIfaceDescr returns the keys of a JSON formatted interface description, nil when the description is not JSON.
+func parseIfaceDescr(description string) map[string]interface{} {
+	// Junos OS Evolved produces a different representation of a JSON string in the description field.
+	// Junos OS Evolved XML output: <description>{\&quot;r_name\&quot;:\&quot;my-far-end-device\&quot;}</description>
+	//      XML decoding results in the string: {\\\"r_name\\\":\\\"my-far-end-device\\\"}
+	//
+	// Junos (regular) XML output: <description>{"r_name":"my-far-end-device"}</description>
+	//      XML decoding results in the string: {\"r_name\":\"my-far-end-device\"}
+	//
+	// This line of code sanitizes the output for the Junos OS Evolved XML response format
+	return parseDescrJSON(strings.ReplaceAll(description, "\\\"", "\""))
+}
+
+// ifaceDescrKeyValues returns the values of keys in the parsed interface description, empty for missing keys.
+func ifaceDescrKeyValues(descrKeys map[string]interface{}, keys []string) []string {
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, _ := descrValue(descrKeys, key)
+		values = append(values, value)
+	}
+	return values
+}
+
+// ifaceLabelValues returns the label values of the metrics of an interface, which are the name of the interface
+// followed by the values of the interface_description_keys when they are added as labels.
+func ifaceLabelValues(name string, description string, conf Config) []string {
+	labels := []string{strings.TrimSpace(name)}
+	if conf.IfaceDescrKeyLabels {
+		labels = append(labels, ifaceDescrKeyValues(parseIfaceDescr(description), conf.IfaceDescrKeys)...)
+	}
+	return labels
+}
+
+// ifaceGNMICounters maps the OpenConfig interface counters to the interface metrics.
+var ifaceGNMICounters = map[string]string{
+	"in-octets":           "InputBytes",
+	"out-octets":          "OutputBytes",
+	"in-pkts":             "InputPackets",
+	"out-pkts":            "OutputPackets",
+	"in-errors":           "InputErrors",
+	"out-errors":          "OutputErrors",
+	"in-discards":         "InputDiscards",
+	"out-discards":        "OutputDrops",
+	"carrier-transitions": "CarrierTransitions",
+}
+
+func processIfaceGNMILeaves(leaves []gnmiLeaf, ch chan<- prometheus.Metric, conf Config, logger log.Logger) {
+	ifaceDesc := getInterfaceDesc(conf)
+	adminStatus := map[string]string{}
+	operStatus := map[string]string{}
+	descriptions := map[string]string{}
+	indexes := ifIndexRecorder{}
+	// Counters are sent once the descriptions of all interfaces are known, for the description keys added as labels.
+	var counters []gnmiLeaf
+	for _, leaf := range leaves {
+		name, ok := leaf.key("interface", "name")
+		if !ok || leaf.has("subinterface") {
+			continue
+		}
+		switch {
+		case leaf.parent() == "counters":
+			if _, ok := ifaceGNMICounters[leaf.name()]; ok {
+				counters = append(counters, leaf)
+			}
+		case leaf.name() == "admin-status":
+			adminStatus[name] = leaf.value
+		case leaf.name() == "oper-status":
+			operStatus[name] = leaf.value
+		case leaf.name() == "description":
+			descriptions[name] = leaf.value
+		case leaf.name() == "ifindex":
+			indexes.add(name, leaf.value)
+		}
+	}
+	indexes.store(conf.SSHTarget)
+	for _, leaf := range counters {
+		name, _ := leaf.key("interface", "name")
+		newCounter(logger, ch, ifaceDesc[ifaceGNMICounters[leaf.name()]], leaf.value, ifaceLabelValues(name, descriptions[name], conf)...)
+	}
+	for name, status := range adminStatus {
+		upLabels := ifaceLabelValues(name, descriptions[name], conf)
+		if conf.IfaceDescrLabel {
+			upLabels = append(upLabels, descriptions[name])
+		}
+		if status == "UP" {
+			if operStatus[name] == "UP" {
+				ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 1.0, upLabels...)
+			} else {
+				ch <- prometheus.MustNewConstMetric(ifaceDesc["Up"], prometheus.GaugeValue, 0.0, upLabels...)
+			}
+		}
+	}
+}
+
 type ifacePhysical struct {
 	Name        ifaceText `xml:"name"`
 	AdminStatus ifaceText `xml:"admin-status"`
@@ -502,9 +757,12 @@ type ifacePhysical struct {
 	InputErrorList    ifaceInputErrorList         `xml:"input-error-list"`
 	OutputErrorList   ifaceOutputErrorList        `xml:"output-error-list"`
 	LogicalInterfaces []ifaceLogical              `xml:"logical-interface"`
-	// BpduError                ifaceText                   `xml:"bpdu-error"`
-	// LdPduError               ifaceText                   `xml:"ld-pdu-error"`
-	// L2ptError                ifaceText                   `xml:"l2pt-error"`
+	// Errors disabling the interface reported by switches, None when the interface is not disabled by the error.
+	BpduError                ifaceText            `xml:"bpdu-error"`
+	LdPduError               ifaceText            `xml:"ld-pdu-error"`
+	L2ptError                ifaceText            `xml:"l2pt-error"`
+	EthSwitchError           ifaceText            `xml:"eth-switch-error"`
+	MacRewriteError          ifaceText            `xml:"mac-rewrite-error"`
 	StpTrafficStatistics     ifaceSTPTrafficStats `xml:"stp-traffic-statistics"`
 	EthernetPcsStatistics    ifacePCSStats        `xml:"ethernet-pcs-statistics"`
 	EthernetMacStatistics    ifaceMACStats        `xml:"ethernet-mac-statistics"`
@@ -622,9 +880,11 @@ type ifaceLogical struct {
 	SnmpIndex         ifaceText             `xml:"snmp-index"`
 	TrafficStatistics ifaceInOutBytesPktsV6 `xml:"traffic-statistics"`
 	IfConfigFlags     ifaceConfigFlags      `xml:"if-config-flags"`
-	// LocalTrafficStatistics       ifaceInOutBytesPkts         `xml:"local-traffic-statistics"`
+	// Traffic destined to or sent by the routing engine.
+	LocalTrafficStatistics       ifaceInOutBytesPkts         `xml:"local-traffic-statistics"`
 	TransitTrafficStatistics     ifaceInOutBytesPktsBPSPPSV6 `xml:"transit-traffic-statistics"`
 	LAGTrafficStatistics         ifaceLAGTrafficStats        `xml:"lag-traffic-statistics"`
+	AddressFamilies              []ifaceAddressFamily        `xml:"address-family"`
 	SecurityInputFlowStatistics  ifaceSecInFlow              `xml:"security-input-flow-statistics"`
 	SecurityOutputFlowStatistics ifaceSecOutFlow             `xml:"security-output-flow-statistics"`
 	SecurityErrorFlowStatistics  ifaceSecErrorFlow           `xml:"security-error-flow-statistics"`
@@ -668,19 +928,15 @@ type ifaceSecInFlow struct {
 	FlowInputConnections      ifaceText `xml:"flow-input-connections"`
 }
 
+type ifaceAddressFamily struct {
+	AeBundleName ifaceText `xml:"ae-bundle-name"`
+}
+
 type BoolIfPresent bool
 type ifaceConfigFlags struct {
 	IffUp BoolIfPresent `xml:"iff-up"`
 }
 
-// Leaving the below as it may be implemented in the future
-// type ifaceLogicalLocalTrafficStats struct {
-// 	InputBytes    ifaceText `xml:"input-bytes"`
-// 	OutputBytes   ifaceText `xml:"output-bytes"`
-// 	InputPackets  ifaceText `xml:"input-packets"`
-// 	OutputPackets ifaceText `xml:"output-packets"`
-// }
-
 type ifaceInOutBytesPkts struct {
 	InputBytes    ifaceText `xml:"input-bytes"`
 	OutputBytes   ifaceText `xml:"output-bytes"`
@@ -1,9 +1,11 @@
 package collector
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Juniper/go-netconf/netconf"
 	"github.com/go-kit/log"
@@ -11,13 +13,22 @@ import (
 )
 
 var (
-	ipsecSubsystem   = "ipsec"
-	totalIpsecErrors = 0.0
+	ipsecSubsystem = "ipsec"
 
-	ipsecLabels = map[string][]string{"TunnelInformation": []string{"saremotegateway", "satunnelindex"}}
+	ipsecLabels = map[string][]string{"TunnelInformation": []string{"saremotegateway", "satunnelindex"}, "TunnelEvent": []string{"saremotegateway", "satunnelindex", "event"}}
 	ipsecDesc   = map[string]*prometheus.Desc{
-		"TunnelStatusUp": colPromDesc(ipsecSubsystem, "tunnel_status_up", "Tunnel Status (1 UP, 0 DOWN)", ipsecLabels["TunnelInformation"]),
+		"TunnelStatusUp":         colPromDesc(ipsecSubsystem, "tunnel_status_up", "Tunnel Status (1 UP, 0 DOWN)", ipsecLabels["TunnelInformation"]),
+		"TunnelEncryptedBytes":   colPromDesc(ipsecSubsystem, "tunnel_encrypted_bytes", "Bytes Encrypted by the Tunnel", ipsecLabels["TunnelInformation"]),
+		"TunnelDecryptedBytes":   colPromDesc(ipsecSubsystem, "tunnel_decrypted_bytes", "Bytes Decrypted by the Tunnel", ipsecLabels["TunnelInformation"]),
+		"TunnelEncryptedPackets": colPromDesc(ipsecSubsystem, "tunnel_encrypted_packets", "Packets Encrypted by the Tunnel", ipsecLabels["TunnelInformation"]),
+		"TunnelDecryptedPackets": colPromDesc(ipsecSubsystem, "tunnel_decrypted_packets", "Packets Decrypted by the Tunnel", ipsecLabels["TunnelInformation"]),
+		"TunnelEventInfo":        colPromDesc(ipsecSubsystem, "tunnel_event_info", "Last Event of the Inactive Tunnel, always 1", ipsecLabels["TunnelEvent"]),
+		"TunnelEventCount":       colPromDesc(ipsecSubsystem, "tunnel_event_count", "Number of Times the Last Event of the Inactive Tunnel Occurred", ipsecLabels["TunnelEvent"]),
+		"TunnelEventTime":        colPromDesc(ipsecSubsystem, "tunnel_event_timestamp_seconds", "Time of the Last Event of the Inactive Tunnel", ipsecLabels["TunnelEvent"]),
 	}
+
+	// Formats of the time of the last event of an inactive tunnel, such as "Thu Dec 17 2020 06:19:25 -0800".
+	ipsecEventTimeLayouts = []string{"Mon Jan 2 2006 15:04:05 -0700", "Mon Jan 2 2006 15:04:05 MST", "Jan 2 2006 15:04:05 -0700"}
 )
 
 // EnvCollector collects environment metrics, implemented as per the Collector interface.
@@ -35,47 +46,70 @@ func (*IpsecCollector) Name() string {
 	return ipsecSubsystem
 }
 
+// RPCs executed by the collector.
+func (*IpsecCollector) RPCs() []string {
+	return []string{"get-inactive-tunnels", "get-security-associations-information", "get-ipsec-statistics-information"}
+}
+
+// UnsupportedPlatforms returns the platforms without IPsec VPNs, as per the PlatformLimiter interface.
+func (*IpsecCollector) UnsupportedPlatforms() []string {
+	return []string{"ex", "qfx", "ptx", "acx", "evo"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *IpsecCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *IpsecCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
-	if err != nil {
-		totalIpsecErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalIpsecErrors
-	}
-	defer s.Close()
-
-	// IPsec inactive tunnels
-	reply, err := s.Exec(netconf.RawMethod(`<get-inactive-tunnels/>`))
-	if err != nil {
-		totalIpsecErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalIpsecErrors
-	}
+	replies, errs := execAll(ctx, conf,
+		// IPsec inactive tunnels
+		netconf.RawMethod(`<get-inactive-tunnels/>`),
+		// IPsec active tunnels
+		netconf.RawMethod(`<get-security-associations-information/>`),
+	)
 
-	if err := processIpsecInactiveNetconfReply(reply, ch, conf.SSHTarget); err != nil {
-		totalIpsecErrors++
+	if errs[0] != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", errs[0]))
+	} else if err := processIpsecInactiveNetconfReply(replies[0], ch, conf.SSHTarget, c.logger); err != nil {
 		errors = append(errors, err)
 	}
 
-	// IPsec active tunnels
-	reply, err = s.Exec(netconf.RawMethod(`<get-security-associations-information/>`))
-	if err != nil {
-		totalIpsecErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalIpsecErrors
+	var tunnels []ipsecTunnel
+	if errs[1] != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", errs[1]))
+	} else {
+		var err error
+		if tunnels, err = processIpsecActiveNetconfReply(replies[1], ch); err != nil {
+			errors = append(errors, err)
+		}
 	}
 
-	if err := processIpsecActiveNetconfReply(reply, ch); err != nil {
-		totalIpsecErrors++
-		errors = append(errors, err)
+	if conf.IpsecTunnelStats {
+		var methods []netconf.RPCMethod
+		for _, tunnel := range tunnels {
+			// show security ipsec statistics index <index>
+			methods = append(methods, netconf.RawMethod(fmt.Sprintf(`<get-ipsec-statistics-information><index>%s</index></get-ipsec-statistics-information>`, tunnel.index)))
+		}
+		replies, errs = execAll(ctx, conf, methods...)
+		for i, tunnel := range tunnels {
+			if errs[i] != nil {
+				errors = append(errors, fmt.Errorf("could not execute netconf RPC call for tunnel %s: %w", tunnel.index, errs[i]))
+				continue
+			}
+			if err := processIpsecStatisticsNetconfReply(replies[i], ch, c.logger, tunnel.remoteGateway, tunnel.index); err != nil {
+				errors = append(errors, err)
+			}
+		}
 	}
 
-	return errors, totalIpsecErrors
+	return errors
+}
+
+// ipsecTunnel is an active tunnel of which the statistics are collected.
+type ipsecTunnel struct {
+	remoteGateway string
+	index         string
 }
 
-func processIpsecInactiveNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, sshTarget string) error {
+func processIpsecInactiveNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, sshTarget string, logger log.Logger) error {
 	var netconfInactiveTunnelReply InactiveTunnelReply
 
 	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfInactiveTunnelReply); err != nil {
@@ -88,18 +122,41 @@ func processIpsecInactiveNetconfReply(reply *netconf.RPCReply, ch chan<- prometh
 		saRemoteGateway := strings.Trim(ipsecData.IpsecSecurityAssociations.SaRemoteGateway, "\n")
 		saTunnelIndex := strings.Trim(ipsecData.IpsecSecurityAssociations.SaTunnelIndex, "\n")
 		ch <- prometheus.MustNewConstMetric(ipsecDesc["TunnelStatusUp"], prometheus.GaugeValue, 0, saRemoteGateway, saTunnelIndex)
+
+		// The last event of the tunnel, such as the reason the IKE negotiation failed.
+		event := strings.TrimSpace(ipsecData.IpsecSecurityAssociations.SaTunnelEvent)
+		if event == "" {
+			continue
+		}
+		eventLabels := []string{saRemoteGateway, saTunnelIndex, event}
+		ch <- prometheus.MustNewConstMetric(ipsecDesc["TunnelEventInfo"], prometheus.GaugeValue, 1, eventLabels...)
+		newGauge(logger, ch, ipsecDesc["TunnelEventCount"], ipsecData.IpsecSecurityAssociations.SaTunnelEventNumTimes, eventLabels...)
+		if t, ok := parseIpsecEventTime(ipsecData.IpsecSecurityAssociations.SaTunnelEventTime); ok {
+			ch <- prometheus.MustNewConstMetric(ipsecDesc["TunnelEventTime"], prometheus.GaugeValue, float64(t.Unix()), eventLabels...)
+		}
 	}
 
 	return nil
 }
 
-// Process active ipsec SAs.
-func processIpsecActiveNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
+func parseIpsecEventTime(eventTime string) (time.Time, bool) {
+	eventTime = strings.Join(strings.Fields(eventTime), " ")
+	for _, layout := range ipsecEventTimeLayouts {
+		if t, err := time.Parse(layout, eventTime); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Process active ipsec SAs, returning the active tunnels.
+func processIpsecActiveNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) ([]ipsecTunnel, error) {
 	var netconfActiveTunnelReply ActiveTunnelReply
 
 	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfActiveTunnelReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf active tunnel reply xml: %s", err)
+		return nil, fmt.Errorf("could not unmarshal netconf active tunnel reply xml: %s", err)
 	}
+	var tunnels []ipsecTunnel
 
 	// Send tunnel status of active tunnels to Prometheus channel.
 	// Set tunnel_status_up to 1
@@ -107,9 +164,34 @@ func processIpsecActiveNetconfReply(reply *netconf.RPCReply, ch chan<- prometheu
 		saRemoteGateway := strings.Trim(ipsecData.IpsecSecurityAssociations.SaRemoteGateway, "\n")
 		saTunnelIndex := strings.Trim(ipsecData.IpsecSecurityAssociations.SaTunnelIndex, "\n")
 		ch <- prometheus.MustNewConstMetric(ipsecDesc["TunnelStatusUp"], prometheus.GaugeValue, 1, saRemoteGateway, saTunnelIndex)
+		tunnels = append(tunnels, ipsecTunnel{remoteGateway: saRemoteGateway, index: saTunnelIndex})
 	}
 
-	return nil
+	return tunnels, nil
+}
+
+// Process the statistics of an active tunnel.
+func processIpsecStatisticsNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger, saRemoteGateway string, saTunnelIndex string) error {
+	// Only the first statistics are of the tunnel as a whole.
+	seen := false
+	return decodeElements(reply, "esp-statistics", func(stats *ipsecESPStatistics) error {
+		if seen {
+			return nil
+		}
+		seen = true
+		newCounter(logger, ch, ipsecDesc["TunnelEncryptedBytes"], stats.EncryptedBytes, saRemoteGateway, saTunnelIndex)
+		newCounter(logger, ch, ipsecDesc["TunnelDecryptedBytes"], stats.DecryptedBytes, saRemoteGateway, saTunnelIndex)
+		newCounter(logger, ch, ipsecDesc["TunnelEncryptedPackets"], stats.EncryptedPackets, saRemoteGateway, saTunnelIndex)
+		newCounter(logger, ch, ipsecDesc["TunnelDecryptedPackets"], stats.DecryptedPackets, saRemoteGateway, saTunnelIndex)
+		return nil
+	})
+}
+
+type ipsecESPStatistics struct {
+	EncryptedBytes   string `xml:"esp-encrypted-bytes"`
+	DecryptedBytes   string `xml:"esp-decrypted-bytes"`
+	EncryptedPackets string `xml:"esp-encrypted-packets"`
+	DecryptedPackets string `xml:"esp-decrypted-packets"`
 }
 
 type InactiveTunnelReply struct {
@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	jtiSubsystem = "telemetry_interface"
+
+	jtiLabels = []string{"system_id", "interface"}
+	jtiDesc   = map[string]*prometheus.Desc{
+		"Up":                   colPromDesc(jtiSubsystem, "up", "Whether the interface is up (1 = up, 0 = down).", jtiLabels),
+		"InputBytes":           colPromDesc(jtiSubsystem, "input_bytes", "Input Bytes.", jtiLabels),
+		"OutputBytes":          colPromDesc(jtiSubsystem, "output_bytes", "Output Bytes.", jtiLabels),
+		"InputPackets":         colPromDesc(jtiSubsystem, "input_packets", "Input Packets.", jtiLabels),
+		"OutputPackets":        colPromDesc(jtiSubsystem, "output_packets", "Output Packets.", jtiLabels),
+		"InputUnicastPackets":  colPromDesc(jtiSubsystem, "input_unicast_packets", "Input Unicast Packets.", jtiLabels),
+		"OutputUnicastPackets": colPromDesc(jtiSubsystem, "output_unicast_packets", "Output Unicast Packets.", jtiLabels),
+		"InputMulticastPkts":   colPromDesc(jtiSubsystem, "input_multicast_packets", "Input Multicast Packets.", jtiLabels),
+		"OutputMulticastPkts":  colPromDesc(jtiSubsystem, "output_multicast_packets", "Output Multicast Packets.", jtiLabels),
+		"InputBroadcastPkts":   colPromDesc(jtiSubsystem, "input_broadcast_packets", "Input Broadcast Packets.", jtiLabels),
+		"OutputBroadcastPkts":  colPromDesc(jtiSubsystem, "output_broadcast_packets", "Output Broadcast Packets.", jtiLabels),
+		"InputBps":             colPromDesc(jtiSubsystem, "input_bytes_per_second", "Input Bytes per Second over the last Second.", jtiLabels),
+		"OutputBps":            colPromDesc(jtiSubsystem, "output_bytes_per_second", "Output Bytes per Second over the last Second.", jtiLabels),
+		"InputPps":             colPromDesc(jtiSubsystem, "input_packets_per_second", "Input Packets per Second over the last Second.", jtiLabels),
+		"OutputPps":            colPromDesc(jtiSubsystem, "output_packets_per_second", "Output Packets per Second over the last Second.", jtiLabels),
+		"InputErrors":          colPromDesc(jtiSubsystem, "input_errors", "Input Errors.", jtiLabels),
+		"OutputErrors":         colPromDesc(jtiSubsystem, "output_errors", "Output Errors.", jtiLabels),
+		"InputDrops":           colPromDesc(jtiSubsystem, "input_drops", "Input Queue Drops.", jtiLabels),
+		"InputDiscards":        colPromDesc(jtiSubsystem, "input_discards", "Input Discards.", jtiLabels),
+		"FramingErrors":        colPromDesc(jtiSubsystem, "framing_errors", "Framing Errors.", jtiLabels),
+		"CarrierTransitions":   colPromDesc(jtiSubsystem, "carrier_transitions", "Carrier transitions.", jtiLabels),
+		"LastUpdate":           colPromDesc(jtiSubsystem, "last_update_timestamp_seconds", "Time the interface was last reported by the device.", jtiLabels),
+	}
+)
+
+// Counters of the InterfaceStats message of the Junos port sensor, by field number.
+var (
+	jtiIngressStats = map[protowire.Number]string{1: "InputPackets", 2: "InputBytes", 3: "InputPps", 4: "InputBps", 5: "InputUnicastPackets", 6: "InputMulticastPkts", 7: "InputBroadcastPkts"}
+	jtiEgressStats  = map[protowire.Number]string{1: "OutputPackets", 2: "OutputBytes", 3: "OutputPps", 4: "OutputBps", 5: "OutputUnicastPackets", 6: "OutputMulticastPkts", 7: "OutputBroadcastPkts", 8: "OutputErrors"}
+	jtiIngressErrs  = map[protowire.Number]string{1: "InputErrors", 2: "InputDrops", 3: "FramingErrors", 4: "InputDiscards"}
+	jtiGauges       = map[string]bool{"InputPps": true, "InputBps": true, "OutputPps": true, "OutputBps": true}
+)
+
+type jtiKey struct {
+	systemID string
+	name     string
+}
+
+type jtiInterface struct {
+	updated    time.Time
+	values     map[string]float64
+	adminState string
+	operState  string
+}
+
+// JTIReceiver receives Junos native streaming telemetry over UDP and exposes the port sensor, implemented as per
+// the prometheus.Collector interface.
+type JTIReceiver struct {
+	mu         sync.Mutex
+	interfaces map[jtiKey]*jtiInterface
+	staleAfter time.Duration
+	logger     log.Logger
+}
+
+// NewJTIReceiver returns a new JTIReceiver. Interfaces that have not been reported for staleAfter are removed.
+func NewJTIReceiver(staleAfter time.Duration, logger log.Logger) *JTIReceiver {
+	return &JTIReceiver{
+		interfaces: map[jtiKey]*jtiInterface{},
+		staleAfter: staleAfter,
+		logger:     logger,
+	}
+}
+
+// ListenAndReceive receives telemetry on the UDP address until an error occurs.
+func (r *JTIReceiver) ListenAndReceive(address string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		if err := r.process(buf[:n]); err != nil {
+			level.Debug(r.logger).Log("msg", "could not decode telemetry", "source", addr, "err", err)
+		}
+	}
+}
+
+// process decodes a TelemetryStream message and stores the interfaces of the port sensor.
+func (r *JTIReceiver) process(b []byte) error {
+	var systemID string
+	var enterprise []byte
+	if err := rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			systemID = string(data)
+		case 101:
+			enterprise = data
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// EnterpriseSensors > juniperNetworks (2636) > jnpr_interface_ext (3) > interface_stats (1).
+	var ports [][]byte
+	if err := rangeProtoFields(enterprise, func(num protowire.Number, _ protowire.Type, juniper []byte, _ uint64) error {
+		if num != 2636 {
+			return nil
+		}
+		return rangeProtoFields(juniper, func(num protowire.Number, _ protowire.Type, port []byte, _ uint64) error {
+			if num != 3 {
+				return nil
+			}
+			return rangeProtoFields(port, func(num protowire.Number, _ protowire.Type, iface []byte, _ uint64) error {
+				if num == 1 {
+					ports = append(ports, iface)
+				}
+				return nil
+			})
+		})
+	}); err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("unsupported sensor from %q", systemID)
+	}
+
+	for _, port := range ports {
+		iface := &jtiInterface{updated: time.Now(), values: map[string]float64{}}
+		var name string
+		if err := rangeProtoFields(port, func(num protowire.Number, _ protowire.Type, data []byte, v uint64) error {
+			switch num {
+			case 1:
+				name = string(data)
+			case 5:
+				return jtiStats(data, jtiEgressStats, iface.values)
+			case 6:
+				return jtiStats(data, jtiIngressStats, iface.values)
+			case 7:
+				return jtiStats(data, jtiIngressErrs, iface.values)
+			case 8:
+				iface.adminState = string(data)
+			case 9:
+				iface.operState = string(data)
+			case 11:
+				iface.values["CarrierTransitions"] = float64(v)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		r.mu.Lock()
+		r.interfaces[jtiKey{systemID: systemID, name: name}] = iface
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+func jtiStats(b []byte, fields map[protowire.Number]string, values map[string]float64) error {
+	return rangeProtoFields(b, func(num protowire.Number, typ protowire.Type, _ []byte, v uint64) error {
+		if name, ok := fields[num]; ok && typ == protowire.VarintType {
+			values[name] = float64(v)
+		}
+		return nil
+	})
+}
+
+// Describe implemented as per the prometheus.Collector interface.
+func (r *JTIReceiver) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range jtiDesc {
+		ch <- desc
+	}
+}
+
+// Collect implemented as per the prometheus.Collector interface.
+func (r *JTIReceiver) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, iface := range r.interfaces {
+		if r.staleAfter > 0 && time.Since(iface.updated) > r.staleAfter {
+			delete(r.interfaces, key)
+			continue
+		}
+		labels := []string{key.systemID, key.name}
+		for name, value := range iface.values {
+			valueType := prometheus.CounterValue
+			if jtiGauges[name] {
+				valueType = prometheus.GaugeValue
+			}
+			ch <- prometheus.MustNewConstMetric(jtiDesc[name], valueType, value, labels...)
+		}
+		if iface.adminState == "UP" {
+			if iface.operState == "UP" {
+				ch <- prometheus.MustNewConstMetric(jtiDesc["Up"], prometheus.GaugeValue, 1.0, labels...)
+			} else {
+				ch <- prometheus.MustNewConstMetric(jtiDesc["Up"], prometheus.GaugeValue, 0.0, labels...)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(jtiDesc["LastUpdate"], prometheus.GaugeValue, float64(iface.updated.Unix()), labels...)
+	}
+}
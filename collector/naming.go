@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	// Names following the Prometheus naming conventions (value) of metrics exported under names without a unit
+	// suffix (key), when the Prometheus naming scheme is used. The values of the metrics are not converted.
+	conventionNames = map[string]string{
+		"junos_interface_input_bps":                 "junos_interface_receive_bits_per_second",
+		"junos_interface_output_bps":                "junos_interface_transmit_bits_per_second",
+		"junos_interface_input_pps":                 "junos_interface_receive_packets_per_second",
+		"junos_interface_output_pps":                "junos_interface_transmit_packets_per_second",
+		"junos_interface_interface_flapped_seconds": "junos_interface_flapped_seconds",
+		"junos_interface_speed_bytes":               "junos_interface_speed_bytes_per_second",
+		"junos_optics_laser_bias_current":           "junos_optics_laser_bias_current_milliamperes",
+		"junos_optics_laser_output_power":           "junos_optics_laser_output_power_milliwatts",
+		"junos_optics_laser_rx_optical_power":       "junos_optics_laser_rx_optical_power_milliwatts",
+		"junos_optics_module_temperature":           "junos_optics_module_temperature_celsius",
+		"junos_optics_module_voltage":               "junos_optics_module_voltage_volts",
+		"junos_fpc_cpu_avg":                         "junos_fpc_cpu_average_percent",
+		"junos_fpc_cpu_interrupt":                   "junos_fpc_cpu_interrupt_percent",
+		"junos_fpc_cpu_total":                       "junos_fpc_cpu_total_percent",
+		"junos_fpc_memory_dram_size":                "junos_fpc_memory_dram_size_bytes",
+		"junos_fpc_memory_buffer_utilization":       "junos_fpc_memory_buffer_utilization_percent",
+		"junos_fpc_memory_heap_utilization":         "junos_fpc_memory_heap_utilization_percent",
+		"junos_fpc_pfe_memory_heap_utilization":     "junos_fpc_pfe_memory_heap_utilization_percent",
+	}
+	// Prefixes of interface metrics replaced by the direction used by the node_exporter when the Prometheus naming
+	// scheme is used.
+	directionPrefixes = []struct{ legacy, convention string }{
+		{"junos_interface_input_", "junos_interface_receive_"},
+		{"junos_interface_output_", "junos_interface_transmit_"},
+	}
+	descHelpRegexp = regexp.MustCompile(`help: ("(?:[^"\\]|\\.)*")`)
+)
+
+// conventionName returns the name following the Prometheus naming conventions of the metric exported under name,
+// adding the _total suffix to counters.
+func conventionName(name string, counter bool) string {
+	if n, ok := conventionNames[name]; ok {
+		name = n
+	} else {
+		for _, p := range directionPrefixes {
+			if strings.HasPrefix(name, p.legacy) {
+				name = p.convention + strings.TrimPrefix(name, p.legacy)
+				break
+			}
+		}
+	}
+	if counter && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+// descHelp returns the help of desc, which is only exposed by its String method.
+func descHelp(desc *prometheus.Desc) string {
+	m := descHelpRegexp.FindStringSubmatch(desc.String())
+	if m == nil {
+		return ""
+	}
+	help, err := strconv.Unquote(m[1])
+	if err != nil {
+		return ""
+	}
+	return help
+}
+
+// renameMetrics sends the metrics received on the returned channel to ch under the names following the Prometheus
+// naming conventions. The returned function must be called once no more metrics are sent.
+func renameMetrics(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	renamed := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Renamed descriptions by the string of the original description, shared by all metrics of a description.
+		descs := map[string]*prometheus.Desc{}
+		for metric := range renamed {
+			ch <- renameMetric(metric, descs)
+		}
+	}()
+	return renamed, func() {
+		close(renamed)
+		<-done
+	}
+}
+
+func renameMetric(metric prometheus.Metric, descs map[string]*prometheus.Desc) prometheus.Metric {
+	m := &dto.Metric{}
+	if err := metric.Write(m); err != nil {
+		return metric
+	}
+	var valueType prometheus.ValueType
+	var value float64
+	switch {
+	case m.Counter != nil:
+		valueType, value = prometheus.CounterValue, m.Counter.GetValue()
+	case m.Gauge != nil:
+		valueType, value = prometheus.GaugeValue, m.Gauge.GetValue()
+	case m.Untyped != nil:
+		valueType, value = prometheus.UntypedValue, m.Untyped.GetValue()
+	default:
+		// Histograms and summaries already follow the naming conventions.
+		return metric
+	}
+	name := descName(metric.Desc())
+	newName := conventionName(name, valueType == prometheus.CounterValue)
+	if newName == name {
+		return metric
+	}
+
+	labelNames := make([]string, 0, len(m.Label))
+	labelValues := make([]string, 0, len(m.Label))
+	for _, label := range m.Label {
+		labelNames = append(labelNames, label.GetName())
+		labelValues = append(labelValues, label.GetValue())
+	}
+	key := metric.Desc().String()
+	desc, ok := descs[key]
+	if !ok {
+		desc = prometheus.NewDesc(newName, descHelp(metric.Desc()), labelNames, nil)
+		descs[key] = desc
+	}
+	renamedMetric, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		return metric
+	}
+	if m.TimestampMs != nil {
+		return prometheus.NewMetricWithTimestamp(time.UnixMilli(m.GetTimestampMs()), renamedMetric)
+	}
+	return renamedMetric
+}
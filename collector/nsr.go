@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	nsrSubsystem = "nsr"
+
+	// Replication states of a protocol, each exported as a series of which the current state is 1.
+	nsrReplicationStates = []string{"Complete", "InProgress", "NotStarted"}
+
+	nsrDesc = map[string]*prometheus.Desc{
+		"Enabled":          colPromDesc(nsrSubsystem, "enabled", "Whether stateful replication is enabled (1 = Enabled, 0 = Disabled).", nil),
+		"ReplicationState": colPromDesc(nsrSubsystem, "protocol_replication_state", "Replication state of the protocol to the backup route engine, 1 for the current state.", []string{"protocol", "state"}),
+	}
+)
+
+// NSRCollector collects nonstop active routing replication metrics, implemented as per the Collector interface.
+type NSRCollector struct {
+	logger log.Logger
+}
+
+// NewNSRCollector returns a new NSRCollector.
+func NewNSRCollector(logger log.Logger) *NSRCollector {
+	return &NSRCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*NSRCollector) Name() string {
+	return nsrSubsystem
+}
+
+// RPCs executed by the collector.
+func (*NSRCollector) RPCs() []string {
+	return []string{"get-routing-task-replication-state"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *NSRCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	// show task replication | display xml
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-routing-task-replication-state/>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		return errors
+	}
+
+	if err := processNSRNetconfReply(reply, ch); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+func processNSRNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
+	var netconfReply nsrRPCReply
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf task replication reply: %s", err)
+	}
+	replication := netconfReply.TaskReplicationState
+
+	enabled := 0.0
+	if strings.EqualFold(strings.TrimSpace(replication.GRESState), "enabled") {
+		enabled = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(nsrDesc["Enabled"], prometheus.GaugeValue, enabled)
+
+	// The name and state of each protocol are sibling elements, which are in the same order.
+	for i, protocol := range replication.ProtocolName {
+		if i >= len(replication.ProtocolState) {
+			break
+		}
+		protocol = strings.TrimSpace(protocol)
+		current := strings.TrimSpace(replication.ProtocolState[i])
+		known := false
+		for _, state := range nsrReplicationStates {
+			value := 0.0
+			if strings.EqualFold(current, state) {
+				value = 1.0
+				known = true
+			}
+			ch <- prometheus.MustNewConstMetric(nsrDesc["ReplicationState"], prometheus.GaugeValue, value, protocol, state)
+		}
+		if !known && current != "" {
+			ch <- prometheus.MustNewConstMetric(nsrDesc["ReplicationState"], prometheus.GaugeValue, 1.0, protocol, current)
+		}
+	}
+	return nil
+}
+
+type nsrRPCReply struct {
+	XMLName              xml.Name                `xml:"rpc-reply"`
+	TaskReplicationState nsrTaskReplicationState `xml:"task-replication-state"`
+}
+
+type nsrTaskReplicationState struct {
+	GRESState     string   `xml:"task-gres-state"`
+	REMode        string   `xml:"task-re-mode"`
+	ProtocolName  []string `xml:"task-protocol-replication-name"`
+	ProtocolState []string `xml:"task-protocol-replication-state"`
+}
@@ -1,7 +1,7 @@
 package collector
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"strings"
 
@@ -77,8 +77,6 @@ var (
 		"NonChannelizedRxSignalAvgOpticalPower":    colPromDesc(opticsSubsystem, "laser_rx_optical_power", "Rx Signal Avg Optical Power", opticsLabel),
 		"NonChannelizedRxSignalAvgOpticalPowerDbm": colPromDesc(opticsSubsystem, "laser_rx_optical_power_dbm", "Rx Signal Avg Optical Power Dbm", opticsLabel),
 	}
-
-	totalOpticsErrors = 0.0
 )
 
 // OpticsCollector collects power metrics, implemented as per the Collector interface.
@@ -96,38 +94,28 @@ func (*OpticsCollector) Name() string {
 	return opticsSubsystem
 }
 
+// RPCs executed by the collector.
+func (*OpticsCollector) RPCs() []string {
+	return []string{"get-interface-optics-diagnostics-information"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *OpticsCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *OpticsCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
-	if err != nil {
-		totalOpticsErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalOpticsErrors
-	}
-	defer s.Close()
-
-	reply, err := s.Exec(netconf.RawMethod(`<get-interface-optics-diagnostics-information></get-interface-optics-diagnostics-information>`))
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-interface-optics-diagnostics-information></get-interface-optics-diagnostics-information>`))
 	if err != nil {
-		totalOpticsErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalOpticsErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		return errors
 	}
 
 	if err := processOpticsNetconfReply(reply, ch, c.logger); err != nil {
-		totalOpticsErrors++
 		errors = append(errors, err)
 	}
-	return errors, totalOpticsErrors
+	return errors
 }
 
 func processOpticsNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
-	var netconfReply opticsRPCReply
-
-	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
-	}
-	for _, opticsData := range netconfReply.OpticsInterfaceInformation.OpticsPhysicalInterface {
+	return decodeElements(reply, "physical-interface", func(opticsData *opticsPhysicalInterface) error {
 		labels := []string{strings.TrimSpace(opticsData.Name.Text)}
 		opticsLaserNoLight := -40.0
 
@@ -313,15 +301,8 @@ func processOpticsNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Met
 			}
 			ch <- prometheus.MustNewConstMetric(opticsDesc["TxLaserDisabledAlarm"], prometheus.GaugeValue, opticLaneTxLaserDisabledAlarm, laneLabels...)
 		}
-	}
-	return nil
-}
-
-type opticsRPCReply struct {
-	OpticsInterfaceInformation opticsInterfaceInformation `xml:"interface-information"`
-}
-type opticsInterfaceInformation struct {
-	OpticsPhysicalInterface []opticsPhysicalInterface `xml:"physical-interface"`
+		return nil
+	})
 }
 
 type opticsPhysicalInterface struct {
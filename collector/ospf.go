@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 
@@ -10,12 +11,14 @@ import (
 )
 
 var (
-	ospfSubsystem   = "ospf"
-	totalOSPFErrors = 0.0
+	ospfSubsystem = "ospf"
 
-	ospfPeerLabels = []string{"neighbor_address", "neighbor_id", "local_interface"}
+	ospfPeerLabels = []string{"neighbor_address", "neighbor_id", "local_interface", "area"}
 	ospfDesc       = map[string]*prometheus.Desc{
-		"NeighborStatus": colPromDesc(ospfSubsystem, "neighbot_status", "OSPF Neighbor Status", ospfPeerLabels),
+		"NeighborStatus":       colPromDesc(ospfSubsystem, "neighbot_status", "OSPF Neighbor Status", ospfPeerLabels),
+		"NeighborUptime":       colPromDesc(ospfSubsystem, "neighbor_uptime_seconds", "Time since the OSPF neighbor was first seen", ospfPeerLabels),
+		"NeighborAdjacencyAge": colPromDesc(ospfSubsystem, "neighbor_adjacency_seconds", "Time since the adjacency with the OSPF neighbor was established", ospfPeerLabels),
+		"AreaNeighbors":        colPromDesc(ospfSubsystem, "area_neighbors", "Number of OSPF neighbors of the area per state", []string{"area", "state"}),
 	}
 )
 
@@ -34,49 +37,59 @@ func (*OSPFCollector) Name() string {
 	return ospfSubsystem
 }
 
+// RPCs executed by the collector.
+func (*OSPFCollector) RPCs() []string {
+	return []string{"get-ospf-neighbor-information"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *OSPFCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *OSPFCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
-	if err != nil {
-		totalOSPFErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalOSPFErrors
-	}
-	defer s.Close()
-
-	// show ospf neighbor | display xml
-	reply, err := s.Exec(netconf.RawMethod(`<get-ospf-neighbor-information/>`))
+	// show ospf neighbor extensive | display xml
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-ospf-neighbor-information><extensive/></get-ospf-neighbor-information>`))
 	if err != nil {
-		totalOSPFErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalOSPFErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		return errors
 	}
 
-	if err := processOSPFNetconfReply(reply, ch); err != nil {
-		totalOSPFErrors++
+	if err := processOSPFNetconfReply(reply, ch, c.logger); err != nil {
 		errors = append(errors, err)
 	}
-	return errors, totalOSPFErrors
+	return errors
 }
 
-func processOSPFNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
+func processOSPFNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
 	ospfNbrStatus := 0.0
 	var netconfReply ospfNeighborRPCReply
 	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
 		return fmt.Errorf("could not unmarshal netconf ospf neighbor reply: %s", err)
 	}
+	// Neighbors per state (value) of each area (key), Full being exported for each area even without full neighbors.
+	areaNeighbors := map[string]map[string]float64{}
 	for _, neighbor := range netconfReply.OSPFNbrInformation.OSPFNeighbor {
+		if _, ok := areaNeighbors[neighbor.OSPFArea]; !ok {
+			areaNeighbors[neighbor.OSPFArea] = map[string]float64{"Full": 0}
+		}
+		areaNeighbors[neighbor.OSPFArea][neighbor.OSPFNeighborState]++
+
 		ospfNbrStatus = 0.0
 		ospfPeerLabels := []string{
 			neighbor.NeighborAddress,
 			neighbor.NeighborId,
 			neighbor.InterfaceName,
+			neighbor.OSPFArea,
 		}
 		if neighbor.OSPFNeighborState == "Full" {
 			ospfNbrStatus = 1.0
 		}
 		ch <- prometheus.MustNewConstMetric(ospfDesc["NeighborStatus"], prometheus.GaugeValue, ospfNbrStatus, ospfPeerLabels...)
+		newGauge(logger, ch, ospfDesc["NeighborUptime"], neighbor.NeighborUpTime.Seconds, ospfPeerLabels...)
+		newGauge(logger, ch, ospfDesc["NeighborAdjacencyAge"], neighbor.NeighborAdjacencyTime.Seconds, ospfPeerLabels...)
+	}
+	for area, states := range areaNeighbors {
+		for state, count := range states {
+			ch <- prometheus.MustNewConstMetric(ospfDesc["AreaNeighbors"], prometheus.GaugeValue, count, area, state)
+		}
 	}
 	return nil
 }
@@ -98,4 +111,13 @@ type ospfNeighbor struct {
 	NeighborId        string `xml:"neighbor-id"`
 	NeighborPriority  string `xml:"neighbor-priority"`
 	ActivityTimer     string `xml:"activity-timer"`
+	// Only in the extensive output.
+	OSPFArea              string      `xml:"ospf-area"`
+	NeighborUpTime        ospfSeconds `xml:"neighbor-up-time"`
+	NeighborAdjacencyTime ospfSeconds `xml:"neighbor-adjacency-time"`
+}
+
+type ospfSeconds struct {
+	Text    string `xml:",chardata"`
+	Seconds string `xml:"seconds,attr"`
 }
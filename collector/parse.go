@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	parseErrors = &targetCounters{
+		opts: prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parse_errors_total",
+			Help:      "Total number of values of a collector that could not be converted to a number, by metric.",
+		},
+		labels: []string{"collector", "field"},
+	}
+
+	// parseTrackers holds the parseTracker (value) of each collector running, by the metric channel passed to the
+	// collector (key), as the values are converted by helpers that are only passed the channel.
+	parseTrackers sync.Map
+
+	errNegativeCounter = errors.New("negative counter value")
+
+	// Values Junos reports in place of a number that is not available, such as the optical power of an absent optic,
+	// compared ignoring case.
+	placeholderValues = map[string]bool{
+		"":              true,
+		"-":             true,
+		"--":            true,
+		"n/a":           true,
+		"na":            true,
+		"not supported": true,
+		"unsupported":   true,
+		"not available": true,
+		"unavailable":   true,
+		"unknown":       true,
+	}
+
+	// Units following numbers that do not change their scale, such as the unit of "45 degrees C", compared ignoring
+	// case.
+	plainUnits = []string{"degrees c", "percent", "%", "rpm", "watts", "volts", "amps"}
+)
+
+// isPlaceholder returns whether value is empty or a placeholder of a value that is not available.
+func isPlaceholder(value string) bool {
+	return placeholderValues[strings.ToLower(strings.TrimSpace(value))]
+}
+
+// parseValue converts value to a number, ignoring any unit in plainUnits. skip is set for empty values and placeholders
+// of values that are not available, which are not exported rather than exported as zero.
+func parseValue(value string) (float64, bool, error) {
+	if isPlaceholder(value) {
+		return 0, true, nil
+	}
+	s := strings.TrimSpace(value)
+	for _, unit := range plainUnits {
+		if len(s) > len(unit) && strings.EqualFold(s[len(s)-len(unit):], unit) {
+			s = strings.TrimSpace(s[:len(s)-len(unit)])
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return v, false, nil
+}
+
+// targetCounters keeps a CounterVec per target, the series of a target are only exported when scraping it.
+type targetCounters struct {
+	opts   prometheus.CounterOpts
+	labels []string
+	vecs   sync.Map
+}
+
+func (c *targetCounters) inc(target string, labels ...string) {
+	vec, ok := c.vecs.Load(target)
+	if !ok {
+		vec, _ = c.vecs.LoadOrStore(target, prometheus.NewCounterVec(c.opts, c.labels))
+	}
+	vec.(*prometheus.CounterVec).WithLabelValues(labels...).Inc()
+}
+
+func (c *targetCounters) collect(target string, ch chan<- prometheus.Metric) {
+	if vec, ok := c.vecs.Load(target); ok {
+		vec.(*prometheus.CounterVec).Collect(ch)
+	}
+}
+
+func (c *targetCounters) describe(ch chan<- *prometheus.Desc) {
+	prometheus.NewCounterVec(c.opts, c.labels).Describe(ch)
+}
+
+// parseTracker records the values of a collector that could not be converted to a number during a scrape.
+type parseTracker struct {
+	target    string
+	collector string
+	mu        sync.Mutex
+	errors    []error
+}
+
+// trackParseErrors sends the metrics received on the returned channel to ch, recording the values that could not be
+// converted by the helpers sending to the returned channel. The returned function must be called once no more metrics
+// are sent, and returns the errors converting the values.
+func trackParseErrors(ch chan<- prometheus.Metric, target string, collector string) (chan<- prometheus.Metric, func() []error) {
+	tracked := make(chan prometheus.Metric)
+	tracker := &parseTracker{target: target, collector: collector}
+	parseTrackers.Store((chan<- prometheus.Metric)(tracked), tracker)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range tracked {
+			ch <- metric
+		}
+	}()
+	return tracked, func() []error {
+		parseTrackers.Delete((chan<- prometheus.Metric)(tracked))
+		close(tracked)
+		<-done
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		return tracker.errors
+	}
+}
+
+// parseFailed records that value of the metric of desc sent to ch could not be converted to a number.
+func parseFailed(logger log.Logger, ch chan<- prometheus.Metric, desc *prometheus.Desc, value string, err error) {
+	field := descName(desc)
+	level.Error(logger).Log("msg", "could not convert metric to float64", "metric", field, "value", value, "err", err)
+	t, ok := parseTrackers.Load(ch)
+	if !ok {
+		return
+	}
+	tracker := t.(*parseTracker)
+	parseErrors.inc(tracker.target, tracker.collector, field)
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.errors = append(tracker.errors, fmt.Errorf("could not parse value %q of %s: %s", value, field, err))
+}
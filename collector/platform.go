@@ -0,0 +1,138 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var platformDesc = promDesc("platform_info", "Platform of the device detected by platform detection, used to skip collectors that do not work on the platform.", []string{"platform", "model", "version"})
+
+// Platform of the product models starting with each prefix, vMX and vSRX matching their hardware counterparts.
+var platformModels = []struct {
+	prefix   string
+	platform string
+}{
+	{"srx", "srx"},
+	{"vsrx", "srx"},
+	{"mx", "mx"},
+	{"vmx", "mx"},
+	{"ex", "ex"},
+	{"qfx", "qfx"},
+	{"ptx", "ptx"},
+	{"acx", "acx"},
+}
+
+// Platform of a device, detected from its software information.
+type Platform struct {
+	// One of srx, mx, ex, qfx, ptx or acx, or evo for all devices running Junos OS Evolved, empty when unknown.
+	Name    string
+	Model   string
+	Version string
+	// Nodes of chassis clusters, such as node0 and node1, empty for other devices.
+	Nodes []string
+}
+
+var (
+	platformsMu sync.Mutex
+	// Platform (value) detected per target (key), kept until the target is stale.
+	platforms = map[string]Platform{}
+)
+
+// targetPlatform returns the platform of target, detecting it using s on the first scrape of the target. Failed
+// detections are retried on the next scrape.
+func targetPlatform(ctx context.Context, s *Session, target string) (Platform, error) {
+	platformsMu.Lock()
+	platform, ok := platforms[target]
+	platformsMu.Unlock()
+	if ok {
+		return platform, nil
+	}
+
+	platform, err := detectPlatform(ctx, s)
+	if err != nil {
+		return Platform{}, err
+	}
+	platformsMu.Lock()
+	platforms[target] = platform
+	platformsMu.Unlock()
+	return platform, nil
+}
+
+// detectPlatform reads the product model and release of the device from get-software-information, using those of the
+// first routing engine or cluster node of devices replying for each of them, as well as the nodes of chassis clusters.
+func detectPlatform(ctx context.Context, s *Session) (Platform, error) {
+	ctx = context.WithValue(ctx, collectorKey{}, "platform")
+	reply, err := s.Exec(ctx, netconf.RawMethod(`<get-software-information/>`))
+	if err != nil {
+		return Platform{}, fmt.Errorf("could not execute netconf RPC call: %w", err)
+	}
+
+	var platform Platform
+	err = decodeMemberElements(reply, "software-information", func(member string, info *struct {
+		ProductModel string `xml:"product-model"`
+		JunosVersion string `xml:"junos-version"`
+	}) error {
+		// The members of virtual chassis are named fpc0, fpc1 and so on.
+		if strings.HasPrefix(member, "node") {
+			platform.Nodes = append(platform.Nodes, member)
+		}
+		if platform.Model == "" {
+			platform.Model = strings.ToLower(strings.TrimSpace(info.ProductModel))
+			platform.Version = strings.TrimSpace(info.JunosVersion)
+		}
+		return nil
+	})
+	if err != nil {
+		return Platform{}, err
+	}
+	if platform.Model == "" {
+		return Platform{}, fmt.Errorf("could not parse platform: no product-model in reply")
+	}
+	platform.Name = platformName(platform.Model, platform.Version)
+	return platform, nil
+}
+
+// platformName returns the platform of the product model, evo for releases of Junos OS Evolved, such as 22.4R2-EVO.
+func platformName(model string, version string) string {
+	if strings.HasSuffix(strings.ToUpper(version), "-EVO") {
+		return "evo"
+	}
+	for _, m := range platformModels {
+		if strings.HasPrefix(model, m.prefix) {
+			return m.platform
+		}
+	}
+	return ""
+}
+
+// platformCollectors returns the collectors that are not known not to work on platform, along with the names of
+// those skipped.
+func platformCollectors(collectors []Collector, platform string) ([]Collector, []string) {
+	if platform == "" {
+		return collectors, nil
+	}
+	var supported []Collector
+	var skipped []string
+	for _, c := range collectors {
+		if limiter, ok := c.(PlatformLimiter); ok {
+			for _, unsupported := range limiter.UnsupportedPlatforms() {
+				if unsupported == platform {
+					skipped = append(skipped, c.Name())
+					goto Skipped
+				}
+			}
+		}
+		supported = append(supported, c)
+	Skipped:
+	}
+	return supported, skipped
+}
+
+func sendPlatform(ch chan<- prometheus.Metric, platform Platform) {
+	ch <- prometheus.MustNewConstMetric(platformDesc, prometheus.GaugeValue, 1, platform.Name, platform.Model, platform.Version)
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Factory creates a collector registered using Register.
+type Factory func(logger log.Logger) Collector
+
+var (
+	pluginsMu sync.Mutex
+	plugins   []Factory
+)
+
+// Register registers a collector that is not part of junos_exporter, so that it is compiled in by importing its
+// package, usually from a file only containing a blank import of the package in the main package. Register must be
+// called from the init function of the package. The collector is enabled like any other collector, by its name in
+// enabled_collectors, which must be unique.
+//
+// The Config passed to Get contains the Session of the target, of which Exec executes RPCs with the timeouts and
+// retries of the config. Desc, Gauge and Counter create the metrics of the collector like the collectors of
+// junos_exporter.
+func Register(factory Factory) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins = append(plugins, factory)
+}
+
+// Plugins returns the collectors registered using Register.
+func Plugins(logger log.Logger) []Collector {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	var collectors []Collector
+	for _, factory := range plugins {
+		collectors = append(collectors, factory(logger))
+	}
+	return collectors
+}
+
+// Desc returns the description of the metric junos_<subsystem>_<name>.
+func Desc(subsystem string, name string, help string, labels []string) *prometheus.Desc {
+	return colPromDesc(subsystem, name, help, labels)
+}
+
+// Gauge sends a gauge of desc to ch with the value parsed from value, nothing is sent when value is empty or a
+// placeholder such as N/A. Values that cannot be parsed are skipped and counted in junos_parse_errors_total, or fail the
+// collector with strict_parsing.
+func Gauge(logger log.Logger, ch chan<- prometheus.Metric, desc *prometheus.Desc, value string, labels ...string) {
+	newGauge(logger, ch, desc, value, labels...)
+}
+
+// Counter sends a counter of desc to ch with the value parsed from value, as Gauge does.
+func Counter(logger log.Logger, ch chan<- prometheus.Metric, desc *prometheus.Desc, value string, labels ...string) {
+	newCounter(logger, ch, desc, value, labels...)
+}
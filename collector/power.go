@@ -1,7 +1,7 @@
 package collector
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"strings"
 
@@ -13,10 +13,18 @@ import (
 var (
 	powerSubsystem = "power"
 
-	powerLabel           = []string{"module"}
-	powerModuleZoneLabel = []string{"module", "zone"}
-	powerZoneLabels      = []string{"zone"}
-	powerDesc            = map[string]*prometheus.Desc{
+	powerDesc = createPowerDesc(nil)
+	// Descriptions of the metrics of each member of virtual chassis and chassis clusters, labeled with the member.
+	powerMemberDesc = createPowerDesc([]string{"member"})
+)
+
+func createPowerDesc(prefix []string) map[string]*prometheus.Desc {
+	powerLabel := memberLabels(prefix, "module")
+	powerModuleZoneLabel := memberLabels(prefix, "module", "zone")
+	powerZoneLabels := memberLabels(prefix, "zone")
+	powerSysLabels := memberLabels(prefix)
+
+	return map[string]*prometheus.Desc{
 		"State":                 colPromDesc(powerSubsystem, "module_state", "Module Power State (1 = Online, 0 = Offline).", powerLabel),
 		"CapacityActual":        colPromDesc(powerSubsystem, "module_capacity_actual_watts", "Module Actual Capacity in Watts", powerLabel),
 		"CapacityMax":           colPromDesc(powerSubsystem, "module_capacity_maximum_watts", "Module Maximum Capacity in Watts", powerLabel),
@@ -32,14 +40,12 @@ var (
 		"CapacityZoneAllocated": colPromDesc(powerSubsystem, "system_zone_allocated_watts", "System Zone Allocated Capacity in Watts", powerZoneLabels),
 		"CapacityZoneRemaining": colPromDesc(powerSubsystem, "system_zone_remaining_watts", "System Zone Remaining Capacity in Watts", powerZoneLabels),
 		"CapacityZoneUsage":     colPromDesc(powerSubsystem, "system_zone_usage_watts", "System Zone Usage in Watts", powerZoneLabels),
-		"CapacitySysActual":     colPromDesc(powerSubsystem, "system_capacity_actual_watts", "System Actual Capacity in Watts", nil),
-		"CapacitySysMax":        colPromDesc(powerSubsystem, "system_capacity_maximum_watts", "System Maximum Capacity in Watts", nil),
-		"CapacitySysRemaining":  colPromDesc(powerSubsystem, "system_remaining_watts", "System Remaining Capacity in Watts", nil),
+		"CapacitySysActual":     colPromDesc(powerSubsystem, "system_capacity_actual_watts", "System Actual Capacity in Watts", powerSysLabels),
+		"CapacitySysMax":        colPromDesc(powerSubsystem, "system_capacity_maximum_watts", "System Maximum Capacity in Watts", powerSysLabels),
+		"CapacitySysRemaining":  colPromDesc(powerSubsystem, "system_remaining_watts", "System Remaining Capacity in Watts", powerSysLabels),
 		"DCUsage":               colPromDesc(powerSubsystem, "module_dc_usage_watts", "Module DC Usage in Watts.", powerLabel),
 	}
-
-	totalPowerErrors = 0.0
-)
+}
 
 // PowerCollector collects power metrics, implemented as per the Collector interface.
 type PowerCollector struct {
@@ -56,84 +62,88 @@ func (*PowerCollector) Name() string {
 	return powerSubsystem
 }
 
+// RPCs executed by the collector.
+func (*PowerCollector) RPCs() []string {
+	return []string{"get-power-usage-information-detail"}
+}
+
+// UnsupportedPlatforms returns the platforms without power usage information, as per the PlatformLimiter interface.
+func (*PowerCollector) UnsupportedPlatforms() []string {
+	return []string{"ex", "qfx", "srx", "acx"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *PowerCollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *PowerCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
-	if err != nil {
-		totalPowerErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalPowerErrors
-	}
-	defer s.Close()
-
-	reply, err := s.Exec(netconf.RawMethod(`<get-power-usage-information-detail></get-power-usage-information-detail>`))
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-power-usage-information-detail></get-power-usage-information-detail>`))
 	if err != nil {
-		totalPowerErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalPowerErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		return errors
 	}
 
 	if err := processPowerNetconfReply(reply, ch, c.logger); err != nil {
-		totalPowerErrors++
 		errors = append(errors, err)
 	}
-	return errors, totalPowerErrors
+	return errors
 }
 
 func processPowerNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
-	var netconfReply powerRPCReply
-
-	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
-		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
-	}
-	for _, powerData := range netconfReply.PowerUsageInformation.PowerUsageItem {
-		labels := []string{strings.TrimSpace(powerData.Name.Text)}
-
-		powerState := 0.0
-		if powerData.State.Text == "Online" {
-			powerState = 1.0
+	return decodeMemberElements(reply, "power-usage-information", func(member string, info *powerInformation) error {
+		desc, prefix := powerMemberDescs(member)
+		for _, powerData := range info.PowerUsageItem {
+			labels := memberLabels(prefix, strings.TrimSpace(powerData.Name.Text))
+
+			powerState := 0.0
+			if powerData.State.Text == "Online" {
+				powerState = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(desc["State"], prometheus.GaugeValue, powerState, labels...)
+			newGauge(logger, ch, desc["CapacityActual"], powerData.PemCapacityDetail.CapacityActual.Text, labels...)
+			newGauge(logger, ch, desc["CapacityMax"], powerData.PemCapacityDetail.CapacityMax.Text, labels...)
+
+			powerACInputState := 0.0
+			if powerData.AcInputDetail.AcInput.Text == "OK" {
+				powerACInputState = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(desc["ACInputState"], prometheus.GaugeValue, powerACInputState, labels...)
+			newGauge(logger, ch, desc["ACExpectedFeeds"], powerData.AcInputDetail.AcExpectFeed.Text, labels...)
+			newGauge(logger, ch, desc["ACConnectedFeeds"], powerData.AcInputDetail.AcActualFeed.Text, labels...)
+
+			labelsDCOutput := memberLabels(prefix, strings.TrimSpace(powerData.Name.Text), strings.TrimSpace(powerData.DcOutputDetail.Zone.Text))
+			newGauge(logger, ch, desc["DCOutputPower"], powerData.DcOutputDetail.DcPower.Text, labelsDCOutput...)
+			newGauge(logger, ch, desc["DCOutputCurrent"], powerData.DcOutputDetail.DcCurrent.Text, labelsDCOutput...)
+			newGauge(logger, ch, desc["DCOutputVoltage"], powerData.DcOutputDetail.DcVoltage.Text, labelsDCOutput...)
+			newGauge(logger, ch, desc["DCOutputLoad"], powerData.DcOutputDetail.DcLoad.Text, labelsDCOutput...)
 		}
-		ch <- prometheus.MustNewConstMetric(powerDesc["State"], prometheus.GaugeValue, powerState, labels...)
-		newGauge(logger, ch, powerDesc["CapacityActual"], powerData.PemCapacityDetail.CapacityActual.Text, labels...)
-		newGauge(logger, ch, powerDesc["CapacityMax"], powerData.PemCapacityDetail.CapacityMax.Text, labels...)
-
-		powerACInputState := 0.0
-		if powerData.AcInputDetail.AcInput.Text == "OK" {
-			powerACInputState = 1.0
+		for _, powerSystem := range info.PowerUsageSystem {
+			for _, zone := range powerSystem.PowerUsageZoneInformation {
+				labels := memberLabels(prefix, strings.TrimSpace(zone.Zone.Text))
+				newGauge(logger, ch, desc["CapacityZoneActual"], zone.CapacityActual.Text, labels...)
+				newGauge(logger, ch, desc["CapacityZoneMax"], zone.CapacityMax.Text, labels...)
+				newGauge(logger, ch, desc["CapacityZoneAllocated"], zone.CapacityAllocated.Text, labels...)
+				newGauge(logger, ch, desc["CapacityZoneRemaining"], zone.CapacityRemaining.Text, labels...)
+				newGauge(logger, ch, desc["CapacityZoneUsage"], zone.CapacityActualUsage.Text, labels...)
+			}
+			newGauge(logger, ch, desc["CapacitySysActual"], powerSystem.CapacitySysActual.Text, prefix...)
+			newGauge(logger, ch, desc["CapacitySysMax"], powerSystem.CapacitySysMax.Text, prefix...)
+			newGauge(logger, ch, desc["CapacitySysRemaining"], powerSystem.CapacitySysRemaining.Text, prefix...)
 		}
-		ch <- prometheus.MustNewConstMetric(powerDesc["ACInputState"], prometheus.GaugeValue, powerACInputState, labels...)
-		newGauge(logger, ch, powerDesc["ACExpectedFeeds"], powerData.AcInputDetail.AcExpectFeed.Text, labels...)
-		newGauge(logger, ch, powerDesc["ACConnectedFeeds"], powerData.AcInputDetail.AcActualFeed.Text, labels...)
-
-		labelsDCOutput := []string{strings.TrimSpace(powerData.Name.Text), strings.TrimSpace(powerData.DcOutputDetail.Zone.Text)}
-		newGauge(logger, ch, powerDesc["DCOutputPower"], powerData.DcOutputDetail.DcPower.Text, labelsDCOutput...)
-		newGauge(logger, ch, powerDesc["DCOutputCurrent"], powerData.DcOutputDetail.DcCurrent.Text, labelsDCOutput...)
-		newGauge(logger, ch, powerDesc["DCOutputVoltage"], powerData.DcOutputDetail.DcVoltage.Text, labelsDCOutput...)
-		newGauge(logger, ch, powerDesc["DCOutputLoad"], powerData.DcOutputDetail.DcLoad.Text, labelsDCOutput...)
-	}
-	for _, powerSystem := range netconfReply.PowerUsageInformation.PowerUsageSystem {
-		for _, zone := range powerSystem.PowerUsageZoneInformation {
-			labels := []string{strings.TrimSpace(zone.Zone.Text)}
-			newGauge(logger, ch, powerDesc["CapacityZoneActual"], zone.CapacityActual.Text, labels...)
-			newGauge(logger, ch, powerDesc["CapacityZoneMax"], zone.CapacityMax.Text, labels...)
-			newGauge(logger, ch, powerDesc["CapacityZoneAllocated"], zone.CapacityAllocated.Text, labels...)
-			newGauge(logger, ch, powerDesc["CapacityZoneRemaining"], zone.CapacityRemaining.Text, labels...)
-			newGauge(logger, ch, powerDesc["CapacityZoneUsage"], zone.CapacityActualUsage.Text, labels...)
+		for _, fruItem := range info.PowerUsageFruItem {
+			newGauge(logger, ch, desc["DCUsage"], fruItem.DcPower.Text, memberLabels(prefix, fruItem.Name.Text)...)
 		}
-		newGauge(logger, ch, powerDesc["CapacitySysActual"], powerSystem.CapacitySysActual.Text)
-		newGauge(logger, ch, powerDesc["CapacitySysMax"], powerSystem.CapacitySysMax.Text)
-		newGauge(logger, ch, powerDesc["CapacitySysRemaining"], powerSystem.CapacitySysRemaining.Text)
-	}
-	for _, fruItem := range netconfReply.PowerUsageInformation.PowerUsageFruItem {
-		newGauge(logger, ch, powerDesc["DCUsage"], fruItem.DcPower.Text, fruItem.Name.Text)
-	}
-	return nil
+		return nil
+	})
 }
 
-type powerRPCReply struct {
-	PowerUsageInformation powerInformation `xml:"power-usage-information"`
+// powerMemberDescs returns the descriptions of the metrics of member, along with the member label, or powerDesc
+// without labels when the device has no members.
+func powerMemberDescs(member string) (map[string]*prometheus.Desc, []string) {
+	if member == "" {
+		return powerDesc, nil
+	}
+	return powerMemberDesc, []string{member}
 }
+
 type powerInformation struct {
 	PowerUsageItem    []powerItem    `xml:"power-usage-item"`
 	PowerUsageSystem  []powerSystem  `xml:"power-usage-system"`
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	powerBudgetSubsystem = "power_budget"
+
+	powerBudgetLabels    = []string{"member"}
+	powerBudgetPSULabels = []string{"member", "psu"}
+	powerBudgetDesc      = map[string]*prometheus.Desc{
+		"PSUCapacity":  colPromDesc(powerBudgetSubsystem, "psu_capacity_watts", "Power the PSU supplies in Watts.", powerBudgetPSULabels),
+		"PSUOnline":    colPromDesc(powerBudgetSubsystem, "psu_online", "Whether the PSU is online (1 = online, 0 = not online).", powerBudgetPSULabels),
+		"Supplied":     colPromDesc(powerBudgetSubsystem, "supplied_watts", "Power supplied by all online PSUs in Watts.", powerBudgetLabels),
+		"Reserved":     colPromDesc(powerBudgetSubsystem, "reserved_watts", "Base power reserved in Watts.", powerBudgetLabels),
+		"Consumed":     colPromDesc(powerBudgetSubsystem, "consumed_watts", "Non-PoE power being consumed in Watts.", powerBudgetLabels),
+		"PoEAllocated": colPromDesc(powerBudgetSubsystem, "poe_allocated_watts", "PoE power allocated in Watts.", powerBudgetLabels),
+		"PoEConsumed":  colPromDesc(powerBudgetSubsystem, "poe_consumed_watts", "PoE power being consumed in Watts.", powerBudgetLabels),
+		"Available":    colPromDesc(powerBudgetSubsystem, "available_watts", "Power available in Watts.", powerBudgetLabels),
+	}
+
+	// Metrics (value) of the lines of show chassis power-budget-statistics (key), compared ignoring case.
+	powerBudgetLines = map[string]string{
+		"total power supplied by all online psus": "Supplied",
+		"base power reserved":                     "Reserved",
+		"non-poe power being consumed":            "Consumed",
+		"total poe power allocated":               "PoEAllocated",
+		"total poe power consumed":                "PoEConsumed",
+		"total power available":                   "Available",
+	}
+
+	powerBudgetMemberRegexp = regexp.MustCompile(`(?i)^fpc\s+(\d+):`)
+	powerBudgetPSURegexp    = regexp.MustCompile(`(?i)^psu\s+(\d+)`)
+)
+
+// PowerBudgetCollector collects the power budget of each member of EX and QFX switches, which do not support the
+// power usage RPC of the power collector, implemented as per the Collector interface.
+type PowerBudgetCollector struct {
+	logger log.Logger
+}
+
+// NewPowerBudgetCollector returns a new PowerBudgetCollector.
+func NewPowerBudgetCollector(logger log.Logger) *PowerBudgetCollector {
+	return &PowerBudgetCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*PowerBudgetCollector) Name() string {
+	return powerBudgetSubsystem
+}
+
+// RPCs executed by the collector.
+func (*PowerBudgetCollector) RPCs() []string {
+	return []string{"command"}
+}
+
+// UnsupportedPlatforms returns the platforms without power budget statistics, as per the PlatformLimiter interface.
+func (*PowerBudgetCollector) UnsupportedPlatforms() []string {
+	return []string{"srx", "mx", "ptx", "acx"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *PowerBudgetCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	// show chassis power-budget-statistics, of which the text output is parsed
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<command format="text">show chassis power-budget-statistics</command>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processPowerBudgetNetconfReply(reply, ch, c.logger); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+// processPowerBudgetNetconfReply sends the power budget of the output of show chassis power-budget-statistics, which
+// lists the budget of each member of a virtual chassis after an "fpc <member>:" line, such as:
+//
+//	fpc 0:
+//	-------------------------------------------
+//	PSU 0 (JPSU-350-AC-AFO)    :   350 W Online
+//	PSU 1                      :     0 W Absent
+//	Total Power supplied by all Online PSUs :   350 W
+//	Base power reserved                     :   175 W
+//	Non-PoE power being consumed            :    74 W
+//	Total PoE power allocated               :     0 W
+//	Total PoE power consumed                :     0 W
+//	Total power available                   :   276 W
+//
+// The member is named fpc<member> as in the replies of the other chassis RPCs, and empty for switches listing a single
+// budget without an fpc line.
+func processPowerBudgetNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	return decodeElements(reply, "output", func(output *string) error {
+		member := ""
+		for _, line := range strings.Split(*output, "\n") {
+			line = strings.TrimSpace(line)
+			if match := powerBudgetMemberRegexp.FindStringSubmatch(line); match != nil {
+				member = "fpc" + match[1]
+				continue
+			}
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			key = strings.TrimSpace(key)
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				continue
+			}
+			watts := strings.TrimSuffix(fields[0], "W")
+			if match := powerBudgetPSURegexp.FindStringSubmatch(key); match != nil {
+				labels := []string{member, match[1]}
+				newGauge(logger, ch, powerBudgetDesc["PSUCapacity"], watts, labels...)
+				online := 0.0
+				if strings.EqualFold(fields[len(fields)-1], "online") {
+					online = 1
+				}
+				ch <- prometheus.MustNewConstMetric(powerBudgetDesc["PSUOnline"], prometheus.GaugeValue, online, labels...)
+				continue
+			}
+			if metric, ok := powerBudgetLines[strings.ToLower(strings.Join(strings.Fields(key), " "))]; ok {
+				newGauge(logger, ch, powerBudgetDesc[metric], watts, member)
+			}
+		}
+		return nil
+	})
+}
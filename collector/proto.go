@@ -0,0 +1,39 @@
+package collector
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// rangeProtoFields calls fn for each field of a protobuf message. Only the value of length delimited fields and
+// the varint or fixed value of the remaining field types are passed.
+func rangeProtoFields(b []byte, fn func(num protowire.Number, typ protowire.Type, data []byte, v uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var data []byte
+		var v uint64
+		switch typ {
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, typ, data, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queuesSubsystem = "system_queue"
+
+	queueLabels = []string{"type", "queue"}
+	queueDesc   = map[string]*prometheus.Desc{
+		"Drops":      colPromDesc(queuesSubsystem, "drops", "Number of packets dropped by the kernel queue.", queueLabels),
+		"Packets":    colPromDesc(queuesSubsystem, "packets", "Number of packets in the kernel queue.", queueLabels),
+		"Bytes":      colPromDesc(queuesSubsystem, "bytes", "Number of bytes in the kernel queue.", queueLabels),
+		"MaxPackets": colPromDesc(queuesSubsystem, "max_packets", "Maximum number of packets allowed in the kernel queue.", queueLabels),
+		"MaxBytes":   colPromDesc(queuesSubsystem, "max_bytes", "Maximum number of bytes allowed in the kernel queue.", queueLabels),
+	}
+)
+
+// QueuesCollector collects the kernel queue metrics of the route engine, implemented as per the Collector interface.
+type QueuesCollector struct {
+	logger log.Logger
+}
+
+// NewQueuesCollector returns a new QueuesCollector.
+func NewQueuesCollector(logger log.Logger) *QueuesCollector {
+	return &QueuesCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*QueuesCollector) Name() string {
+	return "system_queues"
+}
+
+// RPCs executed by the collector.
+func (*QueuesCollector) RPCs() []string {
+	return []string{"get-system-queues-information"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *QueuesCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	// show system queues | display xml
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-system-queues-information/>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+		return errors
+	}
+
+	if err := processQueuesNetconfReply(reply, ch, c.logger); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+func processQueuesNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	var netconfReply queuesRPCReply
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf system queues reply: %s", err)
+	}
+	for _, queue := range netconfReply.QueuesStatistics.InterfaceQueues.Queue {
+		sendQueueMetrics(ch, queue, "interface", logger)
+	}
+	for _, queue := range netconfReply.QueuesStatistics.ProtocolQueues.Queue {
+		sendQueueMetrics(ch, queue, "protocol", logger)
+	}
+	return nil
+}
+
+func sendQueueMetrics(ch chan<- prometheus.Metric, queue systemQueue, queueType string, logger log.Logger) {
+	labels := []string{queueType, strings.TrimSpace(queue.Name)}
+	newCounter(logger, ch, queueDesc["Drops"], queue.Drops, labels...)
+	newGauge(logger, ch, queueDesc["Packets"], queue.Packets, labels...)
+	newGauge(logger, ch, queueDesc["Bytes"], queue.Octets, labels...)
+	newGauge(logger, ch, queueDesc["MaxPackets"], queue.MaxPackets, labels...)
+	newGauge(logger, ch, queueDesc["MaxBytes"], queue.MaxOctets, labels...)
+}
+
+type queuesRPCReply struct {
+	XMLName          xml.Name         `xml:"rpc-reply"`
+	QueuesStatistics queuesStatistics `xml:"queues-statistics"`
+}
+
+type queuesStatistics struct {
+	InterfaceQueues interfaceQueues `xml:"interface-queues-statistics"`
+	ProtocolQueues  protocolQueues  `xml:"protocol-queues-statistics"`
+}
+
+type interfaceQueues struct {
+	Queue []systemQueue `xml:"interface-queue"`
+}
+
+type protocolQueues struct {
+	Queue []systemQueue `xml:"protocol-queue"`
+}
+
+type systemQueue struct {
+	Name       string `xml:"name"`
+	MaxOctets  string `xml:"max-octets-allowed"`
+	MaxPackets string `xml:"max-packets-allowed"`
+	Drops      string `xml:"number-of-queue-drops"`
+	Octets     string `xml:"octets-in-queue"`
+	Packets    string `xml:"packets-in-queue"`
+}
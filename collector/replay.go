@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// replayTransport implements netconfTransport by replying to RPCs with the XML files of a directory instead of
+// connecting to a device, for developing collectors against the output of `| display xml` captured from devices.
+//
+// The reply to an RPC is read from <rpc>.xml in the subdirectory named after the target, or otherwise in dir itself,
+// where <rpc> is the name of the RPC, such as get-interface-information. The values of the arguments of the RPC are
+// appended to the name, separated by underscores, so that the reply of
+// <get-bgp-summary-information><instance>VRF1</instance></get-bgp-summary-information> is read from
+// get-bgp-summary-information_VRF1.xml, falling back to get-bgp-summary-information.xml.
+type replayTransport struct {
+	dir    string
+	target string
+	reply  []byte
+}
+
+func newReplayTransport(dir string, target string) *replayTransport {
+	return &replayTransport{dir: dir, target: target}
+}
+
+// Send reads the reply to the RPC in data.
+func (t *replayTransport) Send(data []byte) error {
+	var rpc struct {
+		Method struct {
+			XMLName xml.Name
+			Args    []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	}
+	if err := xml.Unmarshal(data, &rpc); err != nil {
+		return fmt.Errorf("could not parse rpc: %s", err)
+	}
+
+	method := rpc.Method.XMLName.Local
+	names := []string{method}
+	name := method
+	for _, arg := range rpc.Method.Args {
+		if value := strings.TrimSpace(arg.Value); value != "" {
+			name += "_" + value
+		}
+	}
+	if name != method {
+		names = []string{name, method}
+	}
+
+	for _, name := range names {
+		for _, path := range []string{filepath.Join(t.dir, t.target, name+".xml"), filepath.Join(t.dir, name+".xml")} {
+			buf, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			t.reply = replayReply(buf)
+			return nil
+		}
+	}
+	t.reply = []byte(fmt.Sprintf(`<rpc-reply><rpc-error><error-severity>error</error-severity><error-message>no reply file for %s in %s</error-message></rpc-error></rpc-reply>`, name, t.dir))
+	return nil
+}
+
+// replayReply returns the rpc-reply of a reply file, dropping any CLI prompt, command, XML declaration or comments
+// before the XML and wrapping the XML in an rpc-reply element when the file does not contain one.
+func replayReply(buf []byte) []byte {
+	s := string(buf)
+	if i := strings.Index(s, "<"); i > 0 {
+		s = s[i:]
+	}
+	for {
+		end := ""
+		if strings.HasPrefix(s, "<?") {
+			end = "?>"
+		} else if strings.HasPrefix(s, "<!--") {
+			end = "-->"
+		}
+		i := strings.Index(s, end)
+		if end == "" || i < 0 {
+			break
+		}
+		s = strings.TrimSpace(s[i+len(end):])
+	}
+	if !strings.HasPrefix(s, "<rpc-reply") {
+		s = "<rpc-reply>" + s + "</rpc-reply>"
+	}
+	return []byte(s)
+}
+
+// Receive returns the reply to the last RPC.
+func (t *replayTransport) Receive() ([]byte, error) {
+	if t.reply == nil {
+		return nil, errors.New("no rpc sent")
+	}
+	reply := t.reply
+	t.reply = nil
+	return reply, nil
+}
+
+// ReceiveHello returns a hello message with the NETCONF 1.0 base capability.
+func (t *replayTransport) ReceiveHello() (*netconf.HelloMessage, error) {
+	return &netconf.HelloMessage{Capabilities: []string{"urn:ietf:params:netconf:base:1.0"}}, nil
+}
+
+func (t *replayTransport) SendHello(*netconf.HelloMessage) error {
+	return nil
+}
+
+func (t *replayTransport) SetVersion(version string) {}
+
+func (t *replayTransport) Close() error {
+	return nil
+}
+
+func (t *replayTransport) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (t *replayTransport) keepalive() error {
+	return nil
+}
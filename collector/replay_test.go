@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+// replayExporter returns an Exporter running collectors against the replies of testdata/replay/<dir>, which holds the
+// `| display xml` output of each RPC as described by replayTransport.
+func replayExporter(t *testing.T, dir string, conf Config, collectors ...Collector) *Exporter {
+	t.Helper()
+	connections := NewConnectionManager(0, 0, 0, 0, 0, 0, log.NewNopLogger())
+	t.Cleanup(connections.Close)
+
+	conf.ReplayDir = filepath.Join("testdata", "replay", dir)
+	conf.Connections = connections
+	if conf.SSHTarget == "" {
+		conf.SSHTarget = "router1"
+	}
+	exporter, err := NewExporter(context.Background(), collectors, conf, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exporter
+}
+
+func TestReplayReply(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "rpc-reply",
+			file: "<rpc-reply><uptime/></rpc-reply>",
+			want: "<rpc-reply><uptime/></rpc-reply>",
+		},
+		{
+			name: "cli capture",
+			file: "user@router> show system uptime | display xml\n<rpc-reply><uptime/></rpc-reply>\n\n{master:0}\n",
+			want: "<rpc-reply><uptime/></rpc-reply>\n\n{master:0}\n",
+		},
+		{
+			name: "declaration and comment",
+			file: "<?xml version=\"1.0\"?>\n<!-- captured on r1 -->\n<uptime/>",
+			want: "<rpc-reply><uptime/></rpc-reply>",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(replayReply([]byte(tc.file))); got != tc.want {
+				t.Errorf("replayReply() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	routeSubsystem = "route"
+
+	routeTableLabels    = []string{"table"}
+	routeProtocolLabels = append(routeTableLabels, "protocol")
+	routeDesc           = map[string]*prometheus.Desc{
+		"Destinations":         colPromDesc(routeSubsystem, "table_destinations", "Number of destinations in the routing table.", routeTableLabels),
+		"Routes":               colPromDesc(routeSubsystem, "table_routes", "Number of routes in the routing table.", routeTableLabels),
+		"ActiveRoutes":         colPromDesc(routeSubsystem, "table_active_routes", "Number of active routes in the routing table.", routeTableLabels),
+		"HolddownRoutes":       colPromDesc(routeSubsystem, "table_holddown_routes", "Number of routes in hold-down in the routing table.", routeTableLabels),
+		"HiddenRoutes":         colPromDesc(routeSubsystem, "table_hidden_routes", "Number of hidden routes in the routing table.", routeTableLabels),
+		"ProtocolRoutes":       colPromDesc(routeSubsystem, "protocol_routes", "Number of routes of the protocol in the routing table.", routeProtocolLabels),
+		"ProtocolActiveRoutes": colPromDesc(routeSubsystem, "protocol_active_routes", "Number of active routes of the protocol in the routing table.", routeProtocolLabels),
+		"FilterRoutes":         colPromDesc(routeSubsystem, "filter_routes", "Number of routes matching the route filter.", []string{"filter"}),
+		"FilterActiveRoutes":   colPromDesc(routeSubsystem, "filter_active_routes", "Number of active routes matching the route filter.", []string{"filter"}),
+	}
+)
+
+// RouteFilter selects the routes counted by the route collector.
+type RouteFilter struct {
+	// Name of the filter, exported as the filter label.
+	Name string
+	// Routing table of the routes, such as VRF1.inet.0, all tables when empty.
+	Table string
+	// Destination prefix of the routes, such as 0.0.0.0/0, including more specific routes unless Exact is set.
+	Prefix string
+	Exact  bool
+	// Protocol of the routes, such as bgp or static, all protocols when empty.
+	Protocol string
+}
+
+// RouteCollector collects routing table metrics, implemented as per the Collector interface.
+type RouteCollector struct {
+	logger log.Logger
+}
+
+// NewRouteCollector returns a new RouteCollector.
+func NewRouteCollector(logger log.Logger) *RouteCollector {
+	return &RouteCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*RouteCollector) Name() string {
+	return routeSubsystem
+}
+
+// RPCs executed by the collector.
+func (*RouteCollector) RPCs() []string {
+	return []string{"get-route-summary-information"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *RouteCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	// show route summary | display xml
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-route-summary-information/>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processRouteSummaryNetconfReply(reply, ch, c.logger); err != nil {
+		errors = append(errors, err)
+	}
+
+	for _, filter := range conf.RouteFilters {
+		// show route <prefix> [exact] [table <table>] [protocol <protocol>]
+		replyFilter, err := conf.Session.Exec(ctx, netconf.RawMethod(routeFilterRPC(filter)))
+		if err != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call for route filter %q: %w", filter.Name, err))
+			continue
+		}
+		if err := processRouteFilterNetconfReply(replyFilter, filter, ch); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
+// routeFilterRPC returns the get-route-information RPC of the routes matching filter.
+func routeFilterRPC(filter RouteFilter) string {
+	var rpc strings.Builder
+	rpc.WriteString("<get-route-information>")
+	for _, arg := range []struct{ name, value string }{{"destination", filter.Prefix}, {"table", filter.Table}, {"protocol", filter.Protocol}} {
+		if arg.value == "" {
+			continue
+		}
+		fmt.Fprintf(&rpc, "<%s>", arg.name)
+		xml.EscapeText(&rpc, []byte(arg.value))
+		fmt.Fprintf(&rpc, "</%s>", arg.name)
+	}
+	if filter.Exact {
+		rpc.WriteString("<exact/>")
+	}
+	rpc.WriteString("</get-route-information>")
+	return rpc.String()
+}
+
+func processRouteFilterNetconfReply(reply *netconf.RPCReply, filter RouteFilter, ch chan<- prometheus.Metric) error {
+	routes, active := 0.0, 0.0
+	err := decodeElements(reply, "rt-entry", func(entry *routeFilterEntry) error {
+		routes++
+		if strings.TrimSpace(entry.ActiveTag) == "*" {
+			active++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s of route filter %q", err, filter.Name)
+	}
+	ch <- prometheus.MustNewConstMetric(routeDesc["FilterRoutes"], prometheus.GaugeValue, routes, filter.Name)
+	ch <- prometheus.MustNewConstMetric(routeDesc["FilterActiveRoutes"], prometheus.GaugeValue, active, filter.Name)
+	return nil
+}
+
+func processRouteSummaryNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	var netconfReply routeSummaryRPCReply
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf route summary reply: %s", err)
+	}
+	for _, table := range netconfReply.RouteSummaryInformation.RouteTable {
+		labels := []string{strings.TrimSpace(table.TableName)}
+		newGauge(logger, ch, routeDesc["Destinations"], table.DestinationCount, labels...)
+		newGauge(logger, ch, routeDesc["Routes"], table.TotalRouteCount, labels...)
+		newGauge(logger, ch, routeDesc["ActiveRoutes"], table.ActiveRouteCount, labels...)
+		newGauge(logger, ch, routeDesc["HolddownRoutes"], table.HolddownRouteCount, labels...)
+		newGauge(logger, ch, routeDesc["HiddenRoutes"], table.HiddenRouteCount, labels...)
+		for _, protocol := range table.Protocols {
+			protocolLabels := append(labels, strings.ToLower(strings.TrimSpace(protocol.ProtocolName)))
+			newGauge(logger, ch, routeDesc["ProtocolRoutes"], protocol.ProtocolRouteCount, protocolLabels...)
+			newGauge(logger, ch, routeDesc["ProtocolActiveRoutes"], protocol.ActiveRouteCount, protocolLabels...)
+		}
+	}
+	return nil
+}
+
+type routeSummaryRPCReply struct {
+	XMLName                 xml.Name                `xml:"rpc-reply"`
+	RouteSummaryInformation routeSummaryInformation `xml:"route-summary-information"`
+}
+
+type routeSummaryInformation struct {
+	RouteTable []routeSummaryTable `xml:"route-table"`
+}
+
+type routeSummaryTable struct {
+	TableName          string                 `xml:"table-name"`
+	DestinationCount   string                 `xml:"destination-count"`
+	TotalRouteCount    string                 `xml:"total-route-count"`
+	ActiveRouteCount   string                 `xml:"active-route-count"`
+	HolddownRouteCount string                 `xml:"holddown-route-count"`
+	HiddenRouteCount   string                 `xml:"hidden-route-count"`
+	Protocols          []routeSummaryProtocol `xml:"protocols"`
+}
+
+type routeFilterEntry struct {
+	ActiveTag string `xml:"active-tag"`
+}
+
+type routeSummaryProtocol struct {
+	ProtocolName       string `xml:"protocol-name"`
+	ProtocolRouteCount string `xml:"protocol-route-count"`
+	ActiveRouteCount   string `xml:"active-route-count"`
+}
@@ -1,8 +1,11 @@
 package collector
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/Juniper/go-netconf/netconf"
@@ -13,7 +16,20 @@ import (
 var (
 	reSubsystem = "route_engine"
 
-	totalREErrors = 0.0
+	// Number of processes in the summary of show system processes, such as "152 processes: 2 running, 150 sleeping".
+	reProcessesRegexp = regexp.MustCompile(`(\d+) processes:`)
+
+	// Disks listed by smartctl --scan, such as "/dev/ada0 -d atacam # /dev/ada0, ATA device", of which the device and
+	// its arguments are passed back to smartctl. Only plain device names and arguments are accepted as they are run in
+	// the shell.
+	reDiskScanRegexp = regexp.MustCompile(`^(/dev/[\w./-]+)((?:\s+-d\s+[\w,.-]+)?)\s*(?:#.*)?$`)
+	// Overall health of a disk reported by smartctl -H, PASSED for ATA and NVMe disks and OK for SCSI disks.
+	reDiskHealthRegexp = regexp.MustCompile(`(?m)^(?:SMART overall-health self-assessment test result|SMART Health Status):\s*(\S+)`)
+	// Row of the ATA SMART attributes table of smartctl -A, of which the raw value starts with a number, such as
+	// "194 Temperature_Celsius 0x0022 070 060 000 Old_age Always - 30 (Min/Max 18/40)".
+	reDiskAttributeRegexp = regexp.MustCompile(`(?m)^\s*(\d+)\s+(\S+)\s+0x[0-9a-fA-F]+\s+\d+\s+\d+\s+\S+\s+\S+\s+\S+\s+\S+\s+(\d+)`)
+	// Temperature of NVMe and SCSI disks, such as "Temperature: 35 Celsius" or "Current Drive Temperature: 30 C".
+	reDiskTemperatureRegexp = regexp.MustCompile(`(?m)^(?:Temperature|Current Drive Temperature):\s+(\d+) C`)
 )
 
 func createREDesc(reLabels []string) map[string]*prometheus.Desc {
@@ -26,6 +42,7 @@ func createREDesc(reLabels []string) map[string]*prometheus.Desc {
 		"memTotal":      colPromDesc(reSubsystem, "memory_total_bytes", "Total route engine memory in bytes.", reLabels),
 		"memUsed":       colPromDesc(reSubsystem, "memory_used_bytes", "Used route engine memory in bytes.", reLabels),
 		"memBuf":        colPromDesc(reSubsystem, "memory_buffer_utilization_percent", "Memory buffer utilization as a percent.", reLabels),
+		"memUtil":       colPromDesc(reSubsystem, "memory_utilization_percent", "Memory utilization as a percent.", reLabels),
 		"memDRAM":       colPromDesc(reSubsystem, "memory_dram_size_bytes", "Memory DRAM size in bytes.", reLabels),
 		"memInstalled":  colPromDesc(reSubsystem, "memory_installed_size_bytes", "Memory installed size in bytes.", reLabels),
 		"cpuUser":       colPromDesc(reSubsystem, "cpu_user_percent", "User CPU utilization as a percent.", reCPULabels),
@@ -37,6 +54,7 @@ func createREDesc(reLabels []string) map[string]*prometheus.Desc {
 		"uptime":        colPromDesc(reSubsystem, "uptime_seconds", "Uptime in seconds.", reLabels),
 		"masterState":   colPromDesc(reSubsystem, "mastership_state", "Mastership state (1 = Master, 0 = Backup).", reLabels),
 		"masterPrio":    colPromDesc(reSubsystem, "mastership_priority", "Mastership priority (1 = Master, 0 = Backup).", reLabels),
+		"info":          colPromDesc(reSubsystem, "info", "Route engine information, always 1.", append(reLabels, "model")),
 	}
 }
 func getREDesc() (map[string]*prometheus.Desc, map[string]*prometheus.Desc) {
@@ -48,6 +66,40 @@ func getREDesc() (map[string]*prometheus.Desc, map[string]*prometheus.Desc) {
 
 }
 
+func getREProcessesDesc() (*prometheus.Desc, *prometheus.Desc) {
+	help := "Number of processes running on the route engine."
+	return colPromDesc(reSubsystem, "processes", help, nil), colPromDesc(reSubsystem, "processes", help, []string{"name"})
+}
+
+func getRENetworkServicesDesc() (*prometheus.Desc, *prometheus.Desc) {
+	help := "Network services mode of the chassis as the mode label, always 1."
+	return colPromDesc(reSubsystem, "network_services_info", help, []string{"mode"}), colPromDesc(reSubsystem, "network_services_info", help, []string{"mode", "name"})
+}
+
+func createREStorageDesc(storageLabels []string) map[string]*prometheus.Desc {
+	return map[string]*prometheus.Desc{
+		"storageTotal":     colPromDesc(reSubsystem, "storage_total_bytes", "Total size of the filesystem in bytes.", storageLabels),
+		"storageUsed":      colPromDesc(reSubsystem, "storage_used_bytes", "Used space on the filesystem in bytes.", storageLabels),
+		"storageAvailable": colPromDesc(reSubsystem, "storage_available_bytes", "Available space on the filesystem in bytes.", storageLabels),
+		"storageUsedPct":   colPromDesc(reSubsystem, "storage_used_percent", "Used space on the filesystem as a percent.", storageLabels),
+	}
+}
+
+func getREDiskDesc() map[string]*prometheus.Desc {
+	labels := []string{"slot", "disk"}
+	return map[string]*prometheus.Desc{
+		"diskHealth":    colPromDesc(reSubsystem, "disk_health", "Overall SMART health of the disk (1 = Passed, 0 = Failed).", labels),
+		"diskTemp":      colPromDesc(reSubsystem, "disk_temperature_celsius", "Disk temperature in degrees celsius.", labels),
+		"diskAttribute": colPromDesc(reSubsystem, "disk_smart_attribute", "Raw value of the SMART attribute of the disk.", append(labels, "id", "attribute")),
+	}
+}
+
+func getREStorageDesc() (map[string]*prometheus.Desc, map[string]*prometheus.Desc) {
+	labels := []string{"filesystem", "mount"}
+	multiRELabels := append(labels, "name")
+	return createREStorageDesc(labels), createREStorageDesc(multiRELabels)
+}
+
 // RECollector collects route engine metrics, implemented as per the Collector interface.
 type RECollector struct {
 	logger log.Logger
@@ -63,29 +115,57 @@ func (*RECollector) Name() string {
 	return reSubsystem
 }
 
+// RPCs executed by the collector.
+func (*RECollector) RPCs() []string {
+	return []string{"get-route-engine-information", "get-system-storage", "get-system-process-information", "command", "request-shell-execute"}
+}
+
 // Get metrics and send to the Prometheus.Metric channel.
-func (c *RECollector) Get(ch chan<- prometheus.Metric, conf Config) ([]error, float64) {
+func (c *RECollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
 	errors := []error{}
-	s, err := netconf.DialSSH(conf.SSHTarget, conf.SSHClientConfig)
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-route-engine-information/>`))
 	if err != nil {
-		totalREErrors++
-		errors = append(errors, fmt.Errorf("could not connect to %q: %s", conf.SSHTarget, err))
-		return errors, totalREErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processRENetconfReply(reply, ch, c.logger); err != nil {
+		errors = append(errors, err)
 	}
-	defer s.Close()
 
-	reply, err := s.Exec(netconf.RawMethod(`<get-route-engine-information/>`))
+	if conf.REDiskHealth {
+		errors = append(errors, getREDiskHealth(ctx, ch, conf, reply)...)
+	}
+
+	// show system storage
+	replyStorage, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-system-storage/>`))
 	if err != nil {
-		totalREErrors++
-		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %s", err))
-		return errors, totalREErrors
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processREStorageNetconfReply(replyStorage, ch, c.logger); err != nil {
+		errors = append(errors, err)
 	}
 
-	if err := processRENetconfReply(reply, ch, c.logger); err != nil {
-		totalREErrors++
+	// show system processes summary
+	replyProcesses, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-system-process-information><summary/></get-system-process-information>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processREProcessesNetconfReply(replyProcesses, ch); err != nil {
 		errors = append(errors, err)
 	}
-	return errors, totalREErrors
+
+	// show system processes
+	replyDaemons, err := conf.Session.Exec(ctx, netconf.RawMethod(`<get-system-process-information/>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processREDaemonsNetconfReply(replyDaemons, ch, conf.SSHTarget); err != nil {
+		errors = append(errors, err)
+	}
+
+	// show chassis network-services, which has no RPC
+	replyServices, err := conf.Session.Exec(ctx, netconf.RawMethod(`<command format="xml">show chassis network-services</command>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processRENetworkServicesNetconfReply(replyServices, ch); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
 }
 
 func processRENetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
@@ -134,11 +214,18 @@ func sendREMetrics(ch chan<- prometheus.Metric, reDesc map[string]*prometheus.De
 	newGauge(logger, ch, reDesc["cpuTemp"], reData.CPUTemperature.Temp, labels...)
 	newGauge(logger, ch, reDesc["uptime"], reData.UpTime.Seconds, labels...)
 
-	newGaugeMB(logger, ch, reDesc["memTotal"], reData.MemorySystemTotal.Text, labels...)
-	newGaugeMB(logger, ch, reDesc["memUsed"], reData.MemorySystemTotalUsed.Text, labels...)
+	newGaugeSize(logger, ch, reDesc["memTotal"], reData.MemorySystemTotal.Text, megabyte, labels...)
+	newGaugeSize(logger, ch, reDesc["memUsed"], reData.MemorySystemTotalUsed.Text, megabyte, labels...)
 	newGauge(logger, ch, reDesc["memBuf"], reData.MemoryBufferUtilization.Text, labels...)
-	newGaugeMB(logger, ch, reDesc["memDRAM"], reData.MemoryDRAMSize.Text, labels...)
-	newGaugeMB(logger, ch, reDesc["memInstalled"], reData.MemoryInstalledSize.Text, labels...)
+	// Route engines reporting the total memory usage report the utilization of the total memory, others only report
+	// the buffer utilization, shown as the memory utilization by show chassis routing-engine.
+	memUtil := reData.MemorySystemTotalUtil.Text
+	if memUtil == "" {
+		memUtil = reData.MemoryBufferUtilization.Text
+	}
+	newGauge(logger, ch, reDesc["memUtil"], memUtil, labels...)
+	newGaugeSize(logger, ch, reDesc["memDRAM"], reData.MemoryDRAMSize.Text, megabyte, labels...)
+	newGaugeSize(logger, ch, reDesc["memInstalled"], reData.MemoryInstalledSize.Text, megabyte, labels...)
 
 	label5s := append(labels, "5s")
 	newGauge(logger, ch, reDesc["cpuUser"], reData.CPUUser.Text, label5s...)
@@ -183,6 +270,222 @@ func sendREMetrics(ch chan<- prometheus.Metric, reDesc map[string]*prometheus.De
 	}
 	ch <- prometheus.MustNewConstMetric(reDesc["masterPrio"], prometheus.GaugeValue, mPri, labels...)
 
+	if model := strings.TrimSpace(reData.Model.Text); model != "" {
+		ch <- prometheus.MustNewConstMetric(reDesc["info"], prometheus.GaugeValue, 1, append(labels, model)...)
+	}
+}
+
+func processREProcessesNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
+	var netconfReply reProcessesRPCReply
+	processesDesc, multiREProcessesDesc := getREProcessesDesc()
+
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+
+	// Metrics for multiple route engine instances (i.e. clusters)
+	if len(netconfReply.MultiREResults.MultiREItem) != 0 {
+		for _, re := range netconfReply.MultiREResults.MultiREItem {
+			sendREProcessesMetric(ch, multiREProcessesDesc, re.ProcessInformation.Output, re.REName)
+		}
+		return nil
+	}
+
+	sendREProcessesMetric(ch, processesDesc, netconfReply.ProcessInformation.Output)
+	return nil
+}
+
+func processREDaemonsNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, target string) error {
+	var netconfReply reProcessesRPCReply
+	daemonDesc, multiREDaemonDesc := getREDaemonDesc()
+
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+
+	// Metrics for multiple route engine instances (i.e. clusters)
+	if len(netconfReply.MultiREResults.MultiREItem) != 0 {
+		for _, re := range netconfReply.MultiREResults.MultiREItem {
+			sendREDaemonMetrics(ch, multiREDaemonDesc, target, re.REName, re.ProcessInformation.Output, re.REName)
+		}
+		return nil
+	}
+
+	sendREDaemonMetrics(ch, daemonDesc, target, "", netconfReply.ProcessInformation.Output)
+	return nil
+}
+
+func sendREProcessesMetric(ch chan<- prometheus.Metric, desc *prometheus.Desc, output string, labels ...string) {
+	match := reProcessesRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return
+	}
+	processes, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, processes, labels...)
+}
+
+func processREStorageNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, logger log.Logger) error {
+	var netconfReply reStorageRPCReply
+	storageDesc, multiREStorageDesc := getREStorageDesc()
+
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+
+	// Metrics for multiple route engine instances (i.e. clusters)
+	if len(netconfReply.MultiREResults.MultiREItem) != 0 {
+		for _, re := range netconfReply.MultiREResults.MultiREItem {
+			for _, fs := range re.StorageInformation.Filesystem {
+				labels := []string{strings.TrimSpace(fs.Name), strings.TrimSpace(fs.MountedOn), re.REName}
+				sendREStorageMetrics(ch, multiREStorageDesc, labels, fs, logger)
+			}
+		}
+		return nil
+	}
+
+	// Metrics for single route engine instances
+	for _, fs := range netconfReply.StorageInformation.Filesystem {
+		labels := []string{strings.TrimSpace(fs.Name), strings.TrimSpace(fs.MountedOn)}
+		sendREStorageMetrics(ch, storageDesc, labels, fs, logger)
+	}
+	return nil
+}
+
+func sendREStorageMetrics(ch chan<- prometheus.Metric, storageDesc map[string]*prometheus.Desc, labels []string, fs reFilesystem, logger log.Logger) {
+	// Block counts are reported in 512 byte blocks.
+	newGaugeBlocks(logger, ch, storageDesc["storageTotal"], fs.TotalBlocks, labels...)
+	newGaugeBlocks(logger, ch, storageDesc["storageUsed"], fs.UsedBlocks, labels...)
+	newGaugeBlocks(logger, ch, storageDesc["storageAvailable"], fs.AvailableBlocks, labels...)
+	newGauge(logger, ch, storageDesc["storageUsedPct"], fs.UsedPercent, labels...)
+}
+
+// getREDiskHealth exports the SMART health of the disks of the route engine the session is connected to, using
+// smartctl in the shell as Junos has no RPC for it. The route engine is labeled with the slot of the master route
+// engine found in reReply, nil when it could not be retrieved.
+func getREDiskHealth(ctx context.Context, ch chan<- prometheus.Metric, conf Config, reReply *netconf.RPCReply) []error {
+	slot := "singleRE"
+	if reReply != nil {
+		slot = reMasterSlot(reReply)
+	}
+
+	reply, err := conf.Session.Exec(ctx, shellCommandMethod("smartctl --scan"))
+	if err != nil {
+		return []error{fmt.Errorf("could not execute netconf RPC call: %w", err)}
+	}
+	disks, err := parseREDiskScan(reply)
+	if err != nil {
+		return []error{err}
+	}
+
+	errors := []error{}
+	var methods []netconf.RPCMethod
+	for _, disk := range disks {
+		methods = append(methods, shellCommandMethod("smartctl -H -A "+disk.args))
+	}
+	replies, errs := execAll(ctx, conf, methods...)
+	for i, disk := range disks {
+		if errs[i] != nil {
+			errors = append(errors, fmt.Errorf("could not execute netconf RPC call for disk %q: %w", disk.name, errs[i]))
+			continue
+		}
+		if err := processREDiskHealthReply(replies[i], ch, slot, disk.name); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
+// shellCommandMethod returns the RPC running command in the shell of the route engine, which requires the shell
+// permission.
+func shellCommandMethod(command string) netconf.RPCMethod {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(command))
+	return netconf.RawMethod(fmt.Sprintf(`<request-shell-execute><command>%s</command></request-shell-execute>`, escaped.String()))
+}
+
+// reMasterSlot returns the slot of the master route engine, or of the only route engine, of a reply of
+// get-route-engine-information, singleRE when it has no slot.
+func reMasterSlot(reply *netconf.RPCReply) string {
+	var netconfReply reRPCReply
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return "singleRE"
+	}
+	entries := netconfReply.REInformation.REEntry
+	for _, reData := range entries {
+		if strings.EqualFold(strings.TrimSpace(reData.MastershipState.Text), "master") && reData.Slot.Text != "" {
+			return reData.Slot.Text
+		}
+	}
+	if len(entries) == 1 && entries[0].Slot.Text != "" {
+		return entries[0].Slot.Text
+	}
+	return "singleRE"
+}
+
+// reDisk is a disk of the route engine found by smartctl --scan.
+type reDisk struct {
+	// Name of the device, such as ada0.
+	name string
+	// Device and device type arguments of smartctl, such as /dev/ada0 -d atacam.
+	args string
+}
+
+func parseREDiskScan(reply *netconf.RPCReply) ([]reDisk, error) {
+	var netconfReply reShellRPCReply
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return nil, fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+	var disks []reDisk
+	for _, line := range strings.Split(netconfReply.Output, "\n") {
+		match := reDiskScanRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		args := append([]string{match[1]}, strings.Fields(match[2])...)
+		disks = append(disks, reDisk{name: strings.TrimPrefix(match[1], "/dev/"), args: strings.Join(args, " ")})
+	}
+	return disks, nil
+}
+
+func processREDiskHealthReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric, slot string, disk string) error {
+	var netconfReply reShellRPCReply
+	diskDesc := getREDiskDesc()
+
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+
+	// Disks not supporting SMART report no health.
+	if match := reDiskHealthRegexp.FindStringSubmatch(netconfReply.Output); match != nil {
+		health := 0.0
+		if match[1] == "PASSED" || match[1] == "OK" {
+			health = 1
+		}
+		ch <- prometheus.MustNewConstMetric(diskDesc["diskHealth"], prometheus.GaugeValue, health, slot, disk)
+	}
+
+	temperature, temperatureID := -1.0, ""
+	for _, match := range reDiskAttributeRegexp.FindAllStringSubmatch(netconfReply.Output, -1) {
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(diskDesc["diskAttribute"], prometheus.GaugeValue, value, slot, disk, match[1], match[2])
+		// Temperature_Celsius, or Airflow_Temperature_Cel of disks without it.
+		if match[1] == "194" || (match[1] == "190" && temperatureID == "") {
+			temperature, temperatureID = value, match[1]
+		}
+	}
+	if match := reDiskTemperatureRegexp.FindStringSubmatch(netconfReply.Output); match != nil && temperatureID == "" {
+		temperature, _ = strconv.ParseFloat(match[1], 64)
+	}
+	if temperature >= 0 {
+		ch <- prometheus.MustNewConstMetric(diskDesc["diskTemp"], prometheus.GaugeValue, temperature, slot, disk)
+	}
+	return nil
 }
 
 type reRPCReply struct {
@@ -213,6 +516,8 @@ type reEntry struct {
 	MemoryBufferUtilization reText    `xml:"memory-buffer-utilization"`
 	MemorySystemTotal       reText    `xml:"memory-system-total"`
 	MemorySystemTotalUsed   reText    `xml:"memory-system-total-used"`
+	MemorySystemTotalUtil   reText    `xml:"memory-system-total-util"`
+	Model                   reText    `xml:"model"`
 	CPUUser                 reText    `xml:"cpu-user"`
 	CPUBackground           reText    `xml:"cpu-background"`
 	CPUSystem               reText    `xml:"cpu-system"`
@@ -239,6 +544,93 @@ type reEntry struct {
 	LoadAverageFifteen      reText    `xml:"load-average-fifteen"`
 }
 
+type reStorageRPCReply struct {
+	StorageInformation reStorageInformation  `xml:"system-storage-information"`
+	MultiREResults     multiREStorageResults `xml:"multi-routing-engine-results"`
+}
+
+type reStorageInformation struct {
+	Filesystem []reFilesystem `xml:"filesystem"`
+}
+
+type multiREStorageResults struct {
+	MultiREItem []multiREStorageItem `xml:"multi-routing-engine-item"`
+}
+
+type multiREStorageItem struct {
+	REName             string               `xml:"re-name"`
+	StorageInformation reStorageInformation `xml:"system-storage-information"`
+}
+
+type reFilesystem struct {
+	Name            string `xml:"filesystem-name"`
+	TotalBlocks     string `xml:"total-blocks"`
+	UsedBlocks      string `xml:"used-blocks"`
+	AvailableBlocks string `xml:"available-blocks"`
+	UsedPercent     string `xml:"used-percent"`
+	MountedOn       string `xml:"mounted-on"`
+}
+
+type reProcessesRPCReply struct {
+	ProcessInformation reProcessInformation    `xml:"system-process-information"`
+	MultiREResults     multiREProcessesResults `xml:"multi-routing-engine-results"`
+}
+
+type reProcessInformation struct {
+	Output string `xml:"output"`
+}
+
+type multiREProcessesResults struct {
+	MultiREItem []multiREProcessesItem `xml:"multi-routing-engine-item"`
+}
+
+type multiREProcessesItem struct {
+	REName             string               `xml:"re-name"`
+	ProcessInformation reProcessInformation `xml:"system-process-information"`
+}
+
+func processRENetworkServicesNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
+	var netconfReply reNetworkServicesRPCReply
+	servicesDesc, multiREServicesDesc := getRENetworkServicesDesc()
+
+	if err := xml.Unmarshal([]byte(reply.RawReply), &netconfReply); err != nil {
+		return fmt.Errorf("could not unmarshal netconf reply xml: %s", err)
+	}
+
+	// Metrics for multiple route engine instances (i.e. clusters)
+	if len(netconfReply.MultiREResults.MultiREItem) != 0 {
+		for _, re := range netconfReply.MultiREResults.MultiREItem {
+			if mode := strings.TrimSpace(re.NetworkServices.Name); mode != "" {
+				ch <- prometheus.MustNewConstMetric(multiREServicesDesc, prometheus.GaugeValue, 1, mode, re.REName)
+			}
+		}
+		return nil
+	}
+
+	if mode := strings.TrimSpace(netconfReply.NetworkServices.Name); mode != "" {
+		ch <- prometheus.MustNewConstMetric(servicesDesc, prometheus.GaugeValue, 1, mode)
+	}
+	return nil
+}
+
+type reNetworkServicesRPCReply struct {
+	NetworkServices reNetworkServices             `xml:"network-services"`
+	MultiREResults  multiRENetworkServicesResults `xml:"multi-routing-engine-results"`
+}
+
+type reNetworkServices struct {
+	Name string `xml:"name"`
+}
+
+type multiRENetworkServicesResults struct {
+	MultiREItem []multiRENetworkServicesItem `xml:"multi-routing-engine-item"`
+}
+
+type multiRENetworkServicesItem struct {
+	REName          string            `xml:"re-name"`
+	NetworkServices reNetworkServices `xml:"network-services"`
+}
+
 type reText struct {
 	Text string `xml:",chardata"`
 }
@@ -250,3 +642,7 @@ type reTemp struct {
 type reSeconds struct {
 	Seconds string `xml:"seconds,attr"`
 }
+
+type reShellRPCReply struct {
+	Output string `xml:"output"`
+}
@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// shellReply returns the reply of request-shell-execute with output.
+func shellReply(output string) *netconf.RPCReply {
+	var escaped strings.Builder
+	escaped.WriteString("<rpc-reply><output>")
+	escaped.WriteString(strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(output))
+	escaped.WriteString("</output></rpc-reply>")
+	return &netconf.RPCReply{RawReply: escaped.String()}
+}
+
+// collectFunc is a prometheus.Collector sending the metrics of a function.
+type collectFunc func(ch chan<- prometheus.Metric)
+
+func (f collectFunc) Describe(chan<- *prometheus.Desc) {}
+
+func (f collectFunc) Collect(ch chan<- prometheus.Metric) { f(ch) }
+
+func TestParseREDiskScan(t *testing.T) {
+	disks, err := parseREDiskScan(shellReply(`/dev/ada0 -d atacam # /dev/ada0, ATA device
+/dev/nvme0 -d nvme # /dev/nvme0, NVMe device
+/dev/da1; reboot -d sat # injected
+smartctl: command not found
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []reDisk{{name: "ada0", args: "/dev/ada0 -d atacam"}, {name: "nvme0", args: "/dev/nvme0 -d nvme"}}
+	if len(disks) != len(want) {
+		t.Fatalf("got disks %v, want %v", disks, want)
+	}
+	for i := range want {
+		if disks[i] != want[i] {
+			t.Errorf("disk %d = %v, want %v", i, disks[i], want[i])
+		}
+	}
+}
+
+func TestProcessREDiskHealthReply(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name: "ata",
+			output: `smartctl 6.6 2017-11-05 r4594 [FreeBSD 11.0-STABLE amd64] (local build)
+
+=== START OF READ SMART DATA SECTION ===
+SMART overall-health self-assessment test result: PASSED
+
+SMART Attributes Data Structure revision number: 16
+Vendor Specific SMART Attributes with Thresholds:
+ID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH TYPE      UPDATED  WHEN_FAILED RAW_VALUE
+  5 Reallocated_Sector_Ct   0x0032   100   100   000    Old_age   Always       -       2
+  9 Power_On_Hours          0x0032   100   100   000    Old_age   Always       -       31520
+194 Temperature_Celsius     0x0022   070   060   000    Old_age   Always       -       30 (Min/Max 18/40)
+`,
+			want: `
+# HELP junos_route_engine_disk_health Overall SMART health of the disk (1 = Passed, 0 = Failed).
+# TYPE junos_route_engine_disk_health gauge
+junos_route_engine_disk_health{disk="ada0",slot="0"} 1
+# HELP junos_route_engine_disk_smart_attribute Raw value of the SMART attribute of the disk.
+# TYPE junos_route_engine_disk_smart_attribute gauge
+junos_route_engine_disk_smart_attribute{attribute="Power_On_Hours",disk="ada0",id="9",slot="0"} 31520
+junos_route_engine_disk_smart_attribute{attribute="Reallocated_Sector_Ct",disk="ada0",id="5",slot="0"} 2
+junos_route_engine_disk_smart_attribute{attribute="Temperature_Celsius",disk="ada0",id="194",slot="0"} 30
+# HELP junos_route_engine_disk_temperature_celsius Disk temperature in degrees celsius.
+# TYPE junos_route_engine_disk_temperature_celsius gauge
+junos_route_engine_disk_temperature_celsius{disk="ada0",slot="0"} 30
+`,
+		},
+		{
+			name: "nvme failed",
+			output: `=== START OF SMART DATA SECTION ===
+SMART overall-health self-assessment test result: FAILED!
+- NVM subsystem reliability has been degraded
+
+SMART/Health Information (NVMe Log 0x02)
+Critical Warning:                   0x04
+Temperature:                        41 Celsius
+`,
+			want: `
+# HELP junos_route_engine_disk_health Overall SMART health of the disk (1 = Passed, 0 = Failed).
+# TYPE junos_route_engine_disk_health gauge
+junos_route_engine_disk_health{disk="ada0",slot="0"} 0
+# HELP junos_route_engine_disk_temperature_celsius Disk temperature in degrees celsius.
+# TYPE junos_route_engine_disk_temperature_celsius gauge
+junos_route_engine_disk_temperature_celsius{disk="ada0",slot="0"} 41
+`,
+		},
+		{
+			name:   "smart unavailable",
+			output: "SMART support is: Unavailable - device lacks SMART capability.\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			collector := collectFunc(func(ch chan<- prometheus.Metric) {
+				err = processREDiskHealthReply(shellReply(tc.output), ch, "0", "ada0")
+			})
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tc.want)); err != nil {
+				t.Error(err)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestREMasterSlot(t *testing.T) {
+	for _, tc := range []struct {
+		reply string
+		want  string
+	}{
+		{
+			reply: `<rpc-reply><route-engine-information><route-engine><slot>0</slot><mastership-state>backup</mastership-state></route-engine>` +
+				`<route-engine><slot>1</slot><mastership-state>master</mastership-state></route-engine></route-engine-information></rpc-reply>`,
+			want: "1",
+		},
+		{
+			reply: `<rpc-reply><route-engine-information><route-engine><slot>0</slot></route-engine></route-engine-information></rpc-reply>`,
+			want:  "0",
+		},
+		{
+			reply: `<rpc-reply><route-engine-information><route-engine><mastership-state>master</mastership-state></route-engine></route-engine-information></rpc-reply>`,
+			want:  "singleRE",
+		},
+	} {
+		if got := reMasterSlot(&netconf.RPCReply{RawReply: tc.reply}); got != tc.want {
+			t.Errorf("reMasterSlot() = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TargetStatus is the outcome of the last scrape of a target.
+type TargetStatus struct {
+	Target     string    `json:"target"`
+	LastScrape time.Time `json:"last_scrape"`
+	// Duration of the last scrape in seconds.
+	Duration   float64                     `json:"duration_seconds"`
+	Success    bool                        `json:"success"`
+	Collectors map[string]*CollectorStatus `json:"collectors"`
+}
+
+// CollectorStatus is the outcome of the last scrape of a collector of a target, and its errors across all scrapes.
+type CollectorStatus struct {
+	Up        bool   `json:"up"`
+	Errors    int    `json:"errors"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+var (
+	statusMu sync.Mutex
+	// Status of the last scrape (value) per target (key).
+	statuses = map[string]*TargetStatus{}
+)
+
+// TargetStatuses returns the status of the last scrape of each target scraped since the exporter started, sorted by
+// target.
+func TargetStatuses() []TargetStatus {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	var list []TargetStatus
+	for _, status := range statuses {
+		s := *status
+		s.Collectors = map[string]*CollectorStatus{}
+		for name, c := range status.Collectors {
+			cs := *c
+			s.Collectors[name] = &cs
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Target < list[j].Target
+	})
+	return list
+}
+
+// scrapeStatus records the outcome of the collectors of a scrape, which is published to TargetStatuses once done.
+type scrapeStatus struct {
+	mu         sync.Mutex
+	target     string
+	start      time.Time
+	collectors map[string][]error
+}
+
+func newScrapeStatus(target string) *scrapeStatus {
+	return &scrapeStatus{target: target, start: time.Now(), collectors: map[string][]error{}}
+}
+
+func (s *scrapeStatus) collector(name string, errs []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collectors[name] = errs
+}
+
+func (s *scrapeStatus) done() {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	status, ok := statuses[s.target]
+	if !ok {
+		status = &TargetStatus{Target: s.target, Collectors: map[string]*CollectorStatus{}}
+		statuses[s.target] = status
+	}
+	status.LastScrape = s.start
+	status.Duration = time.Since(s.start).Seconds()
+	status.Success = true
+	for name, errs := range s.collectors {
+		c, ok := status.Collectors[name]
+		if !ok {
+			c = &CollectorStatus{}
+			status.Collectors[name] = c
+		}
+		c.Up = len(errs) == 0
+		c.Errors += len(errs)
+		if len(errs) > 0 {
+			c.LastError = errs[0].Error()
+			status.Success = false
+		}
+	}
+}
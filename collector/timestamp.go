@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clockSkewDesc = promDesc("device_clock_skew_seconds", "Difference between the clock of the device and the clock of the exporter, positive when the device is ahead.", nil)
+
+	// Clock skew (value) measured by the last scrape of each target (key) timestamping its metrics.
+	clockSkews sync.Map
+)
+
+// deviceClockSkew returns the difference between the current time reported by the device in the junos:seconds
+// attribute of the system uptime and the time of the exporter halfway through the RPC.
+func deviceClockSkew(ctx context.Context, s *Session) (time.Duration, error) {
+	device, local, err := deviceTime(context.WithValue(ctx, collectorKey{}, "device_time"), s)
+	if err != nil {
+		return 0, err
+	}
+	return device.Sub(local), nil
+}
+
+// deviceTime returns the current time reported by the device in the junos:seconds attribute of the system uptime,
+// along with the time of the exporter halfway through the RPC.
+func deviceTime(ctx context.Context, s *Session) (time.Time, time.Time, error) {
+	start := time.Now()
+	reply, err := s.Exec(ctx, netconf.RawMethod(`<get-system-uptime-information/>`))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not execute netconf RPC call: %w", err)
+	}
+	local := start.Add(time.Since(start) / 2)
+
+	var seconds string
+	err = decodeElements(reply, "current-time", func(currentTime *struct {
+		DateTime struct {
+			Seconds string `xml:"seconds,attr"`
+		} `xml:"date-time"`
+	}) error {
+		if seconds == "" {
+			seconds = currentTime.DateTime.Seconds
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if seconds == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse device time: no current-time in reply")
+	}
+	s64, err := strconv.ParseInt(strings.TrimSpace(seconds), 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse device time %q: %s", seconds, err)
+	}
+	return time.Unix(s64, 0), local, nil
+}
+
+// deviceTimestamps sends the metrics received on the returned channel to ch with the time of the device as their
+// timestamp, which is the time they are received offset by skew. The returned function must be called once no more
+// metrics are sent.
+func deviceTimestamps(ch chan<- prometheus.Metric, skew time.Duration) (chan<- prometheus.Metric, func()) {
+	timestamped := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range timestamped {
+			ch <- prometheus.NewMetricWithTimestamp(time.Now().Add(skew), metric)
+		}
+	}()
+	return timestamped, func() {
+		close(timestamped)
+		<-done
+	}
+}
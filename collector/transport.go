@@ -0,0 +1,382 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// netconfPort is the default port used for NETCONF over SSH.
+	netconfPort = "830"
+	// netconfTLSPort is the default port used for NETCONF over TLS.
+	netconfTLSPort = "6513"
+
+	// Message separators for NETCONF 1.0 (RFC 4742) and 1.1 (RFC 6242) framing.
+	netconfEOM      = "]]>]]>"
+	netconfEOChunks = "\n##\n"
+)
+
+// netconfTransport is a netconf.Transport over a connection that can be kept alive while idle.
+type netconfTransport interface {
+	netconf.Transport
+	// SetDeadline sets the read and write deadline of the underlying connection.
+	SetDeadline(t time.Time) error
+	// keepalive checks that the connection is still alive.
+	keepalive() error
+}
+
+// framer implements the NETCONF message framing of netconf.Transport over a reader and writer.
+type framer struct {
+	r       *bufio.Reader
+	w       io.Writer
+	version string
+}
+
+// SetVersion sets the framing version negotiated in the hello exchange.
+func (f *framer) SetVersion(version string) {
+	f.version = version
+}
+
+// Send writes data using the framing of the negotiated version.
+func (f *framer) Send(data []byte) error {
+	var buf bytes.Buffer
+	if f.version == "v1.1" {
+		fmt.Fprintf(&buf, "\n#%d\n", len(data))
+		buf.Write(data)
+		buf.WriteString(netconfEOChunks)
+	} else {
+		buf.Write(data)
+		buf.WriteString(netconfEOM)
+	}
+	_, err := f.w.Write(buf.Bytes())
+	return err
+}
+
+// Receive reads a single message using the framing of the negotiated version.
+func (f *framer) Receive() ([]byte, error) {
+	if f.version == "v1.1" {
+		return f.readChunks()
+	}
+	return f.readUntil([]byte(netconfEOM))
+}
+
+// SendHello sends the client hello message.
+func (f *framer) SendHello(hello *netconf.HelloMessage) error {
+	val, err := xml.Marshal(hello)
+	if err != nil {
+		return err
+	}
+	return f.Send(append([]byte(xml.Header), val...))
+}
+
+// ReceiveHello reads the server hello message.
+func (f *framer) ReceiveHello() (*netconf.HelloMessage, error) {
+	hello := new(netconf.HelloMessage)
+	val, err := f.Receive()
+	if err != nil {
+		return hello, err
+	}
+	err = xml.Unmarshal(val, hello)
+	return hello, err
+}
+
+func (f *framer) readUntil(sep []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		data, err := f.r.ReadSlice(sep[len(sep)-1])
+		buf.Write(data)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasSuffix(buf.Bytes(), sep) {
+			return buf.Bytes()[:buf.Len()-len(sep)], nil
+		}
+	}
+}
+
+// readChunks reads a message framed with the chunked framing mechanism of RFC 6242.
+func (f *framer) readChunks() ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		header, err := f.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		// Each chunk is preceded by "\n#<size>\n" and the message ends with "\n##\n".
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+		header = strings.TrimSuffix(header, "\n")
+		if header == "##" {
+			return buf.Bytes(), nil
+		}
+		if !strings.HasPrefix(header, "#") {
+			return nil, fmt.Errorf("invalid netconf chunk header %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid netconf chunk size %q: %s", header, err)
+		}
+		if _, err := io.CopyN(&buf, f.r, int64(size)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sshTransport implements netconf.Transport over an SSH channel running the netconf subsystem.
+type sshTransport struct {
+	framer
+	conn    net.Conn
+	client  *ssh.Client
+	session *ssh.Session
+}
+
+// Close closes the SSH channel and connection.
+func (t *sshTransport) Close() error {
+	if t.session != nil {
+		t.session.Close()
+	}
+	return t.client.Close()
+}
+
+// SetDeadline sets the deadline of the underlying TCP connection.
+func (t *sshTransport) SetDeadline(deadline time.Time) error {
+	return t.conn.SetDeadline(deadline)
+}
+
+func (t *sshTransport) keepalive() error {
+	_, _, err := t.client.SendRequest("keepalive@openssh.com", true, nil)
+	return err
+}
+
+// targetHost returns the host of target, which is a host name or an IPv4 or IPv6 address, with or without port. IPv6
+// addresses may be enclosed in brackets.
+func targetHost(target string) string {
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+}
+
+// targetAddress returns the address to dial to connect to target, using port when target does not include one.
+func targetAddress(target string, port string) string {
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target
+	}
+	return net.JoinHostPort(targetHost(target), port)
+}
+
+// dialConn connects to the address target, through proxyURL when set. SOCKS5 proxies are used for socks5 and socks5h
+// URLs, HTTP proxies supporting the CONNECT method for http URLs.
+func dialConn(ctx context.Context, target string, proxyURL *url.URL, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, "tcp", target)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(proxyURL, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return d.(proxy.ContextDialer).DialContext(ctx, "tcp", target)
+	case "http":
+		return dialHTTPConnect(ctx, dialer, target, proxyURL, timeout)
+	}
+	return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+}
+
+// dialHTTPConnect connects to the address target through the HTTP proxy at proxyURL using the CONNECT method.
+func dialHTTPConnect(ctx context.Context, dialer *net.Dialer, target string, proxyURL *url.URL, timeout time.Duration) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: http.Header{},
+	}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused connection to %q: %s", target, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	// The device may already have sent data, such as its SSH version, that was buffered while reading the response.
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// bufferedConn is a net.Conn that reads from a buffered reader of the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// dialSSHTransport connects to the address target, through proxyURL when set, and opens the netconf subsystem. The
+// connection deadline is set to the SSH timeout and must be cleared once the hello exchange completes.
+func dialSSHTransport(ctx context.Context, target string, proxyURL *url.URL, config *ssh.ClientConfig) (*sshTransport, error) {
+	conn, err := dialConn(ctx, target, proxyURL, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if config.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(config.Timeout))
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, target, config)
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	t := &sshTransport{conn: conn, client: ssh.NewClient(c, chans, reqs)}
+	if err := t.openChannel(); err != nil {
+		t.client.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *sshTransport) openChannel() error {
+	session, err := t.client.NewSession()
+	if err != nil {
+		return err
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return err
+	}
+	if err := session.RequestSubsystem("netconf"); err != nil {
+		session.Close()
+		return err
+	}
+	t.session = session
+	t.framer = framer{r: bufio.NewReaderSize(r, 64*1024), w: w}
+	return nil
+}
+
+// tlsTransport implements netconf.Transport over a TLS connection as per RFC 7589.
+type tlsTransport struct {
+	framer
+	conn net.Conn
+}
+
+// Close closes the TLS connection.
+func (t *tlsTransport) Close() error {
+	return t.conn.Close()
+}
+
+// SetDeadline sets the deadline of the TLS connection.
+func (t *tlsTransport) SetDeadline(deadline time.Time) error {
+	return t.conn.SetDeadline(deadline)
+}
+
+// keepalive is a no-op, idle TLS connections are kept alive using TCP keepalives.
+func (t *tlsTransport) keepalive() error {
+	return nil
+}
+
+// dialTLSTransport connects to the address target using TLS, through proxyURL when set. The connection deadline is set
+// to timeout and must be cleared once the hello exchange completes.
+func dialTLSTransport(ctx context.Context, target string, proxyURL *url.URL, config *tls.Config, timeout time.Duration) (*tlsTransport, error) {
+	rawConn, err := dialConn(ctx, target, proxyURL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if config.ServerName == "" {
+		config = config.Clone()
+		config.ServerName = targetHost(target)
+	}
+	conn := tls.Client(rawConn, config)
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := conn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &tlsTransport{
+		framer: framer{r: bufio.NewReaderSize(conn, 64*1024), w: conn},
+		conn:   conn,
+	}, nil
+}
+
+// newNetconfSession performs the NETCONF hello exchange over t.
+func newNetconfSession(t netconf.Transport) (*netconf.Session, error) {
+	serverHello, err := t.ReceiveHello()
+	if err != nil {
+		return nil, fmt.Errorf("could not receive netconf hello: %s", err)
+	}
+	if err := t.SendHello(&netconf.HelloMessage{Capabilities: netconf.DefaultCapabilities}); err != nil {
+		return nil, fmt.Errorf("could not send netconf hello: %s", err)
+	}
+	t.SetVersion("v1.0")
+	for _, capability := range serverHello.Capabilities {
+		if strings.Contains(capability, "urn:ietf:params:netconf:base:1.1") {
+			t.SetVersion("v1.1")
+			break
+		}
+	}
+	return &netconf.Session{
+		Transport:          t,
+		SessionID:          serverHello.SessionID,
+		ServerCapabilities: serverHello.Capabilities,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFramerSend(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		want    string
+	}{
+		{version: "", want: "<rpc/>]]>]]>"},
+		{version: "v1.1", want: "\n#6\n<rpc/>\n##\n"},
+	} {
+		var buf bytes.Buffer
+		f := framer{w: &buf, version: tc.version}
+		if err := f.Send([]byte("<rpc/>")); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("Send() with version %q wrote %q, want %q", tc.version, buf.String(), tc.want)
+		}
+	}
+}
+
+func TestFramerReceive(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		version string
+		input   string
+		want    []string
+	}{
+		{
+			name:  "end of message",
+			input: "<rpc-reply>a</rpc-reply>]]>]]>\n<rpc-reply>b</rpc-reply>]]>]]>",
+			want:  []string{"<rpc-reply>a</rpc-reply>", "\n<rpc-reply>b</rpc-reply>"},
+		},
+		{
+			name:    "chunks",
+			version: "v1.1",
+			input:   "\n#4\n<rpc\n#9\n-reply/>\n\n##\n\n#4\n<a/>\n##\n",
+			want:    []string{"<rpc-reply/>\n", "<a/>"},
+		},
+		{
+			// A chunk may contain newlines and the characters of the chunk header.
+			name:    "chunk containing headers",
+			version: "v1.1",
+			input:   "\n#10\n\n##\n\n#2\nab\n##\n",
+			want:    []string{"\n##\n\n#2\nab"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := framer{r: bufio.NewReader(strings.NewReader(tc.input)), version: tc.version}
+			for _, want := range tc.want {
+				got, err := f.Receive()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != want {
+					t.Errorf("Receive() = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFramerReceiveLongMessage(t *testing.T) {
+	// Longer than the buffer of the reader, so that the separator is split across reads.
+	message := strings.Repeat("x", 3*4096+2) + "]]"
+	f := framer{r: bufio.NewReaderSize(strings.NewReader(message+netconfEOM), 16)}
+	got, err := f.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != message {
+		t.Errorf("Receive() returned %d bytes, want %d", len(got), len(message))
+	}
+}
+
+func TestFramerReceiveInvalidChunk(t *testing.T) {
+	for _, input := range []string{"\n#x\n<a/>\n##\n", "\nabc\n", "\n#10\n<a/>"} {
+		f := framer{r: bufio.NewReader(strings.NewReader(input)), version: "v1.1"}
+		if _, err := f.Receive(); err == nil {
+			t.Errorf("Receive() of %q succeeded, want error", input)
+		}
+	}
+}
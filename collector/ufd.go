@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ufdSubsystem = "ufd"
+
+	ufdDesc = map[string]*prometheus.Desc{
+		"GroupUp": colPromDesc(ufdSubsystem, "group_up", "Whether the failure action of the uplink failure detection group is inactive (1 = inactive, 0 = active, the downlinks being disabled).", []string{"group"}),
+		"LinkUp":  colPromDesc(ufdSubsystem, "link_up", "Whether the link of the uplink failure detection group is up (1 = up, 0 = down), by role of uplink or downlink.", []string{"group", "interface", "role"}),
+	}
+)
+
+// UFDCollector collects the uplink failure detection groups of EX and QFX switches, implemented as per the Collector
+// interface.
+type UFDCollector struct {
+	logger log.Logger
+}
+
+// NewUFDCollector returns a new UFDCollector.
+func NewUFDCollector(logger log.Logger) *UFDCollector {
+	return &UFDCollector{logger: logger}
+}
+
+// Name of the collector.
+func (*UFDCollector) Name() string {
+	return ufdSubsystem
+}
+
+// RPCs executed by the collector.
+func (*UFDCollector) RPCs() []string {
+	return []string{"command"}
+}
+
+// UnsupportedPlatforms returns the platforms without uplink failure detection, as per the PlatformLimiter interface.
+func (*UFDCollector) UnsupportedPlatforms() []string {
+	return []string{"srx", "mx", "ptx", "acx"}
+}
+
+// Get metrics and send to the Prometheus.Metric channel.
+func (c *UFDCollector) Get(ctx context.Context, ch chan<- prometheus.Metric, conf Config) []error {
+	errors := []error{}
+	// show uplink-failure-detection, of which the text output is parsed
+	reply, err := conf.Session.Exec(ctx, netconf.RawMethod(`<command format="text">show uplink-failure-detection</command>`))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("could not execute netconf RPC call: %w", err))
+	} else if err := processUFDNetconfReply(reply, ch); err != nil {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+func processUFDNetconfReply(reply *netconf.RPCReply, ch chan<- prometheus.Metric) error {
+	return decodeElements(reply, "output", func(output *string) error {
+		for _, group := range parseUFDGroups(*output) {
+			up := 1.0
+			if strings.EqualFold(group.action, "active") {
+				up = 0
+			}
+			ch <- prometheus.MustNewConstMetric(ufdDesc["GroupUp"], prometheus.GaugeValue, up, group.name)
+			for _, link := range group.links {
+				linkUp := 0.0
+				if link.up {
+					linkUp = 1
+				}
+				ch <- prometheus.MustNewConstMetric(ufdDesc["LinkUp"], prometheus.GaugeValue, linkUp, group.name, link.name, link.role)
+			}
+		}
+		return nil
+	})
+}
+
+type ufdGroup struct {
+	name   string
+	action string
+	links  []ufdLink
+}
+
+type ufdLink struct {
+	name string
+	role string
+	up   bool
+}
+
+// parseUFDGroups returns the groups of the output of show uplink-failure-detection, which lists the attributes of each
+// group as "Key : value" lines, such as:
+//
+//	Group                   : group1
+//	Uplink                  : ge-0/0/0*, ge-0/0/1
+//	Downlink                : ge-0/0/2*
+//	Failure Action          : Inactive
+//
+// Links that are up are marked with an asterisk. Lists of links may continue on further lines without a key.
+func parseUFDGroups(output string) []ufdGroup {
+	var groups []ufdGroup
+	var key string
+	for _, line := range strings.Split(output, "\n") {
+		k, value, ok := strings.Cut(line, ":")
+		if !ok || strings.Contains(k, "/") {
+			k, value = "", line
+		}
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			key = k
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		switch {
+		case key == "group" && k != "":
+			groups = append(groups, ufdGroup{name: value})
+		case len(groups) == 0:
+		case key == "failure action":
+			groups[len(groups)-1].action = value
+		case key == "uplink", key == "downlink":
+			for _, name := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+				link := ufdLink{name: strings.TrimSuffix(name, "*"), role: key, up: strings.HasSuffix(name, "*")}
+				groups[len(groups)-1].links = append(groups[len(groups)-1].links, link)
+			}
+		}
+	}
+	return groups
+}
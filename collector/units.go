@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Units of sizes, compared ignoring case. K, M, G and T are decimal units, as used by Junos for the memory of route
+// engines and FPCs, while KiB, MiB, GiB and TiB are binary units.
+const (
+	kilobyte = 1000
+	megabyte = 1000 * kilobyte
+	gigabyte = 1000 * megabyte
+	terabyte = 1000 * gigabyte
+)
+
+var (
+	sizeUnits = map[string]float64{
+		"b": 1, "bytes": 1,
+		"k": kilobyte, "kb": kilobyte, "kib": 1 << 10,
+		"m": megabyte, "mb": megabyte, "mib": 1 << 20,
+		"g": gigabyte, "gb": gigabyte, "gib": 1 << 30,
+		"t": terabyte, "tb": terabyte, "tib": 1 << 40,
+	}
+
+	// Units of rates in bits per second, such as 10Gbps or 100mbps, compared ignoring case.
+	rateUnits = map[string]float64{
+		"bps":  1,
+		"kbps": 1e3,
+		"mbps": 1e6,
+		"gbps": 1e9,
+		"tbps": 1e12,
+	}
+
+	// Units of the parts of durations such as 2w3d, in seconds.
+	durationUnits = map[rune]float64{
+		'y': 365 * 24 * 3600,
+		'w': 7 * 24 * 3600,
+		'd': 24 * 3600,
+		'h': 3600,
+		'm': 60,
+		's': 1,
+	}
+
+	// Units of durations written out, such as "5 days, 3:04:05", of the number before them.
+	durationWords = map[string]rune{
+		"year": 'y', "years": 'y',
+		"week": 'w', "weeks": 'w',
+		"day": 'd', "days": 'd',
+		"hour": 'h', "hours": 'h',
+		"minute": 'm', "minutes": 'm', "min": 'm', "mins": 'm',
+		"second": 's', "seconds": 's', "sec": 's', "secs": 's',
+	}
+)
+
+// splitUnit splits value into its number and the unit following it, such as "1.5 GB" into 1.5 and "gb", the unit being
+// lower case and empty when there is none.
+func splitUnit(value string) (float64, string, error) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+	number, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return number, strings.ToLower(strings.TrimSpace(value[i:])), nil
+}
+
+// parseSize returns the bytes of a size such as "32703 MB", "1.5 GB" or "512K", bare numbers being in unit bytes. skip
+// is set for empty values and placeholders of values that are not available.
+func parseSize(value string, unit float64) (float64, bool, error) {
+	if isPlaceholder(value) {
+		return 0, true, nil
+	}
+	number, suffix, err := splitUnit(value)
+	if err != nil {
+		return 0, false, err
+	}
+	if suffix == "" {
+		return number * unit, false, nil
+	}
+	multiplier, ok := sizeUnits[suffix]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown size unit %q", suffix)
+	}
+	return number * multiplier, false, nil
+}
+
+// parseRate returns the bits per second of a rate such as "10Gbps", "100mbps" or "1.5 Kbps". skip is set for rates
+// without a value, such as Unlimited or Auto, as well as for empty values and placeholders.
+func parseRate(value string) (float64, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "unlimited", "auto", "unspecified":
+		return 0, true, nil
+	}
+	if isPlaceholder(value) {
+		return 0, true, nil
+	}
+	number, suffix, err := splitUnit(value)
+	if err != nil {
+		return 0, false, err
+	}
+	multiplier, ok := rateUnits[suffix]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown rate unit %q", suffix)
+	}
+	return number * multiplier, false, nil
+}
+
+// parseDuration returns the seconds of a duration as formatted by Junos, of which the parts are separated by spaces or
+// commas, such as "2w3d 04:05:06", "1y2w", "5 days, 3:04:05" or "45". Parts with colons are [hours:]minutes:seconds,
+// while other parts are numbers followed by a unit of y, w, d, h, m or s, or by a unit written out, and seconds when
+// there is no unit.
+func parseDuration(value string) (float64, error) {
+	var parts []string
+	for _, part := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	}) {
+		if unit, ok := durationWords[part]; ok && len(parts) > 0 {
+			parts[len(parts)-1] += string(unit)
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("empty duration")
+	}
+	seconds := 0.0
+	for _, part := range parts {
+		if strings.Contains(part, ":") {
+			clock := 0.0
+			for _, field := range strings.Split(part, ":") {
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid duration %q", value)
+				}
+				clock = clock*60 + v
+			}
+			seconds += clock
+			continue
+		}
+		start := 0
+		for i, r := range part {
+			unit, ok := durationUnits[r]
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(part[start:i], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			seconds += v * unit
+			start = i + 1
+		}
+		if start < len(part) {
+			v, err := strconv.ParseFloat(part[start:], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			seconds += v
+		}
+	}
+	return seconds, nil
+}
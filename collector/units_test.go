@@ -0,0 +1,97 @@
+package collector
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		unit  float64
+		want  float64
+		skip  bool
+	}{
+		{value: "32703 MB", unit: 1, want: 32703e6},
+		{value: "1.5 GB", unit: 1, want: 1.5e9},
+		{value: "512K", unit: 1, want: 512e3},
+		{value: "4 GiB", unit: 1, want: 4 << 30},
+		{value: "100 bytes", unit: 1, want: 100},
+		{value: "2048", unit: 1024, want: 2048 * 1024},
+		{value: " 7 tb ", unit: 1, want: 7e12},
+		{value: "", unit: 1, skip: true},
+		{value: "N/A", unit: 1, skip: true},
+	} {
+		got, skip, err := parseSize(tc.value, tc.unit)
+		if err != nil {
+			t.Errorf("parseSize(%q) failed: %v", tc.value, err)
+			continue
+		}
+		if got != tc.want || skip != tc.skip {
+			t.Errorf("parseSize(%q) = %v, %t, want %v, %t", tc.value, got, skip, tc.want, tc.skip)
+		}
+	}
+
+	for _, value := range []string{"12 parsecs", "MB"} {
+		if _, _, err := parseSize(value, 1); err == nil {
+			t.Errorf("parseSize(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  float64
+		skip  bool
+	}{
+		{value: "10Gbps", want: 10e9},
+		{value: "100mbps", want: 100e6},
+		{value: "1.5 Kbps", want: 1500},
+		{value: "9600 bps", want: 9600},
+		{value: "Unlimited", skip: true},
+		{value: "Auto", skip: true},
+		{value: "Unspecified", skip: true},
+		{value: "", skip: true},
+	} {
+		got, skip, err := parseRate(tc.value)
+		if err != nil {
+			t.Errorf("parseRate(%q) failed: %v", tc.value, err)
+			continue
+		}
+		if got != tc.want || skip != tc.skip {
+			t.Errorf("parseRate(%q) = %v, %t, want %v, %t", tc.value, got, skip, tc.want, tc.skip)
+		}
+	}
+
+	for _, value := range []string{"10", "10 GB", "fast"} {
+		if _, _, err := parseRate(value); err == nil {
+			t.Errorf("parseRate(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, want := range map[string]float64{
+		"45":                 45,
+		"2w3d 04:05:06":      2*604800 + 3*86400 + 4*3600 + 5*60 + 6,
+		"1y2w":               365*86400 + 2*604800,
+		"5 days, 3:04:05":    5*86400 + 3*3600 + 4*60 + 5,
+		"1d 2h 3m 4s":        86400 + 2*3600 + 3*60 + 4,
+		"12:34":              12*60 + 34,
+		"3 hours 20 minutes": 3*3600 + 20*60,
+		"1 week, 10 secs":    604800 + 10,
+	} {
+		got, err := parseDuration(value)
+		if err != nil {
+			t.Errorf("parseDuration(%q) failed: %v", value, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseDuration(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"", "never", "1x2", "12:ab"} {
+		if _, err := parseDuration(value); err == nil {
+			t.Errorf("parseDuration(%q) succeeded, want error", value)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errCollectorTimeout is the error of a collector that did not complete within its collector timeout.
+var errCollectorTimeout = errors.New("collector timeout exceeded")
+
+// collectorTimeout returns the time the collector may take within a scrape, no limit when 0.
+func collectorTimeout(config Config, collectorName string) time.Duration {
+	if timeout, ok := config.CollectorTimeouts[collectorName]; ok {
+		return timeout
+	}
+	return config.CollectorTimeout
+}
+
+// watchdog runs get, sending the metrics it sends to ch, and returns its errors. When get does not return within
+// timeout, the collector is abandoned: watchdog returns errCollectorTimeout right away and the metrics get sends
+// afterwards are discarded, so that a collector stuck outside of an RPC does not hold back the scrape.
+func watchdog(ch chan<- prometheus.Metric, timeout time.Duration, get func(chan<- prometheus.Metric) []error) []error {
+	if timeout <= 0 {
+		return get(ch)
+	}
+
+	guarded := make(chan prometheus.Metric)
+	// Values that could not be converted are recorded by the tracker of ch, if any.
+	if tracker, ok := parseTrackers.Load(ch); ok {
+		parseTrackers.Store((chan<- prometheus.Metric)(guarded), tracker)
+		defer parseTrackers.Delete((chan<- prometheus.Metric)(guarded))
+	}
+	result := make(chan []error, 1)
+	go func() {
+		result <- get(guarded)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case metric := <-guarded:
+			ch <- metric
+		case errs := <-result:
+			return errs
+		case <-timer.C:
+			go func() {
+				for {
+					select {
+					case <-guarded:
+					case <-result:
+						return
+					}
+				}
+			}()
+			return []error{fmt.Errorf("%w after %s", errCollectorTimeout, timeout)}
+		}
+	}
+}
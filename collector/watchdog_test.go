@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var watchdogDesc = prometheus.NewDesc("junos_test", "Test metric.", nil, nil)
+
+// collectWatchdog runs get under watchdog and returns the number of metrics sent and the errors returned.
+func collectWatchdog(timeout time.Duration, get func(chan<- prometheus.Metric) []error) (int, []error) {
+	ch := make(chan prometheus.Metric)
+	done := make(chan []error)
+	go func() {
+		errs := watchdog(ch, timeout, get)
+		close(ch)
+		done <- errs
+	}()
+	n := 0
+	for range ch {
+		n++
+	}
+	return n, <-done
+}
+
+func sendMetrics(ch chan<- prometheus.Metric, n int) {
+	for i := 0; i < n; i++ {
+		ch <- prometheus.MustNewConstMetric(watchdogDesc, prometheus.GaugeValue, float64(i))
+	}
+}
+
+func TestWatchdogCompletes(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		n, errs := collectWatchdog(timeout, func(ch chan<- prometheus.Metric) []error {
+			sendMetrics(ch, 3)
+			return []error{errors.New("partial")}
+		})
+		if n != 3 {
+			t.Errorf("timeout %s: got %d metrics, want 3", timeout, n)
+		}
+		if len(errs) != 1 || errs[0].Error() != "partial" {
+			t.Errorf("timeout %s: got errors %v, want the errors of the collector", timeout, errs)
+		}
+	}
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	start := time.Now()
+	n, errs := collectWatchdog(50*time.Millisecond, func(ch chan<- prometheus.Metric) []error {
+		defer close(finished)
+		sendMetrics(ch, 2)
+		<-release
+		// Sent after the collector was abandoned, so discarded rather than blocking the collector.
+		sendMetrics(ch, 2)
+		return nil
+	})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("watchdog returned after %s, want right after the timeout", elapsed)
+	}
+	if n != 2 {
+		t.Errorf("got %d metrics, want the 2 sent before the timeout", n)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], errCollectorTimeout) {
+		t.Errorf("got errors %v, want %v", errs, errCollectorTimeout)
+	}
+
+	close(release)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Error("abandoned collector is blocked sending its metrics")
+	}
+}
+
+func TestCollectorTimeout(t *testing.T) {
+	config := Config{
+		CollectorTimeout:  10 * time.Second,
+		CollectorTimeouts: map[string]time.Duration{"bgp": time.Minute, "route": 0},
+	}
+	for name, want := range map[string]time.Duration{"interface": 10 * time.Second, "bgp": time.Minute, "route": 0} {
+		if got := collectorTimeout(config, name); got != want {
+			t.Errorf("collectorTimeout(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
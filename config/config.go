@@ -1,54 +1,275 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 
+	"github.com/prometheus/common/model"
 	"golang.org/x/crypto/ssh"
 	yaml "gopkg.in/yaml.v2"
 )
 
 // Configuration contains a slice of all configurations in the format of a map, where the key is the name of the config and the values are a Config type.
 type Configuration struct {
-	Config map[string]Config `yaml:"configs"`
-	Global Global            `yaml:"global"`
+	Config  map[string]Config `yaml:"configs"`
+	Global  Global            `yaml:"global"`
+	Targets map[string]Target `yaml:"targets"`
+	// Files or directories whose configs and targets are merged into the configuration.
+	Include     []string    `yaml:"include"`
+	RemoteWrite RemoteWrite `yaml:"remote_write"`
+	OTLP        OTLP        `yaml:"otlp"`
+	// Bundles of collectors and metric filters selected by the 'module' parameter.
+	Modules map[string]Module `yaml:"modules"`
+}
+
+// Module contains the collectors and metric filters of a scrape passing the module in the 'module' parameter, which
+// are used instead of those of the config.
+type Module struct {
+	Collectors    []string                `yaml:"enabled_collectors"`
+	MetricFilters map[string]MetricFilter `yaml:"metric_filters"`
+}
+
+// RemoteWrite contains the endpoint to which the metrics of the targets polled in the background are pushed using
+// the Prometheus remote write protocol.
+type RemoteWrite struct {
+	URL string `yaml:"url"`
+	// Interval and timeout of the remote write requests in seconds.
+	Interval    int               `yaml:"interval"`
+	Timeout     int               `yaml:"timeout"`
+	Job         string            `yaml:"job"`
+	Username    string            `yaml:"username"`
+	Password    string            `yaml:"password"`
+	BearerToken string            `yaml:"bearer_token"`
+	Headers     map[string]string `yaml:"headers"`
+}
+
+// OTLP contains the OpenTelemetry collector to which the metrics of the targets polled in the background are exported
+// using OTLP over HTTP.
+type OTLP struct {
+	// URL of the OTLP metrics endpoint, /v1/metrics is appended to URLs without a path.
+	Endpoint string `yaml:"endpoint"`
+	// Interval and timeout of the export requests in seconds.
+	Interval int               `yaml:"interval"`
+	Timeout  int               `yaml:"timeout"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// Target contains the inventory information of a target.
+type Target struct {
+	// Address connected to when the target is an alias.
+	Address string            `yaml:"address"`
+	Labels  map[string]string `yaml:"labels"`
+	// Seconds during which the metrics of a scrape of the target are served to further scrapes instead of collecting
+	// the target again.
+	MinScrapeInterval int `yaml:"min_scrape_interval"`
 }
 
 // Config contains the information required by junos_collector to create SSH based NETCONF connections.
 type Config struct {
-	Username            string   `yaml:"username"`
-	Timeout             int      `yaml:"timeout"`
-	Password            string   `yaml:"password"`
-	SSHKey              string   `yaml:"ssh_key"`
-	AllowedTargets      []string `yaml:"allowed_targets"`
-	Collectors          []string `yaml:"enabled_collectors"`
-	InterfaceDescKeys   []string `yaml:"interface_description_keys"`
-	InterfaceMetricKeys []string `yaml:"interface_metric_keys"`
-	BGPTypeKeys         []string `yaml:"bgp_peer_type_keys"`
+	Credential           `yaml:",inline"`
+	Credentials          []Credential            `yaml:"credentials"`
+	Timeout              int                     `yaml:"timeout"`
+	Port                 int                     `yaml:"port"`
+	ProxyURL             string                  `yaml:"proxy_url"`
+	RPCTimeout           int                     `yaml:"rpc_timeout"`
+	ScrapeCacheTTL       int                     `yaml:"scrape_cache_ttl"`
+	RPCRetries           int                     `yaml:"rpc_retries"`
+	RPCRetryBackoff      int                     `yaml:"rpc_retry_backoff"`
+	RPCParallelism       int                     `yaml:"rpc_parallelism"`
+	MaxSessions          int                     `yaml:"max_sessions"`
+	CollectorRPCTimeouts map[string]int          `yaml:"collector_rpc_timeouts"`
+	CollectorTimeout     int                     `yaml:"collector_timeout"`
+	CollectorTimeouts    map[string]int          `yaml:"collector_timeouts"`
+	AllowedTargets       []string                `yaml:"allowed_targets"`
+	Collectors           []string                `yaml:"enabled_collectors"`
+	InterfaceDescKeys    []string                `yaml:"interface_description_keys"`
+	InterfaceMetricKeys  []string                `yaml:"interface_metric_keys"`
+	InterfaceDescLabel   bool                    `yaml:"interface_description_label"`
+	InterfaceKeyLabels   bool                    `yaml:"interface_description_key_labels"`
+	InterfaceDetail      string                  `yaml:"interface_detail"`
+	InterfaceTypes       string                  `yaml:"interface_types"`
+	InterfaceUtilization bool                    `yaml:"interface_utilization"`
+	InterfaceInstances   bool                    `yaml:"interface_routing_instances"`
+	BGPTypeKeys          []string                `yaml:"bgp_peer_type_keys"`
+	REDiskHealth         bool                    `yaml:"route_engine_disk_health"`
+	BGPInstances         []string                `yaml:"bgp_instances"`
+	DisableBGPInstances  bool                    `yaml:"disable_bgp_instances"`
+	BGPMonitoredPeers    []string                `yaml:"bgp_monitored_peers"`
+	IpsecTunnelStats     bool                    `yaml:"ipsec_tunnel_statistics"`
+	HostKeyChecking      string                  `yaml:"host_key_checking"`
+	KnownHostsFile       string                  `yaml:"known_hosts_file"`
+	HostKeys             map[string]string       `yaml:"host_keys"`
+	SSHAlgorithms        SSHAlgorithms           `yaml:"ssh_algorithms"`
+	Transport            string                  `yaml:"transport"`
+	ReplayDir            string                  `yaml:"replay_dir"`
+	Commands             []Command               `yaml:"commands"`
+	RouteFilters         []RouteFilter           `yaml:"route_filters"`
+	GNMI                 GNMI                    `yaml:"gnmi"`
+	TLS                  TLS                     `yaml:"tls"`
+	Vault                Vault                   `yaml:"vault"`
+	MetricFilters        map[string]MetricFilter `yaml:"metric_filters"`
+	StrictParsing        bool                    `yaml:"strict_parsing"`
+	DeviceTimestamps     bool                    `yaml:"device_timestamps"`
+	DeviceTimestampSkew  int                     `yaml:"device_timestamp_max_skew"`
+	PlatformDetection    bool                    `yaml:"platform_detection"`
+	Polling              Polling                 `yaml:"polling"`
+	Labels               map[string]string       `yaml:"labels"`
+
+	// AllowedTargetPatterns are the parsed allowed_targets.
+	AllowedTargetPatterns []TargetPattern `yaml:"-"`
+}
+
+// Credential contains the SSH credentials used to authenticate to a device.
+type Credential struct {
+	Username             string `yaml:"username"`
+	Password             string `yaml:"password"`
+	PasswordFile         string `yaml:"password_file"`
+	PasswordEnv          string `yaml:"password_env"`
+	SSHKey               string `yaml:"ssh_key"`
+	SSHKeyEnv            string `yaml:"ssh_key_env"`
+	SSHKeyPassphrase     string `yaml:"ssh_key_passphrase"`
+	SSHKeyPassphraseFile string `yaml:"ssh_key_passphrase_file"`
+	SSHKeyPassphraseEnv  string `yaml:"ssh_key_passphrase_env"`
+
+	// SSHKeyData is the private key read from ssh_key or ssh_key_env.
+	SSHKeyData []byte `yaml:"-"`
+}
+
+// GNMI contains the information required by junos_collector to collect metrics using gNMI.
+type GNMI struct {
+	Port int `yaml:"port"`
+	// Either get or subscribe, the RPC used to collect the state of paths, defaults to get.
+	Mode string `yaml:"mode"`
+	TLS  `yaml:",inline"`
+}
+
+// Vault contains the information required by junos_collector to fetch credentials from HashiCorp Vault.
+type Vault struct {
+	Address     string `yaml:"address"`
+	Namespace   string `yaml:"namespace"`
+	Token       string `yaml:"token"`
+	TokenFile   string `yaml:"token_file"`
+	TokenEnv    string `yaml:"token_env"`
+	SecretPath  string `yaml:"secret_path"`
+	SSHSignPath string `yaml:"ssh_sign_path"`
+	CacheTTL    int    `yaml:"cache_ttl"`
+}
+
+// TLS contains the TLS settings used by junos_collector to connect to a device.
+type TLS struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
 }
 
 // Global contains the global information required by junos_collector to create SSH based NETCONF connections.
 type Global struct {
-	AllowedTargets      []string `yaml:"allowed_targets"`
-	Timeout             int      `yaml:"timeout"`
-	InterfaceDescKeys   []string `yaml:"interface_description_keys"`
-	InterfaceMetricKeys []string `yaml:"interface_metric_keys"`
-	BGPTypeKeys         []string `yaml:"bgp_peer_type_keys"`
+	AllowedTargets       []string                `yaml:"allowed_targets"`
+	Timeout              int                     `yaml:"timeout"`
+	Port                 int                     `yaml:"port"`
+	ProxyURL             string                  `yaml:"proxy_url"`
+	RPCTimeout           int                     `yaml:"rpc_timeout"`
+	RPCRetries           int                     `yaml:"rpc_retries"`
+	RPCRetryBackoff      int                     `yaml:"rpc_retry_backoff"`
+	RPCParallelism       int                     `yaml:"rpc_parallelism"`
+	ScrapeCacheTTL       int                     `yaml:"scrape_cache_ttl"`
+	CollectorRPCTimeouts map[string]int          `yaml:"collector_rpc_timeouts"`
+	CollectorTimeout     int                     `yaml:"collector_timeout"`
+	CollectorTimeouts    map[string]int          `yaml:"collector_timeouts"`
+	InterfaceDescKeys    []string                `yaml:"interface_description_keys"`
+	InterfaceMetricKeys  []string                `yaml:"interface_metric_keys"`
+	InterfaceDescLabel   bool                    `yaml:"interface_description_label"`
+	InterfaceKeyLabels   bool                    `yaml:"interface_description_key_labels"`
+	InterfaceDetail      string                  `yaml:"interface_detail"`
+	InterfaceTypes       string                  `yaml:"interface_types"`
+	InterfaceUtilization bool                    `yaml:"interface_utilization"`
+	InterfaceInstances   bool                    `yaml:"interface_routing_instances"`
+	BGPTypeKeys          []string                `yaml:"bgp_peer_type_keys"`
+	REDiskHealth         bool                    `yaml:"route_engine_disk_health"`
+	BGPInstances         []string                `yaml:"bgp_instances"`
+	DisableBGPInstances  bool                    `yaml:"disable_bgp_instances"`
+	BGPMonitoredPeers    []string                `yaml:"bgp_monitored_peers"`
+	IpsecTunnelStats     bool                    `yaml:"ipsec_tunnel_statistics"`
+	HostKeyChecking      string                  `yaml:"host_key_checking"`
+	KnownHostsFile       string                  `yaml:"known_hosts_file"`
+	HostKeys             map[string]string       `yaml:"host_keys"`
+	SSHAlgorithms        SSHAlgorithms           `yaml:"ssh_algorithms"`
+	MetricFilters        map[string]MetricFilter `yaml:"metric_filters"`
+	StrictParsing        bool                    `yaml:"strict_parsing"`
+	DeviceTimestamps     bool                    `yaml:"device_timestamps"`
+	DeviceTimestampSkew  int                     `yaml:"device_timestamp_max_skew"`
+	PlatformDetection    bool                    `yaml:"platform_detection"`
+	Labels               map[string]string       `yaml:"labels"`
+
+	// AllowedTargetPatterns are the parsed allowed_targets.
+	AllowedTargetPatterns []TargetPattern `yaml:"-"`
 }
 
+// Polling contains the intervals in seconds at which the targets of a config are polled in the background.
+type Polling struct {
+	Interval           int            `yaml:"interval"`
+	CollectorIntervals map[string]int `yaml:"collector_intervals"`
+}
+
+// MetricFilter contains regular expressions matching the names of the metrics of a collector that are kept or dropped.
+type MetricFilter struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// Command contains an operational command run by the command collector and the metrics mapped from its XML output.
+type Command struct {
+	Command string          `yaml:"command"`
+	Metrics []CommandMetric `yaml:"metrics"`
+}
+
+// CommandMetric maps the elements of the XML output of a command to a metric.
+type CommandMetric struct {
+	Name   string            `yaml:"name"`
+	Help   string            `yaml:"help"`
+	Type   string            `yaml:"type"`
+	Items  string            `yaml:"items"`
+	Value  string            `yaml:"value"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// RouteFilter selects the routes counted by the route collector.
+type RouteFilter struct {
+	Name     string `yaml:"name"`
+	Table    string `yaml:"table"`
+	Prefix   string `yaml:"prefix"`
+	Exact    bool   `yaml:"exact"`
+	Protocol string `yaml:"protocol"`
+}
+
+// SSHAlgorithms contains the SSH algorithms offered to devices, Go's defaults are used for lists that are not set.
+type SSHAlgorithms struct {
+	Ciphers           []string `yaml:"ciphers"`
+	KeyExchanges      []string `yaml:"key_exchanges"`
+	MACs              []string `yaml:"macs"`
+	HostKeyAlgorithms []string `yaml:"host_key_algorithms"`
+}
+
+// envVarRegexp matches the ${VAR} references to environment variables in the values of the config file, and the $$
+// escapes of a literal $.
+var envVarRegexp = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 // LoadConfigFile returns a Configs type from a passed file.
 func LoadConfigFile(path string, collectors []string) (*Configuration, error) {
 	var configs Configuration
-
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("could not open config file %q: %v", path, err)
+	if err := readConfigFile(path, &configs); err != nil {
+		return nil, err
 	}
-	defer file.Close()
-
-	decoder := yaml.NewDecoder(file)
-	if err = decoder.Decode(&configs); err != nil {
-		return nil, fmt.Errorf("could not parse config file %q: %v", path, err)
+	if err := includeConfigFiles(filepath.Dir(path), &configs); err != nil {
+		return nil, err
 	}
 
 	if err := parseConfig(&configs, collectors); err != nil {
@@ -57,39 +278,790 @@ func LoadConfigFile(path string, collectors []string) (*Configuration, error) {
 	return &configs, nil
 }
 
+// readConfigFile decodes the config file at path into configs.
+func readConfigFile(path string, configs *Configuration) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not open config file %q: %v", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(buf))
+	if err = decoder.Decode(configs); err != nil {
+		return fmt.Errorf("could not parse config file %q: %v", path, err)
+	}
+	if err = expandEnv(reflect.ValueOf(configs).Elem()); err != nil {
+		return fmt.Errorf("could not parse config file %q: %v", path, err)
+	}
+	return nil
+}
+
+// includeConfigFiles merges the configs and targets of the files included by configs into configs. Included paths
+// are relative to dir, may be glob patterns, and directories include all their .yml and .yaml files.
+func includeConfigFiles(dir string, configs *Configuration) error {
+	var paths []string
+	for _, include := range configs.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+		matches, err := filepath.Glob(include)
+		if err != nil {
+			return fmt.Errorf("invalid include %q: %v", include, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("could not find included config file %q", include)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return fmt.Errorf("could not open config file %q: %v", match, err)
+			}
+			if !info.IsDir() {
+				paths = append(paths, match)
+				continue
+			}
+			entries, err := os.ReadDir(match)
+			if err != nil {
+				return fmt.Errorf("could not open config directory %q: %v", match, err)
+			}
+			for _, entry := range entries {
+				if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+					paths = append(paths, filepath.Join(match, entry.Name()))
+				}
+			}
+		}
+	}
+
+	for _, path := range paths {
+		var included Configuration
+		if err := readConfigFile(path, &included); err != nil {
+			return err
+		}
+		if len(included.Include) > 0 {
+			return fmt.Errorf("nested include in config file %q", path)
+		}
+		if !reflect.DeepEqual(included.Global, Global{}) {
+			return fmt.Errorf("global configuration in included config file %q", path)
+		}
+		if !reflect.DeepEqual(included.RemoteWrite, RemoteWrite{}) {
+			return fmt.Errorf("remote_write configuration in included config file %q", path)
+		}
+		if !reflect.DeepEqual(included.OTLP, OTLP{}) {
+			return fmt.Errorf("otlp configuration in included config file %q", path)
+		}
+		for name, configData := range included.Config {
+			if _, ok := configs.Config[name]; ok {
+				return fmt.Errorf("duplicate %q configuration in config file %q", name, path)
+			}
+			if configs.Config == nil {
+				configs.Config = map[string]Config{}
+			}
+			configs.Config[name] = configData
+		}
+		for target, targetData := range included.Targets {
+			if _, ok := configs.Targets[target]; ok {
+				return fmt.Errorf("duplicate target %q in config file %q", target, path)
+			}
+			if configs.Targets == nil {
+				configs.Targets = map[string]Target{}
+			}
+			configs.Targets[target] = targetData
+		}
+		for name, module := range included.Modules {
+			if _, ok := configs.Modules[name]; ok {
+				return fmt.Errorf("duplicate %q module in config file %q", name, path)
+			}
+			if configs.Modules == nil {
+				configs.Modules = map[string]Module{}
+			}
+			configs.Modules[name] = module
+		}
+	}
+	return nil
+}
+
+// expandEnv replaces the ${VAR} references in the string values decoded into v with the value of the environment
+// variable, failing on references to variables that are not set. Values are expanded once decoded, so that the value
+// of a variable is never parsed as YAML. $$ is replaced with a single $, such as in $${VAR} for a literal ${VAR}.
+func expandEnv(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		value, err := expandEnvString(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(value)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return expandEnv(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := expandEnv(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandEnv(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values are not addressable, each is expanded in a copy that replaces it. Keys, such as the names of
+		// configs and targets, are not expanded.
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			if err := expandEnv(value); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+	return nil
+}
+
+// expandEnvString replaces the ${VAR} references and $$ escapes in s.
+func expandEnvString(s string) (string, error) {
+	var err error
+	s = envVarRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+		name := envVarRegexp.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %q is not set", name)
+		}
+		return value
+	})
+	return s, err
+}
+
 func parseConfig(configuration *Configuration, validCollectors []string) error {
+	if err := parseRemoteWrite(configuration); err != nil {
+		return err
+	}
+	if err := parseOTLP(configuration); err != nil {
+		return err
+	}
+	if err := parseHostKeyChecking(configuration.Global.HostKeyChecking, configuration.Global.KnownHostsFile); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if err := parseMetricFilters(configuration.Global.MetricFilters, validCollectors); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if err := parseCollectorTimeouts("collector_rpc_timeouts", configuration.Global.CollectorRPCTimeouts, validCollectors); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if err := parseCollectorTimeouts("collector_timeouts", configuration.Global.CollectorTimeouts, validCollectors); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if configuration.Global.CollectorTimeout < 0 {
+		return fmt.Errorf("collector_timeout must not be negative in global configuration")
+	}
+	if err := parseInterfaceDetail(configuration.Global.InterfaceDetail); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if err := parseInterfaceTypes(configuration.Global.InterfaceTypes); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if configuration.Global.Port < 0 || configuration.Global.Port > 65535 {
+		return fmt.Errorf("invalid port %d in global configuration", configuration.Global.Port)
+	}
+	if _, err := ParseProxyURL(configuration.Global.ProxyURL); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	if err := parseLabels(configuration.Global.Labels); err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	for target, targetData := range configuration.Targets {
+		if err := parseLabels(targetData.Labels); err != nil {
+			return fmt.Errorf("%s of target %q", err, target)
+		}
+		if targetData.MinScrapeInterval < 0 {
+			return fmt.Errorf("min_scrape_interval must not be negative of target %q", target)
+		}
+	}
+	for name, module := range configuration.Modules {
+		if err := parseModule(module, validCollectors); err != nil {
+			return fmt.Errorf("%s in %q module", err, name)
+		}
+	}
+	patterns, err := parseTargetPatterns(configuration.Global.AllowedTargets)
+	if err != nil {
+		return fmt.Errorf("%s in global configuration", err)
+	}
+	configuration.Global.AllowedTargetPatterns = patterns
 	for name, configData := range configuration.Config {
-		if configData.Username == "" {
-			return fmt.Errorf("missing username in %q configuration", name)
+		if err := resolveCredentials(&configData); err != nil {
+			return fmt.Errorf("%v in %q configuration", err, name)
+		}
+		if configData.AllowedTargetPatterns, err = parseTargetPatterns(configData.AllowedTargets); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
 		}
+		configuration.Config[name] = configData
+
+		if configData.Transport == "tls" {
+			if configData.TLS.CertFile == "" || configData.TLS.KeyFile == "" {
+				return fmt.Errorf("missing tls cert_file or key_file in %q configuration", name)
+			}
+		} else if configData.Transport == "replay" {
+			if configData.ReplayDir == "" {
+				return fmt.Errorf("missing replay_dir in %q configuration", name)
+			}
+		} else if configData.Vault.Address != "" {
+			if configData.Vault.SecretPath == "" && configData.Vault.SSHSignPath == "" {
+				return fmt.Errorf("missing vault secret_path or ssh_sign_path in %q configuration", name)
+			}
+			if configData.Vault.SSHSignPath != "" && len(configData.SSHKeyData) == 0 {
+				return fmt.Errorf("vault ssh_sign_path requires ssh_key in %q configuration", name)
+			}
+			if configData.Vault.SecretPath == "" && configData.Username == "" {
+				return fmt.Errorf("missing username in %q configuration", name)
+			}
+		} else {
+			for _, credential := range configData.SSHCredentials() {
+				if credential.Username == "" {
+					return fmt.Errorf("missing username in %q configuration", name)
+				}
 
-		if configData.Password == "" && configData.SSHKey == "" {
-			return fmt.Errorf("missing password or ssh_key in %q configuration", name)
+				if credential.Password == "" && len(credential.SSHKeyData) == 0 {
+					return fmt.Errorf("missing password or ssh_key in %q configuration", name)
+				}
+			}
 		}
 
 		if len(configData.Collectors) == 0 {
 			return fmt.Errorf("no collectors enabled in %q configuration", name)
 		}
 
-		if configData.SSHKey != "" {
-			buf, err := os.ReadFile(configData.SSHKey)
-			if err != nil {
-				return fmt.Errorf("could not open ssh_key %q in %q configuration: %v", configData.SSHKey, name, err)
-			}
-			_, err = ssh.ParsePrivateKey(buf)
-			if err != nil {
-				return fmt.Errorf("invalid ssh_key %q in %q configuration: %v", configData.SSHKey, name, err)
+		knownHostsFile := configData.KnownHostsFile
+		if knownHostsFile == "" {
+			knownHostsFile = configuration.Global.KnownHostsFile
+		}
+		if err := parseHostKeyChecking(configData.HostKeyChecking, knownHostsFile); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+
+		if err := parseMetricFilters(configData.MetricFilters, validCollectors); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if err := parseCollectorTimeouts("collector_rpc_timeouts", configData.CollectorRPCTimeouts, validCollectors); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if err := parseCollectorTimeouts("collector_timeouts", configData.CollectorTimeouts, validCollectors); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if configData.CollectorTimeout < 0 {
+			return fmt.Errorf("collector_timeout must not be negative in %q configuration", name)
+		}
+		if err := parseInterfaceDetail(configData.InterfaceDetail); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if err := parseInterfaceTypes(configData.InterfaceTypes); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if err := parseLabels(configData.Labels); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if err := parseCommands(configData.Commands, configData.Collectors); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if err := parseRouteFilters(configData.RouteFilters, configData.Collectors); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+		if configData.Port < 0 || configData.Port > 65535 {
+			return fmt.Errorf("invalid port %d in %q configuration", configData.Port, name)
+		}
+		if _, err := ParseProxyURL(configData.ProxyURL); err != nil {
+			return fmt.Errorf("%s in %q configuration", err, name)
+		}
+
+		if configData.Polling.Interval > 0 {
+			if len(configData.AllowedTargets) == 0 && len(configuration.Global.AllowedTargets) == 0 {
+				return fmt.Errorf("polling requires allowed_targets in %q configuration", name)
 			}
-			for _, collector := range configData.Collectors {
-				for _, validCollector := range validCollectors {
-					if collector == validCollector {
-						goto CollectorFound
+			for collector, interval := range configData.Polling.CollectorIntervals {
+				for _, enabledCollector := range configData.Collectors {
+					if collector == enabledCollector {
+						goto PollingCollectorFound
 					}
 				}
-				return fmt.Errorf("invalid collector %q in %q configuration", collector, name)
+				return fmt.Errorf("collector %q of polling collector_intervals is not enabled in %q configuration", collector, name)
+			PollingCollectorFound:
+				if interval <= 0 {
+					return fmt.Errorf("invalid polling interval of collector %q in %q configuration", collector, name)
+				}
+			}
+		} else if len(configData.Polling.CollectorIntervals) > 0 {
+			return fmt.Errorf("polling collector_intervals requires a polling interval in %q configuration", name)
+		}
+
+		switch configData.Transport {
+		case "", "netconf", "tls", "replay":
+		case "gnmi":
+			if (configData.GNMI.CertFile == "") != (configData.GNMI.KeyFile == "") {
+				return fmt.Errorf("gnmi cert_file and key_file must be set together in %q configuration", name)
+			}
+			if m := configData.GNMI.Mode; m != "" && m != "get" && m != "subscribe" {
+				return fmt.Errorf("invalid gnmi mode %q in %q configuration", m, name)
+			}
+		default:
+			return fmt.Errorf("invalid transport %q in %q configuration", configData.Transport, name)
+		}
+
+		for _, credential := range configData.SSHCredentials() {
+			if len(credential.SSHKeyData) > 0 {
+				_, err := ParsePrivateKey(credential)
+				if err != nil {
+					return fmt.Errorf("invalid ssh_key in %q configuration: %v", name, err)
+				}
+			}
+		}
+		for _, collector := range configData.Collectors {
+			valid := false
+			for _, validCollector := range validCollectors {
+				if collector == validCollector {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return fmt.Errorf("invalid collector %q in %q configuration, valid collectors are: %s", collector, name, strings.Join(validCollectors, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCredentials reads the Vault token and the passwords and SSH keys of c from the files and environment variables
+// they reference.
+func resolveCredentials(c *Config) error {
+	token, err := readSecret(c.Vault.Token, c.Vault.TokenFile, c.Vault.TokenEnv)
+	if err != nil {
+		return fmt.Errorf("could not read vault token: %v", err)
+	}
+	c.Vault.Token = token
+
+	if err := resolveCredential(&c.Credential); err != nil {
+		return err
+	}
+	credentials := make([]Credential, len(c.Credentials))
+	for i, credential := range c.Credentials {
+		if err := resolveCredential(&credential); err != nil {
+			return fmt.Errorf("%v of credentials %d", err, i+1)
+		}
+		credentials[i] = credential
+	}
+	c.Credentials = credentials
+	return nil
+}
+
+// SSHCredentials returns the credentials tried in order to authenticate to a device, the username, password and
+// ssh_key of c when set followed by its credentials.
+func (c Config) SSHCredentials() []Credential {
+	if c.Username == "" && len(c.Credentials) > 0 {
+		return c.Credentials
+	}
+	return append([]Credential{c.Credential}, c.Credentials...)
+}
+
+// resolveCredential reads the password and SSH key of c from the files and environment variables it references.
+func resolveCredential(c *Credential) error {
+	password, err := readSecret(c.Password, c.PasswordFile, c.PasswordEnv)
+	if err != nil {
+		return fmt.Errorf("could not read password: %v", err)
+	}
+	c.Password = password
+
+	passphrase, err := readSecret(c.SSHKeyPassphrase, c.SSHKeyPassphraseFile, c.SSHKeyPassphraseEnv)
+	if err != nil {
+		return fmt.Errorf("could not read ssh_key_passphrase: %v", err)
+	}
+	c.SSHKeyPassphrase = passphrase
+
+	switch {
+	case c.SSHKeyEnv != "":
+		key, ok := os.LookupEnv(c.SSHKeyEnv)
+		if !ok {
+			return fmt.Errorf("environment variable %q of ssh_key_env is not set", c.SSHKeyEnv)
+		}
+		c.SSHKeyData = []byte(key)
+	case c.SSHKey != "":
+		buf, err := os.ReadFile(c.SSHKey)
+		if err != nil {
+			return fmt.Errorf("could not open ssh_key %q: %v", c.SSHKey, err)
+		}
+		c.SSHKeyData = buf
+	}
+	return nil
+}
+
+// ParsePrivateKey parses the SSH key of c, decrypting it with the configured passphrase when set.
+func ParsePrivateKey(c Credential) (ssh.Signer, error) {
+	if c.SSHKeyPassphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(c.SSHKeyData, []byte(c.SSHKeyPassphrase))
+	}
+	signer, err := ssh.ParsePrivateKey(c.SSHKeyData)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return nil, fmt.Errorf("ssh_key is encrypted but no ssh_key_passphrase is configured")
+	}
+	return signer, err
+}
+
+// readSecret returns the value of the environment variable env or the contents of file if either is set, otherwise
+// value.
+func readSecret(value string, file string, env string) (string, error) {
+	switch {
+	case env != "":
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set", env)
+		}
+		return v, nil
+	case file != "":
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(buf), "\r\n"), nil
+	}
+	return value, nil
+}
+
+func parseMetricFilters(filters map[string]MetricFilter, validCollectors []string) error {
+	for collector, filter := range filters {
+		for _, validCollector := range validCollectors {
+			if collector == validCollector {
+				goto CollectorFound
+			}
+		}
+		return fmt.Errorf("invalid collector %q in metric_filters", collector)
+	CollectorFound:
+		for _, expr := range append(append([]string{}, filter.Allow...), filter.Deny...) {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("invalid metric_filters expression %q of collector %q: %s", expr, collector, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseModule validates the collectors and metric filters of a module.
+func parseModule(module Module, validCollectors []string) error {
+	if len(module.Collectors) == 0 {
+		return fmt.Errorf("no collectors enabled")
+	}
+	for _, collector := range module.Collectors {
+		for _, validCollector := range validCollectors {
+			if collector == validCollector {
+				goto CollectorFound
+			}
+		}
+		return fmt.Errorf("invalid collector %q, valid collectors are: %s", collector, strings.Join(validCollectors, ", "))
+	CollectorFound:
+	}
+	return parseMetricFilters(module.MetricFilters, validCollectors)
+}
+
+// parseCollectorTimeouts validates the timeouts per collector of the setting key.
+func parseCollectorTimeouts(key string, timeouts map[string]int, validCollectors []string) error {
+	for collector, timeout := range timeouts {
+		for _, validCollector := range validCollectors {
+			if collector == validCollector {
+				goto CollectorFound
+			}
+		}
+		return fmt.Errorf("invalid collector %q in %s", collector, key)
+	CollectorFound:
+		if timeout <= 0 {
+			return fmt.Errorf("invalid %s timeout of collector %q", key, collector)
+		}
+	}
+	return nil
+}
+
+// ParseProxyURL parses the URL of a SOCKS5 or HTTP proxy, returning nil when proxyURL is empty.
+func ParseProxyURL(proxyURL string) (*url.URL, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy_url: %v", err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("invalid proxy_url scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host in proxy_url")
+	}
+	return u, nil
+}
+
+func parseInterfaceDetail(detail string) error {
+	switch detail {
+	case "", "statistics", "detail", "extensive":
+	default:
+		return fmt.Errorf("invalid interface_detail %q", detail)
+	}
+	return nil
+}
+
+// parseRemoteWrite validates the remote_write configuration and sets the defaults of the settings that are not set.
+func parseRemoteWrite(configuration *Configuration) error {
+	rw := &configuration.RemoteWrite
+	if rw.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(rw.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid remote_write url %q", rw.URL)
+	}
+	if rw.Interval < 0 || rw.Timeout < 0 {
+		return fmt.Errorf("remote_write interval and timeout must not be negative")
+	}
+	if rw.Interval == 0 {
+		rw.Interval = 30
+	}
+	if rw.Timeout == 0 {
+		rw.Timeout = 30
+	}
+	if rw.Job == "" {
+		rw.Job = "junos"
+	}
+	if rw.Username != "" && rw.BearerToken != "" {
+		return fmt.Errorf("remote_write username and bearer_token must not be set together")
+	}
+	if !pollingEnabled(configuration) {
+		return fmt.Errorf("remote_write requires polling in at least one configuration")
+	}
+	return nil
+}
+
+// parseOTLP validates the otlp configuration and sets the defaults of the settings that are not set.
+func parseOTLP(configuration *Configuration) error {
+	o := &configuration.OTLP
+	if o.Endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(o.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid otlp endpoint %q", o.Endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/metrics"
+		o.Endpoint = u.String()
+	}
+	if o.Interval < 0 || o.Timeout < 0 {
+		return fmt.Errorf("otlp interval and timeout must not be negative")
+	}
+	if o.Interval == 0 {
+		o.Interval = 30
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 30
+	}
+	if !pollingEnabled(configuration) {
+		return fmt.Errorf("otlp requires polling in at least one configuration")
+	}
+	return nil
+}
+
+// pollingEnabled returns whether any config polls its targets in the background.
+func pollingEnabled(configuration *Configuration) bool {
+	for _, configData := range configuration.Config {
+		if configData.Polling.Interval > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInterfaceTypes(types string) error {
+	switch types {
+	case "", "all", "physical", "logical":
+	default:
+		return fmt.Errorf("invalid interface_types %q", types)
+	}
+	return nil
+}
+
+// TargetPattern matches the targets allowed by an allowed_targets entry, which is either a target, a CIDR range such as
+// 192.0.2.0/24, a wildcard such as *.example.net, or a regular expression prefixed with ~.
+type TargetPattern struct {
+	target string
+	prefix *net.IPNet
+	re     *regexp.Regexp
+}
+
+func parseTargetPatterns(targets []string) ([]TargetPattern, error) {
+	var patterns []TargetPattern
+	for _, target := range targets {
+		pattern, err := parseTargetPattern(target)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func parseTargetPattern(target string) (TargetPattern, error) {
+	if !IsTargetPattern(target) {
+		return TargetPattern{target: target}, nil
+	}
+	if strings.HasPrefix(target, "~") {
+		re, err := regexp.Compile("^(?:" + target[1:] + ")$")
+		if err != nil {
+			return TargetPattern{}, fmt.Errorf("invalid allowed_targets expression %q: %s", target, err)
+		}
+		return TargetPattern{re: re}, nil
+	}
+	if strings.Contains(target, "/") {
+		_, prefix, err := net.ParseCIDR(target)
+		if err != nil {
+			return TargetPattern{}, fmt.Errorf("invalid allowed_targets range %q: %s", target, err)
+		}
+		return TargetPattern{prefix: prefix}, nil
+	}
+	re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(target), `\*`, `[^.:]*`) + "$")
+	return TargetPattern{re: re}, nil
+}
+
+// IsTargetPattern returns whether an allowed_targets entry matches several targets rather than being a target.
+func IsTargetPattern(target string) bool {
+	return strings.HasPrefix(target, "~") || strings.Contains(target, "/") || strings.Contains(target, "*")
+}
+
+// Match returns whether target is allowed by p. CIDR ranges match targets with an IP address within the range,
+// regardless of the port of the target.
+func (p TargetPattern) Match(target string) bool {
+	switch {
+	case p.prefix != nil:
+		host := target
+		if h, _, err := net.SplitHostPort(target); err == nil {
+			host = h
+		}
+		ip := net.ParseIP(strings.Trim(host, "[]"))
+		return ip != nil && p.prefix.Contains(ip)
+	case p.re != nil:
+		return p.re.MatchString(target)
+	}
+	return p.target == target
+}
+
+// parseCommands validates the commands of the command collector, which requires at least one command when enabled.
+func parseCommands(commands []Command, collectors []string) error {
+	enabled := false
+	for _, collector := range collectors {
+		if collector == "command" {
+			enabled = true
+		}
+	}
+	if !enabled {
+		if len(commands) > 0 {
+			return fmt.Errorf("commands require the command collector")
+		}
+		return nil
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("no commands for the command collector")
+	}
+
+	names := map[string]bool{}
+	for _, command := range commands {
+		if strings.TrimSpace(command.Command) == "" {
+			return fmt.Errorf("missing command of commands")
+		}
+		if len(command.Metrics) == 0 {
+			return fmt.Errorf("no metrics of command %q", command.Command)
+		}
+		for _, metric := range command.Metrics {
+			if metric.Name == "" || !model.IsValidMetricName(model.LabelValue("junos_command_"+metric.Name)) {
+				return fmt.Errorf("invalid metric name %q of command %q", metric.Name, command.Command)
+			}
+			if names[metric.Name] {
+				return fmt.Errorf("duplicate metric name %q of commands", metric.Name)
+			}
+			names[metric.Name] = true
+			switch metric.Type {
+			case "", "gauge", "counter":
+			default:
+				return fmt.Errorf("invalid type %q of metric %q", metric.Type, metric.Name)
+			}
+			if metric.Items == "" || metric.Value == "" {
+				return fmt.Errorf("missing items or value of metric %q", metric.Name)
+			}
+			for label := range metric.Labels {
+				if !model.LabelName(label).IsValid() || strings.HasPrefix(label, "__") {
+					return fmt.Errorf("invalid label %q of metric %q", label, metric.Name)
+				}
 			}
-		CollectorFound:
 		}
 	}
 	return nil
 }
+
+// parseRouteFilters validates the route filters of the route collector.
+func parseRouteFilters(filters []RouteFilter, collectors []string) error {
+	if len(filters) == 0 {
+		return nil
+	}
+	enabled := false
+	for _, collector := range collectors {
+		if collector == "route" {
+			enabled = true
+		}
+	}
+	if !enabled {
+		return fmt.Errorf("route_filters require the route collector")
+	}
+
+	names := map[string]bool{}
+	for _, filter := range filters {
+		if strings.TrimSpace(filter.Name) == "" {
+			return fmt.Errorf("missing name of route_filters")
+		}
+		if names[filter.Name] {
+			return fmt.Errorf("duplicate name %q of route_filters", filter.Name)
+		}
+		names[filter.Name] = true
+		if filter.Exact && filter.Prefix == "" {
+			return fmt.Errorf("exact without prefix of route filter %q", filter.Name)
+		}
+		if filter.Table == "" && filter.Prefix == "" && filter.Protocol == "" {
+			return fmt.Errorf("missing table, prefix or protocol of route filter %q", filter.Name)
+		}
+	}
+	return nil
+}
+
+func parseLabels(labels map[string]string) error {
+	for label := range labels {
+		if !model.LabelName(label).IsValid() || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("invalid label %q", label)
+		}
+	}
+	return nil
+}
+
+func parseHostKeyChecking(mode string, knownHostsFile string) error {
+	switch mode {
+	case "", "insecure", "strict":
+	case "accept-new":
+		if knownHostsFile == "" {
+			return fmt.Errorf("host_key_checking accept-new requires known_hosts_file")
+		}
+	default:
+		return fmt.Errorf("invalid host_key_checking %q", mode)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFileExpandsEnv(t *testing.T) {
+	t.Setenv("JUNOS_USERNAME", "exporter")
+	t.Setenv("JUNOS_PASSWORD", `p#ss: "word"`)
+	path := writeConfigFile(t, `
+configs:
+  default:
+    # ${JUNOS_UNSET} in a comment is not expanded.
+    username: ${JUNOS_USERNAME}
+    password: ${JUNOS_PASSWORD}
+    enabled_collectors: [interface]
+    allowed_targets: ['~^router[0-9]+$', 'literal-$${JUNOS_USERNAME}']
+`)
+
+	conf, err := LoadConfigFile(path, []string{"interface"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := conf.Config["default"]
+	if c.Username != "exporter" {
+		t.Errorf("username = %q, want %q", c.Username, "exporter")
+	}
+	if c.Password != `p#ss: "word"` {
+		t.Errorf("password = %q, want %q", c.Password, `p#ss: "word"`)
+	}
+	want := []string{"~^router[0-9]+$", "literal-${JUNOS_USERNAME}"}
+	if strings.Join(c.AllowedTargets, ",") != strings.Join(want, ",") {
+		t.Errorf("allowed_targets = %q, want %q", c.AllowedTargets, want)
+	}
+}
+
+func TestLoadConfigFileUnsetEnv(t *testing.T) {
+	path := writeConfigFile(t, `
+configs:
+  default:
+    username: ${JUNOS_UNSET_VARIABLE}
+    enabled_collectors: [interface]
+`)
+
+	_, err := LoadConfigFile(path, []string{"interface"})
+	if err == nil || !strings.Contains(err.Error(), `"JUNOS_UNSET_VARIABLE" is not set`) {
+		t.Errorf("err = %v, want unset variable error", err)
+	}
+}
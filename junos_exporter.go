@@ -1,16 +1,34 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
 	inbuiltLog "log"
+	"net"
 	"net/http"
+	"net/http/pprof"
+	"net/url"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	promcollectors "github.com/prometheus/client_golang/prometheus/collectors"
 	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/promlog"
@@ -21,12 +39,43 @@ import (
 	"github.com/tynany/junos_exporter/collector"
 	"github.com/tynany/junos_exporter/config"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 var (
-	telemetryPath = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
-	configPath    = kingpin.Flag("config.path", "Path of the YAML configuration file.").Required().String()
-	webFlagConfig = kingpinflag.AddFlags(kingpin.CommandLine, ":9347")
+	telemetryPath  = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+	webLifecycle   = kingpin.Flag("web.enable-lifecycle", "Enable reloading the configuration using HTTP POST or PUT requests to /-/reload.").Default("false").Bool()
+	configPath     = kingpin.Flag("config.path", "Path of the YAML configuration file.").Required().String()
+	sshMaxIdle     = kingpin.Flag("ssh.max-idle-time", "How long an unused NETCONF session is kept open for reuse by later scrapes, 0 closes sessions after every scrape.").Default("5m").Duration()
+	sshKeepalive   = kingpin.Flag("ssh.keepalive-interval", "Interval between SSH keepalives sent on idle NETCONF sessions, 0 disables keepalives.").Default("30s").Duration()
+	sshMaxMissed   = kingpin.Flag("ssh.keepalive-max-missed", "Number of consecutive SSH keepalives not answered within --ssh.keepalive-interval after which an idle NETCONF session is closed.").Default("3").Int()
+	failureLimit   = kingpin.Flag("target.failure-threshold", "Consecutive connection failures after which connections to a target are paused for --target.cooldown, 0 disables pausing.").Default("3").Int()
+	cooldown       = kingpin.Flag("target.cooldown", "How long connections to a target are paused for after --target.failure-threshold consecutive connection failures.").Default("1m").Duration()
+	staleAfter     = kingpin.Flag("target.stale-after", "Duration after which the series kept across scrapes of a target that is no longer scraped, such as its error counters, are removed. 0 keeps them until the exporter restarts.").Default("1h").Duration()
+	sshMaxSessions = kingpin.Flag("ssh.max-sessions", "Maximum number of NETCONF sessions open at the same time across all targets, new sessions wait for a slot. 0 means no limit.").Default("0").Int()
+	debugRPC       = kingpin.Flag("debug.enable-rpc-dump", "Enable the /debug/rpc endpoint returning the raw NETCONF replies of the collectors of a target.").Default("false").Bool()
+	debugPprof     = kingpin.Flag("debug.enable-pprof", "Enable the /debug/pprof endpoints for profiling the exporter.").Default("false").Bool()
+	listCollectors = kingpin.Flag("collectors.list", "List the available collectors, their RPCs and the configs enabling them, and exit.").Default("false").Bool()
+	captureDir     = kingpin.Flag("capture.dir", "Directory to which the RPC replies of a collector that failed to parse are written, with secrets redacted, disabled when empty.").Default("").String()
+	captureMax     = kingpin.Flag("capture.max-bytes", "Size in bytes after which captured RPC replies are truncated, 0 means no limit.").Default("1048576").Int()
+	metricNaming   = kingpin.Flag("metrics.naming", "Naming scheme of the exported metrics, legacy or prometheus, which adds units and _total suffixes following the Prometheus naming conventions.").Default("legacy").Enum("legacy", "prometheus")
+	runtimeMetrics = kingpin.Flag("debug.runtime-metrics", "Export all Go runtime metrics, including detailed garbage collector and memory metrics.").Default("false").Bool()
+	maxScrapes     = kingpin.Flag("max-concurrent-scrapes", "Maximum number of targets scraped at the same time, further scrapes wait for a slot. 0 means no limit.").Default("0").Int()
+	timeoutOffset  = kingpin.Flag("scrape-timeout-offset", "Offset subtracted from the timeout of Prometheus scrapes, set by the X-Prometheus-Scrape-Timeout-Seconds header, by which the collectors must complete.").Default("500ms").Duration()
+	maxQueueWait   = kingpin.Flag("scrape-queue.max-wait", "Maximum time a scrape waits for a slot when --max-concurrent-scrapes is reached, 0 waits until the scrape is canceled.").Default("0").Duration()
+	jtiAddress     = kingpin.Flag("jti.listen-address", "UDP address on which to receive Junos native streaming telemetry, disabled when empty.").Default("").String()
+	jtiPath        = kingpin.Flag("jti.telemetry-path", "Path under which to expose metrics received using streaming telemetry.").Default("/jti").String()
+	jtiStaleAfter  = kingpin.Flag("jti.stale-after", "Duration after which interfaces no longer reported using streaming telemetry are removed.").Default("5m").Duration()
+	webFlagConfig  = kingpinflag.AddFlags(kingpin.CommandLine, ":9347")
+
+	_               = kingpin.Command("serve", "Serve metrics over HTTP.").Default()
+	scrapeCmd       = kingpin.Command("scrape", "Scrape a target once, print its metrics to stdout and exit.")
+	scrapeConfig    = scrapeCmd.Flag("config", "Name of the config to scrape the target with.").Required().String()
+	scrapeTarget    = scrapeCmd.Flag("target", "Target to scrape.").Required().String()
+	scrapeCollector = scrapeCmd.Flag("collector", "Collector to run instead of the collectors of the config, can be repeated or comma separated.").Strings()
+
+	// Whether the scrape command is run instead of serving metrics.
+	scrapeOnly bool
 
 	// Slice of all configs.
 	collectors = []collector.Collector{}
@@ -34,15 +83,77 @@ var (
 	// Map of client SSH configuration (value) per config as specified in the config file (key).
 	exporterSSHConfig = map[string]*ssh.ClientConfig{}
 
+	// Map of client SSH configurations (value) tried in order when authenticating with the SSH configuration of a config
+	// fails, per config (key).
+	exporterSSHFallbackConfigs = map[string][]*ssh.ClientConfig{}
+
+	// Map of Vault clients (value) per config fetching credentials from Vault as specified in the config file (key).
+	vaultClients = map[string]*vaultClient{}
+
+	// Map of NETCONF over TLS configuration (value) per config using the tls transport as specified in the config file (key).
+	exporterTLSConfig = map[string]*tls.Config{}
+
+	// Map of gNMI clients (value) per config using the gnmi transport as specified in the config file (key).
+	gnmiClients = map[string]*collector.GNMIClient{}
+
+	// Pool of NETCONF sessions shared across scrapes.
+	connections *collector.ConnectionManager
+
+	// Limits the number of concurrent scrapes when --max-concurrent-scrapes is set.
+	scrapeSlots *scrapeQueue
+
+	// Per-target locks so overlapping scrapes of the same target run one after another.
+	targetLocks   = map[string]*sync.Mutex{}
+	targetLocksMu sync.Mutex
+
 	// Globally accessible configuration loaded from the config file.
 	collectorConfig *config.Configuration
 
+	// Guards the configuration and the maps generated from it, which are replaced when the config file is reloaded.
+	configMu sync.RWMutex
+	// Time /-/healthy waits to read the configuration before reporting the exporter unhealthy.
+	healthTimeout = 5 * time.Second
+
 	interfaceDescriptionKeys = map[string][]string{}
 	interfaceMetricKeys      = map[string][]string{}
+	interfaceDetails         = map[string]string{}
+	interfaceTypes           = map[string]string{}
+	interfaceUtilization     = map[string]bool{}
+	interfaceInstances       = map[string]bool{}
+	interfaceDescLabels      = map[string]bool{}
+	interfaceKeyLabels       = map[string]bool{}
 	bgpTypeKeys              = map[string][]string{}
+	reDiskHealth             = map[string]bool{}
+	bgpInstances             = map[string][]string{}
+	disableBGPInstances      = map[string]bool{}
+	bgpMonitoredPeers        = map[string][]string{}
+	ipsecTunnelStats         = map[string]bool{}
+	rpcTimeouts              = map[string]time.Duration{}
+	metricFilters            = map[string]map[string]*collector.MetricFilter{}
+	moduleFilters            = map[string]map[string]*collector.MetricFilter{}
+	strictParsing            = map[string]bool{}
+	commands                 = map[string][]collector.Command{}
+	routeFilters             = map[string][]collector.RouteFilter{}
+	deviceTimestamps         = map[string]bool{}
+	deviceTimestampSkews     = map[string]time.Duration{}
+	platformDetection        = map[string]bool{}
+	scrapeCacheTTLs          = map[string]time.Duration{}
+	collectorRPCTimeouts     = map[string]map[string]time.Duration{}
+	collectorTimeout         = map[string]time.Duration{}
+	collectorTimeouts        = map[string]map[string]time.Duration{}
+	rpcRetries               = map[string]int{}
+	rpcRetryBackoffs         = map[string]time.Duration{}
+	rpcParallelism           = map[string]int{}
+	ports                    = map[string]int{}
+	proxyURLs                = map[string]*url.URL{}
+	replayDirs               = map[string]string{}
+	configLabels             = map[string]map[string]string{}
+
+	// Map of background pollers (value) per config and target polled in the background (key).
+	pollers = map[pollerKey]*poller{}
 )
 
-func initCollectors(logger log.Logger) {
+func initCollectors(logger log.Logger) error {
 	collectors = append(collectors, collector.NewInterfaceCollector(logger))
 	collectors = append(collectors, collector.NewBGPCollector(logger))
 	collectors = append(collectors, collector.NewEnvCollector(logger))
@@ -52,6 +163,28 @@ func initCollectors(logger log.Logger) {
 	collectors = append(collectors, collector.NewOpticsCollector(logger))
 	collectors = append(collectors, collector.NewOSPFCollector(logger))
 	collectors = append(collectors, collector.NewFPCCollector(logger))
+	collectors = append(collectors, collector.NewCommandCollector(logger))
+	collectors = append(collectors, collector.NewNSRCollector(logger))
+	collectors = append(collectors, collector.NewQueuesCollector(logger))
+	collectors = append(collectors, collector.NewFlowCollector(logger))
+	collectors = append(collectors, collector.NewRouteCollector(logger))
+	collectors = append(collectors, collector.NewUFDCollector(logger))
+	collectors = append(collectors, collector.NewPowerBudgetCollector(logger))
+	collectors = append(collectors, collector.NewClockCollector(logger))
+
+	names := map[string]bool{}
+	for _, c := range collectors {
+		names[c.Name()] = true
+	}
+	// Collectors compiled in from other packages, which must not reuse the name of another collector.
+	for _, c := range collector.Plugins(logger) {
+		if names[c.Name()] {
+			return fmt.Errorf("duplicate collector %q", c.Name())
+		}
+		names[c.Name()] = true
+		collectors = append(collectors, c)
+	}
+	return nil
 }
 
 func validateRequest(configParam string, targetParam string) error {
@@ -70,16 +203,16 @@ ConfigFound:
 		return fmt.Errorf("'target' parameter must be specified")
 	}
 	if len(collectorConfig.Config[configParam].AllowedTargets) > 0 {
-		for _, target := range collectorConfig.Config[configParam].AllowedTargets {
-			if targetParam == target {
+		for _, pattern := range collectorConfig.Config[configParam].AllowedTargetPatterns {
+			if pattern.Match(targetParam) {
 				goto TargetFound
 			}
 		}
 		return fmt.Errorf("allowed_targets is defined under %q configuration but %q is not listed", configParam, targetParam)
 	}
 	if len(collectorConfig.Global.AllowedTargets) > 0 {
-		for _, target := range collectorConfig.Global.AllowedTargets {
-			if targetParam == target {
+		for _, pattern := range collectorConfig.Global.AllowedTargetPatterns {
+			if pattern.Match(targetParam) {
 				goto TargetFound
 			}
 		}
@@ -89,39 +222,287 @@ TargetFound:
 	return nil
 }
 
+// selectCollectors returns the collectors of enabledCollectors listed in the 'collectors' parameter, which is either
+// repeated or a comma separated list.
+func selectCollectors(enabledCollectors []collector.Collector, collectorsParam []string) ([]collector.Collector, error) {
+	var selected []collector.Collector
+	for _, param := range collectorsParam {
+		for _, name := range strings.Split(param, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			for _, c := range enabledCollectors {
+				if c.Name() == name {
+					selected = append(selected, c)
+					goto CollectorFound
+				}
+			}
+			return nil, fmt.Errorf("collector %q is not enabled in the configuration", name)
+		CollectorFound:
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("'collectors' parameter must list at least one collector")
+	}
+	return selected, nil
+}
+
+// configCollectors returns the collectors enabled in the config. configMu must be held.
+func configCollectors(configName string) []collector.Collector {
+	enabledCollectors := []collector.Collector{}
+	for _, collector := range collectors {
+		for _, col := range collectorConfig.Config[configName].Collectors {
+			if collector.Name() == col {
+				enabledCollectors = append(enabledCollectors, collector)
+			}
+		}
+	}
+	return enabledCollectors
+}
+
+// moduleCollectors returns the collectors enabled in the module. configMu must be held.
+func moduleCollectors(moduleName string) []collector.Collector {
+	enabledCollectors := []collector.Collector{}
+	for _, collector := range collectors {
+		for _, col := range collectorConfig.Modules[moduleName].Collectors {
+			if collector.Name() == col {
+				enabledCollectors = append(enabledCollectors, collector)
+			}
+		}
+	}
+	return enabledCollectors
+}
+
+// checkGNMICollectors returns an error when one of the collectors does not support the gnmi transport of the config.
+// configMu must be held.
+func checkGNMICollectors(configName string, enabledCollectors []collector.Collector) error {
+	if _, ok := gnmiClients[configName]; !ok {
+		return nil
+	}
+	for _, c := range enabledCollectors {
+		if _, ok := c.(collector.GNMICollector); !ok {
+			return fmt.Errorf("collector %q does not support the gnmi transport of the %q configuration", c.Name(), configName)
+		}
+	}
+	return nil
+}
+
+// targetAddress returns the address of target, which is the address of the target under targets when target is an
+// alias. configMu must be held.
+func targetAddress(target string) string {
+	if address := collectorConfig.Targets[target].Address; address != "" {
+		return address
+	}
+	return target
+}
+
+// collectorSettings returns the settings used by the collectors to collect the target using the config. configMu must
+// be held.
+func collectorSettings(configName string, target string) collector.Config {
+	return collector.Config{
+		ConfigName:           configName,
+		SSHClientConfig:      exporterSSHConfig[configName],
+		SSHFallbackConfigs:   exporterSSHFallbackConfigs[configName],
+		TLSConfig:            exporterTLSConfig[configName],
+		SSHTarget:            targetAddress(target),
+		IfaceDescrKeys:       interfaceDescriptionKeys[configName],
+		IfaceMetricKeys:      interfaceMetricKeys[configName],
+		IfaceDetail:          interfaceDetails[configName],
+		IfaceTypes:           interfaceTypes[configName],
+		IfaceUtilization:     interfaceUtilization[configName],
+		IfaceInstances:       interfaceInstances[configName],
+		IfaceDescrLabel:      interfaceDescLabels[configName],
+		IfaceDescrKeyLabels:  interfaceKeyLabels[configName],
+		BGPTypeKeys:          bgpTypeKeys[configName],
+		REDiskHealth:         reDiskHealth[configName],
+		BGPInstances:         bgpInstances[configName],
+		DisableBGPInstances:  disableBGPInstances[configName],
+		BGPMonitoredPeers:    bgpMonitoredPeers[configName],
+		IpsecTunnelStats:     ipsecTunnelStats[configName],
+		RPCTimeout:           rpcTimeouts[configName],
+		CollectorRPCTimeouts: collectorRPCTimeouts[configName],
+		CollectorTimeout:     collectorTimeout[configName],
+		CollectorTimeouts:    collectorTimeouts[configName],
+		RPCRetries:           rpcRetries[configName],
+		RPCRetryBackoff:      rpcRetryBackoffs[configName],
+		RPCParallelism:       rpcParallelism[configName],
+		MaxSessions:          collectorConfig.Config[configName].MaxSessions,
+		Port:                 ports[configName],
+		ProxyURL:             proxyURLs[configName],
+		ReplayDir:            replayDirs[configName],
+		Connections:          connections,
+		GNMI:                 gnmiClients[configName],
+		MetricFilters:        metricFilters[configName],
+		Commands:             commands[configName],
+		RouteFilters:         routeFilters[configName],
+		DeviceTimestamps:     deviceTimestamps[configName],
+		DeviceTimestampSkew:  deviceTimestampSkews[configName],
+		PlatformDetection:    platformDetection[configName],
+		StrictParsing:        strictParsing[configName],
+		CaptureDir:           *captureDir,
+		CaptureMaxBytes:      *captureMax,
+		PrometheusNaming:     *metricNaming == "prometheus",
+	}
+}
+
+// acquireScrapeSlot waits for a free scrape slot when --max-concurrent-scrapes is set, returning false when ctx is
+// done or --scrape-queue.max-wait elapsed first. Waiting scrapes with an earlier deadline are granted a slot first.
+func acquireScrapeSlot(ctx context.Context, deadline time.Time) bool {
+	if scrapeSlots == nil {
+		return true
+	}
+	return scrapeSlots.acquire(ctx, deadline)
+}
+
+func releaseScrapeSlot() {
+	if scrapeSlots != nil {
+		scrapeSlots.release()
+	}
+}
+
+// lockTarget locks the address of a target, as returned by targetAddress, so that the aliases of a device and its
+// address are not collected concurrently.
+func lockTarget(address string) *sync.Mutex {
+	targetLocksMu.Lock()
+	lock, ok := targetLocks[address]
+	if !ok {
+		lock = &sync.Mutex{}
+		targetLocks[address] = lock
+	}
+	targetLocksMu.Unlock()
+	lock.Lock()
+	return lock
+}
+
 func handler(logger log.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		deadline := scrapeDeadline(r, start)
 		configParam := r.URL.Query().Get("config")
 		targetParam := r.URL.Query().Get("target")
+		moduleParam := r.URL.Query().Get("module")
+		configMu.RLock()
 		if err := validateRequest(configParam, targetParam); err != nil {
+			configMu.RUnlock()
 			http.Error(w, err.Error(), 400)
 			return
 		}
-		registry := prometheus.NewRegistry()
-		enabledCollectors := []collector.Collector{}
-		for _, collector := range collectors {
-			for _, col := range collectorConfig.Config[configParam].Collectors {
-				if collector.Name() == col {
-					enabledCollectors = append(enabledCollectors, collector)
+		if _, ok := collectorConfig.Modules[moduleParam]; moduleParam != "" && !ok {
+			configMu.RUnlock()
+			http.Error(w, fmt.Sprintf("could not find %q module in configuration file", moduleParam), 400)
+			return
+		}
+		var err error
+		enabledCollectors := configCollectors(configParam)
+		if moduleParam != "" {
+			enabledCollectors = moduleCollectors(moduleParam)
+		}
+		if r.URL.Query().Has("collectors") {
+			if enabledCollectors, err = selectCollectors(enabledCollectors, r.URL.Query()["collectors"]); err != nil {
+				configMu.RUnlock()
+				http.Error(w, err.Error(), 400)
+				return
+			}
+		}
+		if err = checkGNMICollectors(configParam, enabledCollectors); err != nil {
+			configMu.RUnlock()
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		config := collectorSettings(configParam, targetParam)
+		if filters, ok := moduleFilters[moduleParam]; ok {
+			config.MetricFilters = filters
+		}
+		vc, useVault := vaultClients[configParam]
+		labels := targetLabels(configParam, targetParam)
+		p, polled := pollers[pollerKey{config: configParam, target: targetParam}]
+		if polled && moduleParam != "" {
+			// The collectors and metric filters of targets polled in the background are those of the config.
+			configMu.RUnlock()
+			http.Error(w, fmt.Sprintf("'module' parameter is not supported for target %q polled in the background", targetParam), 400)
+			return
+		}
+		cacheTTL := scrapeCacheTTLs[configParam]
+		if interval := time.Second * time.Duration(collectorConfig.Targets[targetParam].MinScrapeInterval); interval > cacheTTL {
+			// Scrapes of the target within its minimum interval are served the metrics of the previous scrape.
+			cacheTTL = interval
+		}
+		configMu.RUnlock()
+
+		handlerOpts := promhttp.HandlerOpts{
+			ErrorLog:      inbuiltLog.New(log.NewStdlibAdapter(level.Error(logger)), "", 0),
+			ErrorHandling: promhttp.ContinueOnError,
+		}
+
+		if polled {
+			// Serve the metrics last polled in the background.
+			registry := prometheus.NewRegistry()
+			if err := prometheus.WrapRegistererWith(labels, registry).Register(p.cachedCollector(enabledCollectors, config)); err != nil {
+				level.Error(logger).Log("msg", "could not register collector", "err", err)
+				os.Exit(1)
+			}
+			promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, registry}, handlerOpts).ServeHTTP(w, r)
+			return
+		}
+
+		lock := lockTarget(config.SSHTarget)
+		defer lock.Unlock()
+
+		// Scrapes waiting for the target lock are served the metrics of the scrape that held it.
+		var cacheKey scrapeCacheKey
+		if cacheTTL > 0 {
+			cacheKey = newScrapeCacheKey(configParam, moduleParam, targetParam, enabledCollectors)
+			if metrics, ok := cachedScrape(cacheKey); ok {
+				registry := prometheus.NewRegistry()
+				if err := prometheus.WrapRegistererWith(labels, registry).Register(metrics); err != nil {
+					level.Error(logger).Log("msg", "could not register collector", "err", err)
+					os.Exit(1)
 				}
+				promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, registry}, handlerOpts).ServeHTTP(w, r)
+				return
 			}
 		}
 
-		config := collector.Config{
-			SSHClientConfig: exporterSSHConfig[configParam],
-			SSHTarget:       targetParam,
-			IfaceDescrKeys:  interfaceDescriptionKeys[configParam],
-			IfaceMetricKeys: interfaceMetricKeys[configParam],
-			BGPTypeKeys:     bgpTypeKeys[configParam],
+		if !acquireScrapeSlot(r.Context(), deadline) {
+			http.Error(w, "scrape canceled while waiting for a free scrape slot", http.StatusServiceUnavailable)
+			return
 		}
+		defer releaseScrapeSlot()
 
-		nc, err := collector.NewExporter(enabledCollectors, config, logger)
+		if useVault {
+			if config.SSHClientConfig, err = vc.sshConfig(r.Context(), targetParam); err != nil {
+				level.Error(logger).Log("msg", "could not get credentials from vault", "target", targetParam, "err", err)
+				http.Error(w, "could not get credentials from vault", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// The collectors not completed before the scrape timeout of Prometheus, less the offset, are reported down so
+		// that the metrics collected so far are returned before Prometheus gives up on the scrape.
+		ctx := r.Context()
+		if timeout, ok := scrapeTimeout(r); ok && timeout > *timeoutOffset {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, start.Add(timeout-*timeoutOffset))
+			defer cancel()
+		}
+
+		registry := prometheus.NewRegistry()
+		nc, err := collector.NewExporter(ctx, enabledCollectors, config, logger)
 		if err != nil {
 			level.Error(logger).Log("msg", "could not create collector", "err", err)
 			os.Exit(1)
 		}
 
-		if err := registry.Register(nc); err != nil {
+		var c prometheus.Collector = nc
+		if cacheTTL > 0 {
+			metrics := collectMetrics(nc)
+			if r.Context().Err() == nil {
+				cacheScrape(cacheKey, metrics, cacheTTL)
+			}
+			c = metrics
+		}
+
+		if err := prometheus.WrapRegistererWith(labels, registry).Register(c); err != nil {
 			level.Error(logger).Log("msg", "could not register collector", "err", err)
 			os.Exit(1)
 		}
@@ -130,34 +511,83 @@ func handler(logger log.Logger) http.Handler {
 			prometheus.DefaultGatherer,
 			registry,
 		}
-		handlerOpts := promhttp.HandlerOpts{
-			ErrorLog:      inbuiltLog.New(log.NewStdlibAdapter(level.Error(logger)), "", 0),
-			ErrorHandling: promhttp.ContinueOnError,
-		}
 
 		metricsHandler := promhttp.HandlerFor(gatherers, handlerOpts)
 		metricsHandler.ServeHTTP(w, r)
 	})
 }
 
-func generateSSHConfig() error {
-	for name, configData := range collectorConfig.Config {
-		sshClientConfig := &ssh.ClientConfig{
-			User: configData.Username,
+// debugRPCHandler runs the collectors passed in the 'collector' parameter, or all collectors of the config, against the
+// target and returns the RPCs they executed along with the raw replies of the device.
+func debugRPCHandler(logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		configParam := r.URL.Query().Get("config")
+		targetParam := r.URL.Query().Get("target")
+		configMu.RLock()
+		if err := validateRequest(configParam, targetParam); err != nil {
+			configMu.RUnlock()
+			http.Error(w, err.Error(), 400)
+			return
 		}
-		if configData.SSHKey != "" {
-			buf, err := os.ReadFile(configData.SSHKey)
-			if err != nil {
-				return fmt.Errorf("could not open ssh key %q: %s", configData.SSHKey, err)
+		var err error
+		enabledCollectors := configCollectors(configParam)
+		if r.URL.Query().Has("collector") {
+			if enabledCollectors, err = selectCollectors(enabledCollectors, r.URL.Query()["collector"]); err != nil {
+				configMu.RUnlock()
+				http.Error(w, err.Error(), 400)
+				return
 			}
-			parsedKey, err := ssh.ParsePrivateKey(buf)
-			if err != nil {
-				return err
+		}
+		config := collectorSettings(configParam, targetParam)
+		vc, useVault := vaultClients[configParam]
+		configMu.RUnlock()
+
+		if config.GNMI != nil {
+			http.Error(w, "raw replies are only available for NETCONF transports", 400)
+			return
+		}
+
+		lock := lockTarget(config.SSHTarget)
+		defer lock.Unlock()
+		if !acquireScrapeSlot(r.Context(), scrapeDeadline(r, time.Now())) {
+			http.Error(w, "canceled while waiting for a free scrape slot", http.StatusServiceUnavailable)
+			return
+		}
+		defer releaseScrapeSlot()
+
+		if useVault {
+			if config.SSHClientConfig, err = vc.sshConfig(r.Context(), targetParam); err != nil {
+				level.Error(logger).Log("msg", "could not get credentials from vault", "target", targetParam, "err", err)
+				http.Error(w, "could not get credentials from vault", http.StatusInternalServerError)
+				return
 			}
-			sshClientConfig.Auth = []ssh.AuthMethod{ssh.PublicKeys(parsedKey)}
-		} else {
-			sshClientConfig.Auth = []ssh.AuthMethod{ssh.Password(configData.Password)}
 		}
+
+		ctx, capture := collector.CaptureRPCs(r.Context())
+		nc, err := collector.NewExporter(ctx, enabledCollectors, config, logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "could not create collector", "err", err)
+			os.Exit(1)
+		}
+		collectMetrics(nc)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, rpc := range capture.RPCs() {
+			fmt.Fprintf(w, "<!-- collector: %s, rpc: %s -->\n", rpc.Collector, rpc.RPC)
+			if rpc.Err != nil {
+				fmt.Fprintf(w, "<!-- error: %s -->\n", rpc.Err)
+			}
+			if rpc.Reply != "" {
+				fmt.Fprintf(w, "%s\n", strings.TrimSpace(rpc.Reply))
+			}
+			fmt.Fprintln(w)
+		}
+	})
+}
+
+func generateSSHConfig() error {
+	for name, configData := range collectorConfig.Config {
+		sshClientConfig := &ssh.ClientConfig{}
 		if configData.Timeout != 0 {
 			sshClientConfig.Timeout = time.Second * time.Duration(configData.Timeout)
 
@@ -166,8 +596,263 @@ func generateSSHConfig() error {
 		} else {
 			sshClientConfig.Timeout = time.Second * 20
 		}
-		sshClientConfig.HostKeyCallback = ssh.InsecureIgnoreHostKey()
-		exporterSSHConfig[name] = sshClientConfig
+		hostKeyChecking := configData.HostKeyChecking
+		if hostKeyChecking == "" {
+			hostKeyChecking = collectorConfig.Global.HostKeyChecking
+		}
+		knownHostsFile := configData.KnownHostsFile
+		if knownHostsFile == "" {
+			knownHostsFile = collectorConfig.Global.KnownHostsFile
+		}
+		hostKeys := configData.HostKeys
+		if len(hostKeys) == 0 {
+			hostKeys = collectorConfig.Global.HostKeys
+		}
+		callback, err := hostKeyCallback(hostKeyChecking, knownHostsFile, hostKeys)
+		if err != nil {
+			return fmt.Errorf("could not configure host key checking of %q configuration: %s", name, err)
+		}
+		sshClientConfig.HostKeyCallback = callback
+
+		algorithms := configData.SSHAlgorithms
+		if len(algorithms.Ciphers) == 0 {
+			algorithms.Ciphers = collectorConfig.Global.SSHAlgorithms.Ciphers
+		}
+		if len(algorithms.KeyExchanges) == 0 {
+			algorithms.KeyExchanges = collectorConfig.Global.SSHAlgorithms.KeyExchanges
+		}
+		if len(algorithms.MACs) == 0 {
+			algorithms.MACs = collectorConfig.Global.SSHAlgorithms.MACs
+		}
+		if len(algorithms.HostKeyAlgorithms) == 0 {
+			algorithms.HostKeyAlgorithms = collectorConfig.Global.SSHAlgorithms.HostKeyAlgorithms
+		}
+		sshClientConfig.Ciphers = algorithms.Ciphers
+		sshClientConfig.KeyExchanges = algorithms.KeyExchanges
+		sshClientConfig.MACs = algorithms.MACs
+		sshClientConfig.HostKeyAlgorithms = algorithms.HostKeyAlgorithms
+
+		// The first credentials are used by the SSH configuration of the config, further credentials by copies of it
+		// tried in order when authentication fails.
+		for i, credential := range configData.SSHCredentials() {
+			credentialConfig := sshClientConfig
+			if i > 0 {
+				c := *sshClientConfig
+				credentialConfig = &c
+			}
+			credentialConfig.User = credential.Username
+			if len(credential.SSHKeyData) > 0 {
+				parsedKey, err := config.ParsePrivateKey(credential)
+				if err != nil {
+					return err
+				}
+				credentialConfig.Auth = []ssh.AuthMethod{ssh.PublicKeys(parsedKey)}
+			} else {
+				credentialConfig.Auth = []ssh.AuthMethod{ssh.Password(credential.Password)}
+			}
+			if i == 0 {
+				exporterSSHConfig[name] = sshClientConfig
+			} else {
+				exporterSSHFallbackConfigs[name] = append(exporterSSHFallbackConfigs[name], credentialConfig)
+			}
+		}
+	}
+	return nil
+}
+
+// hostKeyCallback returns a callback verifying host keys against the keys pinned per target and the known_hosts
+// file. When no mode is set, host keys are verified when either is configured.
+func hostKeyCallback(mode string, knownHostsFile string, hostKeys map[string]string) (ssh.HostKeyCallback, error) {
+	if mode == "" {
+		mode = "insecure"
+		if knownHostsFile != "" || len(hostKeys) > 0 {
+			mode = "strict"
+		}
+	}
+	if mode == "insecure" {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	pinned := map[string]ssh.PublicKey{}
+	for target, key := range hostKeys {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host key of %q: %s", target, err)
+		}
+		pinned[target] = pub
+	}
+
+	var known ssh.HostKeyCallback
+	if knownHostsFile != "" {
+		if mode == "accept-new" {
+			f, err := os.OpenFile(knownHostsFile, os.O_CREATE|os.O_RDONLY, 0600)
+			if err != nil {
+				return nil, err
+			}
+			f.Close()
+		}
+		var err error
+		if known, err = knownhosts.New(knownHostsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keys accepted since the known_hosts file was read.
+	var mu sync.Mutex
+	accepted := map[string]ssh.PublicKey{}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		host := hostname
+		if h, _, err := net.SplitHostPort(hostname); err == nil {
+			host = h
+		}
+		for _, name := range []string{hostname, host} {
+			if pub, ok := pinned[name]; ok {
+				if bytes.Equal(pub.Marshal(), key.Marshal()) {
+					return nil
+				}
+				return fmt.Errorf("host key of %q does not match the pinned key", hostname)
+			}
+		}
+		if known == nil {
+			return fmt.Errorf("no pinned host key for %q", hostname)
+		}
+
+		err := known(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if err == nil || mode != "accept-new" || !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return err
+		}
+
+		// The host is unknown, accept and record its key.
+		mu.Lock()
+		defer mu.Unlock()
+		if pub, ok := accepted[hostname]; ok {
+			if bytes.Equal(pub.Marshal(), key.Marshal()) {
+				return nil
+			}
+			return fmt.Errorf("host key of %q changed since it was accepted", hostname)
+		}
+		f, err := os.OpenFile(knownHostsFile, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)); err != nil {
+			return err
+		}
+		accepted[hostname] = key
+		return nil
+	}, nil
+}
+
+func generateVaultClients(logger log.Logger) error {
+	for name, configData := range collectorConfig.Config {
+		if configData.Vault.Address == "" {
+			continue
+		}
+		var signer ssh.Signer
+		if configData.Vault.SSHSignPath != "" {
+			var err error
+			if signer, err = config.ParsePrivateKey(configData.Credential); err != nil {
+				return fmt.Errorf("could not parse ssh key of %q configuration: %s", name, err)
+			}
+		}
+		vc := newVaultClient(configData.Vault, exporterSSHConfig[name], signer, logger)
+		go vc.renewToken()
+		vaultClients[name] = vc
+	}
+	return nil
+}
+
+func loadTLSConfig(c config.TLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		buf, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not open ca_file %q: %s", c.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("could not parse ca_file %q", c.CAFile)
+		}
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func generateTLSConfig() error {
+	for name, configData := range collectorConfig.Config {
+		if configData.Transport != "tls" {
+			continue
+		}
+		tlsConfig, err := loadTLSConfig(configData.TLS)
+		if err != nil {
+			return fmt.Errorf("could not load tls configuration of %q configuration: %s", name, err)
+		}
+		exporterTLSConfig[name] = tlsConfig
+	}
+	return nil
+}
+
+func generateGNMIClients() error {
+	for name, configData := range collectorConfig.Config {
+		if configData.Transport != "gnmi" {
+			continue
+		}
+		for _, col := range configData.Collectors {
+			for _, c := range collectors {
+				if _, ok := c.(collector.GNMICollector); c.Name() == col && !ok {
+					return fmt.Errorf("collector %q in %q configuration does not support the gnmi transport", col, name)
+				}
+			}
+		}
+		tlsConfig, err := loadTLSConfig(configData.GNMI.TLS)
+		if err != nil {
+			return fmt.Errorf("could not load gnmi tls configuration: %s", err)
+		}
+		port := configData.GNMI.Port
+		if port == 0 {
+			port = 32767
+		}
+		gnmiClients[name] = collector.NewGNMIClient(tlsConfig, port, configData.Username, configData.Password, configData.GNMI.Mode == "subscribe")
+	}
+	return nil
+}
+
+func generateMetricFilters() error {
+	for name, configData := range collectorConfig.Config {
+		filters := configData.MetricFilters
+		if len(filters) == 0 {
+			filters = collectorConfig.Global.MetricFilters
+		}
+		metricFilters[name] = map[string]*collector.MetricFilter{}
+		for col, filter := range filters {
+			metricFilter, err := collector.NewMetricFilter(filter.Allow, filter.Deny)
+			if err != nil {
+				return fmt.Errorf("invalid metric filter of collector %q in %q configuration: %s", col, name, err)
+			}
+			metricFilters[name][col] = metricFilter
+		}
+	}
+	for name, module := range collectorConfig.Modules {
+		if len(module.MetricFilters) == 0 {
+			continue
+		}
+		moduleFilters[name] = map[string]*collector.MetricFilter{}
+		for col, filter := range module.MetricFilters {
+			metricFilter, err := collector.NewMetricFilter(filter.Allow, filter.Deny)
+			if err != nil {
+				return fmt.Errorf("invalid metric filter of collector %q in %q module: %s", col, name, err)
+			}
+			moduleFilters[name][col] = metricFilter
+		}
 	}
 	return nil
 }
@@ -204,6 +889,29 @@ func getInterfaceMetricKeys() {
 	}
 }
 
+func getInterfaceDetails() {
+	for name, configData := range collectorConfig.Config {
+		if configData.InterfaceDetail != "" {
+			interfaceDetails[name] = configData.InterfaceDetail
+		} else if collectorConfig.Global.InterfaceDetail != "" {
+			interfaceDetails[name] = collectorConfig.Global.InterfaceDetail
+		} else {
+			interfaceDetails[name] = "extensive"
+		}
+		// Both physical and logical interfaces are collected when interface_types is empty or all.
+		switch {
+		case configData.InterfaceTypes != "" && configData.InterfaceTypes != "all":
+			interfaceTypes[name] = configData.InterfaceTypes
+		case configData.InterfaceTypes == "" && collectorConfig.Global.InterfaceTypes != "all":
+			interfaceTypes[name] = collectorConfig.Global.InterfaceTypes
+		}
+		interfaceUtilization[name] = configData.InterfaceUtilization || collectorConfig.Global.InterfaceUtilization
+		interfaceInstances[name] = configData.InterfaceInstances || collectorConfig.Global.InterfaceInstances
+		interfaceDescLabels[name] = configData.InterfaceDescLabel || collectorConfig.Global.InterfaceDescLabel
+		interfaceKeyLabels[name] = configData.InterfaceKeyLabels || collectorConfig.Global.InterfaceKeyLabels
+	}
+}
+
 func getBGPTypeKeys() {
 	var globalBGPTypeKeys []string
 	if len(bgpTypeKeys) == 0 {
@@ -220,19 +928,624 @@ func getBGPTypeKeys() {
 	}
 }
 
+func getREDiskHealth() {
+	for name, configData := range collectorConfig.Config {
+		reDiskHealth[name] = configData.REDiskHealth || collectorConfig.Global.REDiskHealth
+	}
+}
+
+func getBGPInstances() {
+	for name, configData := range collectorConfig.Config {
+		if len(configData.BGPInstances) > 0 {
+			bgpInstances[name] = configData.BGPInstances
+		} else {
+			bgpInstances[name] = collectorConfig.Global.BGPInstances
+		}
+		disableBGPInstances[name] = configData.DisableBGPInstances || collectorConfig.Global.DisableBGPInstances
+		if len(configData.BGPMonitoredPeers) > 0 {
+			bgpMonitoredPeers[name] = configData.BGPMonitoredPeers
+		} else {
+			bgpMonitoredPeers[name] = collectorConfig.Global.BGPMonitoredPeers
+		}
+	}
+}
+
+func getRPCTimeouts() {
+	for name, configData := range collectorConfig.Config {
+		if configData.RPCTimeout != 0 {
+			rpcTimeouts[name] = time.Second * time.Duration(configData.RPCTimeout)
+		} else if collectorConfig.Global.RPCTimeout != 0 {
+			rpcTimeouts[name] = time.Second * time.Duration(collectorConfig.Global.RPCTimeout)
+		} else {
+			rpcTimeouts[name] = time.Second * 60
+		}
+	}
+}
+
+func getCollectorRPCTimeouts() {
+	for name, configData := range collectorConfig.Config {
+		timeouts := configData.CollectorRPCTimeouts
+		if len(timeouts) == 0 {
+			timeouts = collectorConfig.Global.CollectorRPCTimeouts
+		}
+		collectorRPCTimeouts[name] = map[string]time.Duration{}
+		for col, timeout := range timeouts {
+			collectorRPCTimeouts[name][col] = time.Second * time.Duration(timeout)
+		}
+	}
+}
+
+func getCollectorTimeouts() {
+	for name, configData := range collectorConfig.Config {
+		if configData.CollectorTimeout != 0 {
+			collectorTimeout[name] = time.Second * time.Duration(configData.CollectorTimeout)
+		} else {
+			collectorTimeout[name] = time.Second * time.Duration(collectorConfig.Global.CollectorTimeout)
+		}
+		timeouts := configData.CollectorTimeouts
+		if len(timeouts) == 0 {
+			timeouts = collectorConfig.Global.CollectorTimeouts
+		}
+		collectorTimeouts[name] = map[string]time.Duration{}
+		for col, timeout := range timeouts {
+			collectorTimeouts[name][col] = time.Second * time.Duration(timeout)
+		}
+	}
+}
+
+func getRPCRetries() {
+	for name, configData := range collectorConfig.Config {
+		if configData.RPCRetries != 0 {
+			rpcRetries[name] = configData.RPCRetries
+		} else {
+			rpcRetries[name] = collectorConfig.Global.RPCRetries
+		}
+		if configData.RPCRetryBackoff != 0 {
+			rpcRetryBackoffs[name] = time.Second * time.Duration(configData.RPCRetryBackoff)
+		} else if collectorConfig.Global.RPCRetryBackoff != 0 {
+			rpcRetryBackoffs[name] = time.Second * time.Duration(collectorConfig.Global.RPCRetryBackoff)
+		} else {
+			rpcRetryBackoffs[name] = time.Second
+		}
+	}
+}
+
+func getPorts() {
+	for name, configData := range collectorConfig.Config {
+		if configData.Port != 0 {
+			ports[name] = configData.Port
+		} else {
+			ports[name] = collectorConfig.Global.Port
+		}
+	}
+}
+
+func getProxyURLs() {
+	for name, configData := range collectorConfig.Config {
+		proxyURL := configData.ProxyURL
+		if proxyURL == "" {
+			proxyURL = collectorConfig.Global.ProxyURL
+		}
+		// The proxy URLs are validated when loading the config file.
+		proxyURLs[name], _ = config.ParseProxyURL(proxyURL)
+	}
+}
+
+func getReplayDirs() {
+	for name, configData := range collectorConfig.Config {
+		if configData.Transport == "replay" {
+			replayDirs[name] = configData.ReplayDir
+		}
+	}
+}
+
+// getStrictParsing enables strict parsing for the configs setting it, or for all configs when set globally.
+func getStrictParsing() {
+	for name, configData := range collectorConfig.Config {
+		strictParsing[name] = configData.StrictParsing || collectorConfig.Global.StrictParsing
+	}
+}
+
+func getIpsecTunnelStats() {
+	for name, configData := range collectorConfig.Config {
+		ipsecTunnelStats[name] = configData.IpsecTunnelStats || collectorConfig.Global.IpsecTunnelStats
+	}
+}
+
+// getDeviceTimestamps enables device timestamps for the configs setting it, or for all configs when set globally, with
+// a maximum clock skew of 60 seconds unless configured.
+func getDeviceTimestamps() {
+	for name, configData := range collectorConfig.Config {
+		deviceTimestamps[name] = configData.DeviceTimestamps || collectorConfig.Global.DeviceTimestamps
+		if configData.DeviceTimestampSkew > 0 {
+			deviceTimestampSkews[name] = time.Duration(configData.DeviceTimestampSkew) * time.Second
+		} else if collectorConfig.Global.DeviceTimestampSkew > 0 {
+			deviceTimestampSkews[name] = time.Duration(collectorConfig.Global.DeviceTimestampSkew) * time.Second
+		} else {
+			deviceTimestampSkews[name] = time.Minute
+		}
+	}
+}
+
+func getPlatformDetection() {
+	for name, configData := range collectorConfig.Config {
+		platformDetection[name] = configData.PlatformDetection || collectorConfig.Global.PlatformDetection
+	}
+}
+
+func getCommands() {
+	for name, configData := range collectorConfig.Config {
+		for _, c := range configData.Commands {
+			command := collector.Command{Command: c.Command}
+			for _, m := range c.Metrics {
+				command.Metrics = append(command.Metrics, collector.CommandMetric{
+					Name:   m.Name,
+					Help:   m.Help,
+					Type:   m.Type,
+					Items:  m.Items,
+					Value:  m.Value,
+					Labels: m.Labels,
+				})
+			}
+			commands[name] = append(commands[name], command)
+		}
+	}
+}
+
+func getRouteFilters() {
+	for name, configData := range collectorConfig.Config {
+		for _, f := range configData.RouteFilters {
+			routeFilters[name] = append(routeFilters[name], collector.RouteFilter{
+				Name:     f.Name,
+				Table:    f.Table,
+				Prefix:   f.Prefix,
+				Exact:    f.Exact,
+				Protocol: f.Protocol,
+			})
+		}
+	}
+}
+
+// getConfigLabels merges the global labels with the labels of each config, the labels of a config take precedence.
+func getConfigLabels() {
+	for name, configData := range collectorConfig.Config {
+		labels := map[string]string{}
+		for label, value := range collectorConfig.Global.Labels {
+			labels[label] = value
+		}
+		for label, value := range configData.Labels {
+			labels[label] = value
+		}
+		configLabels[name] = labels
+	}
+}
+
+// targetLabels returns the static labels added to all metrics of a target scraped using a config, the labels of the
+// target under targets take precedence over the labels of the config. Aliases of an address are added as the device
+// label.
+func targetLabels(configName string, target string) prometheus.Labels {
+	labels := prometheus.Labels{}
+	for label, value := range configLabels[configName] {
+		labels[label] = value
+	}
+	if collectorConfig.Targets[target].Address != "" {
+		labels["device"] = target
+	}
+	for label, value := range collectorConfig.Targets[target].Labels {
+		labels[label] = value
+	}
+	return labels
+}
+
+func getRPCParallelism() {
+	for name, configData := range collectorConfig.Config {
+		if configData.RPCParallelism != 0 {
+			rpcParallelism[name] = configData.RPCParallelism
+		} else {
+			rpcParallelism[name] = collectorConfig.Global.RPCParallelism
+		}
+	}
+}
+
+func getScrapeCacheTTLs() {
+	for name, configData := range collectorConfig.Config {
+		if configData.ScrapeCacheTTL != 0 {
+			scrapeCacheTTLs[name] = time.Second * time.Duration(configData.ScrapeCacheTTL)
+		} else {
+			scrapeCacheTTLs[name] = time.Second * time.Duration(collectorConfig.Global.ScrapeCacheTTL)
+		}
+	}
+}
+
+// loadConfig reads the config file and regenerates the per-config settings derived from it. The previous
+// configuration is kept when the config file is invalid.
+func loadConfig(collectorNames []string, logger log.Logger) error {
+	newConfig, err := config.LoadConfigFile(*configPath, collectorNames)
+	if err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	oldConfig, oldSSHConfig, oldSSHFallbackConfigs, oldVaultClients, oldTLSConfig, oldGNMIClients, oldMetricFilters, oldModuleFilters := collectorConfig, exporterSSHConfig, exporterSSHFallbackConfigs, vaultClients, exporterTLSConfig, gnmiClients, metricFilters, moduleFilters
+	collectorConfig = newConfig
+	exporterSSHConfig = map[string]*ssh.ClientConfig{}
+	exporterSSHFallbackConfigs = map[string][]*ssh.ClientConfig{}
+	vaultClients = map[string]*vaultClient{}
+	exporterTLSConfig = map[string]*tls.Config{}
+	gnmiClients = map[string]*collector.GNMIClient{}
+	metricFilters = map[string]map[string]*collector.MetricFilter{}
+	moduleFilters = map[string]map[string]*collector.MetricFilter{}
+
+	if err = generateSSHConfig(); err != nil {
+		err = fmt.Errorf("could not generate SSH configuration: %s", err)
+	} else if err = generateVaultClients(logger); err != nil {
+		err = fmt.Errorf("could not generate Vault configuration: %s", err)
+	} else if err = generateTLSConfig(); err != nil {
+		err = fmt.Errorf("could not generate TLS configuration: %s", err)
+	} else if err = generateGNMIClients(); err != nil {
+		err = fmt.Errorf("could not generate gNMI configuration: %s", err)
+	} else if err = generateMetricFilters(); err != nil {
+		err = fmt.Errorf("could not generate metric filters: %s", err)
+	}
+	if err != nil {
+		for _, vc := range vaultClients {
+			vc.stop()
+		}
+		for _, gc := range gnmiClients {
+			gc.Close()
+		}
+		collectorConfig, exporterSSHConfig, exporterSSHFallbackConfigs, vaultClients, exporterTLSConfig, gnmiClients, metricFilters, moduleFilters = oldConfig, oldSSHConfig, oldSSHFallbackConfigs, oldVaultClients, oldTLSConfig, oldGNMIClients, oldMetricFilters, oldModuleFilters
+		return err
+	}
+	for _, vc := range oldVaultClients {
+		vc.stop()
+	}
+	for _, gc := range oldGNMIClients {
+		gc.Close()
+	}
+
+	interfaceDescriptionKeys = map[string][]string{}
+	interfaceMetricKeys = map[string][]string{}
+	interfaceDetails = map[string]string{}
+	interfaceTypes = map[string]string{}
+	interfaceUtilization = map[string]bool{}
+	interfaceInstances = map[string]bool{}
+	interfaceDescLabels = map[string]bool{}
+	interfaceKeyLabels = map[string]bool{}
+	bgpTypeKeys = map[string][]string{}
+	reDiskHealth = map[string]bool{}
+	bgpInstances = map[string][]string{}
+	disableBGPInstances = map[string]bool{}
+	bgpMonitoredPeers = map[string][]string{}
+	ipsecTunnelStats = map[string]bool{}
+	rpcTimeouts = map[string]time.Duration{}
+	scrapeCacheTTLs = map[string]time.Duration{}
+	collectorRPCTimeouts = map[string]map[string]time.Duration{}
+	collectorTimeout = map[string]time.Duration{}
+	collectorTimeouts = map[string]map[string]time.Duration{}
+	rpcRetries = map[string]int{}
+	rpcRetryBackoffs = map[string]time.Duration{}
+	rpcParallelism = map[string]int{}
+	ports = map[string]int{}
+	proxyURLs = map[string]*url.URL{}
+	replayDirs = map[string]string{}
+	configLabels = map[string]map[string]string{}
+	strictParsing = map[string]bool{}
+	commands = map[string][]collector.Command{}
+	routeFilters = map[string][]collector.RouteFilter{}
+	deviceTimestamps = map[string]bool{}
+	deviceTimestampSkews = map[string]time.Duration{}
+	platformDetection = map[string]bool{}
+	getInterfaceDescriptionKeys()
+	getInterfaceMetricKeys()
+	getInterfaceDetails()
+	getBGPTypeKeys()
+	getREDiskHealth()
+	getBGPInstances()
+	getRPCTimeouts()
+	getCollectorRPCTimeouts()
+	getCollectorTimeouts()
+	getRPCRetries()
+	getRPCParallelism()
+	getPorts()
+	getProxyURLs()
+	getReplayDirs()
+	getConfigLabels()
+	getScrapeCacheTTLs()
+	getStrictParsing()
+	getIpsecTunnelStats()
+	getCommands()
+	getRouteFilters()
+	getDeviceTimestamps()
+	getPlatformDetection()
+
+	var configNames []string
+	for name := range collectorConfig.Config {
+		configNames = append(configNames, name)
+	}
+	connections.RetainConfigs(configNames)
+
+	for _, p := range pollers {
+		p.stop()
+	}
+	pollers = map[pollerKey]*poller{}
+	if remoteWriter != nil {
+		remoteWriter.stop()
+		remoteWriter = nil
+	}
+	if otlpExporter != nil {
+		otlpExporter.stop()
+		otlpExporter = nil
+	}
+	if !scrapeOnly {
+		startPollers(logger)
+		if collectorConfig.RemoteWrite.URL != "" {
+			remoteWriter = startRemoteWrite(collectorConfig.RemoteWrite, logger)
+		}
+		if collectorConfig.OTLP.Endpoint != "" {
+			otlpExporter = startOTLPExport(collectorConfig.OTLP, logger)
+		}
+	}
+
+	level.Info(logger).Log("msg", "Loaded configuration", "path", *configPath)
+	return nil
+}
+
+// sdTargetGroup is a target group in the Prometheus HTTP service discovery format.
+type sdTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// sdHandler serves the allowed_targets of each config, or of the config passed in the 'config' parameter, for
+// Prometheus HTTP service discovery. Each target is labeled with the static labels of its config and its inventory labels.
+func sdHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		configParam := r.URL.Query().Get("config")
+
+		configMu.RLock()
+		var names []string
+		for name := range collectorConfig.Config {
+			if configParam == "" || configParam == name {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			configMu.RUnlock()
+			http.Error(w, fmt.Sprintf("could not find %q config in configuration file", configParam), 400)
+			return
+		}
+		sort.Strings(names)
+
+		groups := []sdTargetGroup{}
+		for _, name := range names {
+			targets := collectorConfig.Config[name].AllowedTargets
+			if len(targets) == 0 {
+				targets = collectorConfig.Global.AllowedTargets
+			}
+			if len(targets) == 0 {
+				continue
+			}
+			for _, target := range targets {
+				if config.IsTargetPattern(target) {
+					continue
+				}
+				labels := targetLabels(name, target)
+				labels["__param_config"] = name
+				groups = append(groups, sdTargetGroup{
+					Targets: []string{target},
+					Labels:  labels,
+				})
+			}
+		}
+		configMu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(groups); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// collectorInfo describes an available collector.
+type collectorInfo struct {
+	Name    string   `json:"name"`
+	RPCs    []string `json:"rpcs"`
+	Configs []string `json:"enabled_configs"`
+}
+
+// collectorInfos returns the available collectors with the configs of conf enabling them, conf may be nil.
+func collectorInfos(conf *config.Configuration) []collectorInfo {
+	infos := []collectorInfo{}
+	for _, c := range collectors {
+		info := collectorInfo{Name: c.Name(), RPCs: []string{}, Configs: []string{}}
+		if lister, ok := c.(collector.RPCLister); ok {
+			info.RPCs = lister.RPCs()
+		}
+		if conf != nil {
+			for name, configData := range conf.Config {
+				for _, enabled := range configData.Collectors {
+					if enabled == c.Name() {
+						info.Configs = append(info.Configs, name)
+					}
+				}
+			}
+			sort.Strings(info.Configs)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// printCollectors writes the available collectors as a table to w.
+func printCollectors(w io.Writer, infos []collectorInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTOR\tRPCS\tENABLED CONFIGS")
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", info.Name, strings.Join(info.RPCs, ","), strings.Join(info.Configs, ","))
+	}
+	return tw.Flush()
+}
+
+// collectorsHandler serves the available collectors, their RPCs and the configs enabling them as JSON.
+func collectorsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		configMu.RLock()
+		infos := collectorInfos(collectorConfig)
+		configMu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(infos); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+var targetsTemplate = template.Must(template.New("targets").Parse(`<html>
+<head><title>Junos Exporter Targets</title></head>
+<body>
+<h1>Targets</h1>
+<table border="1" cellpadding="4">
+<tr><th>Target</th><th>Last Scrape</th><th>Duration</th><th>Success</th><th>Collectors</th></tr>
+{{range .}}<tr>
+<td>{{.Target}}</td>
+<td>{{.LastScrape.Format "2006-01-02 15:04:05 MST"}}</td>
+<td>{{printf "%.3fs" .Duration}}</td>
+<td>{{.Success}}</td>
+<td>{{range $name, $c := .Collectors}}{{$name}}: {{if $c.Up}}up{{else}}down{{end}}, {{$c.Errors}} errors{{if $c.LastError}} (last: {{$c.LastError}}){{end}}<br>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// targetsHandler serves the status of the last scrape of each target as an HTML page, or as JSON when the 'format'
+// parameter is json.
+func targetsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses := collector.TargetStatuses()
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			if statuses == nil {
+				statuses = []collector.TargetStatus{}
+			}
+			if err := json.NewEncoder(w).Encode(statuses); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := targetsTemplate.Execute(w, statuses); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// ifIndexHandler serves the SNMP ifIndex of the interfaces of each target as JSON, from the last scrape of the
+// interface collector of the target. The 'target' parameter limits the reply to the interfaces of a target.
+func ifIndexHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		indexes := collector.IfIndexes()
+		if target := r.URL.Query().Get("target"); target != "" {
+			configMu.RLock()
+			address := targetAddress(target)
+			configMu.RUnlock()
+			list, ok := indexes[address]
+			if !ok {
+				http.Error(w, fmt.Sprintf("no interfaces of target %q collected", target), http.StatusNotFound)
+				return
+			}
+			indexes = map[string][]collector.IfIndex{target: list}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(indexes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// checkHealth returns an error when the configuration cannot be read, such as when a reload of the configuration is
+// stuck, or the pool of NETCONF sessions cannot be locked, within healthTimeout.
+func checkHealth() error {
+	locked := make(chan struct{})
+	go func() {
+		configMu.RLock()
+		configMu.RUnlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(healthTimeout):
+		return fmt.Errorf("configuration not readable within %s", healthTimeout)
+	}
+	if connections != nil && !connections.Responsive(healthTimeout) {
+		return fmt.Errorf("session pool not responsive within %s", healthTimeout)
+	}
+	return nil
+}
+
+// healthyHandler reports whether the exporter is healthy, without scraping any target.
+func healthyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := checkHealth(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "Healthy")
+	})
+}
+
+// readyHandler reports the exporter as ready once the configuration is loaded, without scraping any target.
+func readyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		configMu.RLock()
+		loaded := collectorConfig != nil
+		configMu.RUnlock()
+		if !loaded {
+			http.Error(w, "configuration not loaded", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "Ready")
+	})
+}
+
+func reloadHandler(collectorNames []string, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			http.Error(w, "only POST and PUT requests are allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := loadConfig(collectorNames, logger); err != nil {
+			level.Error(logger).Log("msg", "could not reload configuration", "err", err)
+			http.Error(w, fmt.Sprintf("could not reload configuration: %s", err), http.StatusInternalServerError)
+		}
+	})
+}
+
 func main() {
 	promlogConfig := &promlog.Config{}
 
 	flag.AddFlags(kingpin.CommandLine, promlogConfig)
 	kingpin.Version(version.Print("junos_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	scrapeOnly = kingpin.Parse() == scrapeCmd.FullCommand()
 
 	logger := promlog.New(promlogConfig)
 
-	initCollectors(logger)
+	if err := initCollectors(logger); err != nil {
+		level.Error(logger).Log("msg", "could not initialize collectors", "err", err)
+		os.Exit(1)
+	}
 
 	prometheus.MustRegister(versioncollector.NewCollector("junos_exporter"))
+	prometheus.MustRegister(remoteWriteSamples, remoteWriteFailures, otlpDataPoints, otlpFailures)
+	prometheus.MustRegister(scrapeQueueLength, scrapeQueueWait, scrapeQueueRejected)
+	if *runtimeMetrics {
+		prometheus.Unregister(promcollectors.NewGoCollector())
+		prometheus.MustRegister(promcollectors.NewGoCollector(promcollectors.WithGoCollectorRuntimeMetrics(promcollectors.MetricsAll)))
+	}
 
 	level.Info(logger).Log("msg", "Starting junos_exporter", "version", version.Info())
 	level.Info(logger).Log("msg", "Build context", "build_context", version.BuildContext())
@@ -241,22 +1554,89 @@ func main() {
 	for _, collector := range collectors {
 		collectorNames = append(collectorNames, collector.Name())
 	}
-	var err error
-	collectorConfig, err = config.LoadConfigFile(*configPath, collectorNames)
-	if err != nil {
+	if *listCollectors {
+		conf, err := config.LoadConfigFile(*configPath, collectorNames)
+		if err != nil {
+			level.Error(logger).Log("msg", "could not load configuration, enabled configs are not listed", "err", err)
+		}
+		if err := printCollectors(os.Stdout, collectorInfos(conf)); err != nil {
+			level.Error(logger).Log("err", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	connections = collector.NewConnectionManager(*sshMaxIdle, *sshKeepalive, *sshMaxMissed, *failureLimit, *cooldown, *sshMaxSessions, logger)
+	if *maxScrapes > 0 {
+		scrapeSlots = newScrapeQueue(*maxScrapes, *maxQueueWait)
+	}
+	if *captureDir != "" {
+		if err := os.MkdirAll(*captureDir, 0o700); err != nil {
+			level.Error(logger).Log("msg", "could not create capture directory", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := loadConfig(collectorNames, logger); err != nil {
 		level.Error(logger).Log("err", err)
 		os.Exit(1)
 	}
+	if scrapeOnly {
+		os.Exit(scrapeOnce(*scrapeConfig, *scrapeTarget, *scrapeCollector, logger))
+	}
 
-	if err = generateSSHConfig(); err != nil {
-		level.Error(logger).Log("could not generate SSH configuration", err)
+	if *staleAfter > 0 {
+		go func() {
+			ticker := time.NewTicker(*staleAfter)
+			defer ticker.Stop()
+			for range ticker.C {
+				collector.ForgetStaleTargets(*staleAfter, connections)
+			}
+		}()
 	}
 
-	getInterfaceDescriptionKeys()
-	getInterfaceMetricKeys()
-	getBGPTypeKeys()
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if err := loadConfig(collectorNames, logger); err != nil {
+				level.Error(logger).Log("msg", "could not reload configuration", "err", err)
+			}
+		}
+	}()
 
-	http.Handle(*telemetryPath, handler(logger))
+	mux := http.NewServeMux()
+	mux.Handle(*telemetryPath, handler(logger))
+	if *webLifecycle {
+		mux.Handle("/-/reload", reloadHandler(collectorNames, logger))
+	}
+	mux.Handle("/-/healthy", healthyHandler())
+	mux.Handle("/-/ready", readyHandler())
+	mux.Handle("/sd", sdHandler())
+	mux.Handle("/targets", targetsHandler())
+	mux.Handle("/collectors", collectorsHandler())
+	mux.Handle("/ifindex", ifIndexHandler())
+	if *debugRPC {
+		mux.Handle("/debug/rpc", debugRPCHandler(logger))
+	}
+	if *debugPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	if *jtiAddress != "" {
+		jtiRegistry := prometheus.NewRegistry()
+		receiver := collector.NewJTIReceiver(*jtiStaleAfter, logger)
+		jtiRegistry.MustRegister(receiver)
+		go func() {
+			if err := receiver.ListenAndReceive(*jtiAddress); err != nil {
+				level.Error(logger).Log("msg", "streaming telemetry receiver failed", "err", err)
+				os.Exit(1)
+			}
+		}()
+		mux.Handle(*jtiPath, promhttp.HandlerFor(jtiRegistry, promhttp.HandlerOpts{}))
+	}
 	if *telemetryPath != "/" && *telemetryPath != "" {
 		landingConfig := web.LandingConfig{
 			Name:        "Junos Exporter",
@@ -264,6 +1644,9 @@ func main() {
 			Version:     version.Info(),
 			Links: []web.LandingLinks{
 				{Address: *telemetryPath, Text: "Metrics"},
+				{Address: "/sd", Text: "Service Discovery"},
+				{Address: "/targets", Text: "Targets"},
+				{Address: "/collectors", Text: "Collectors"},
 			},
 		}
 		landingPage, err := web.NewLandingPage(landingConfig)
@@ -271,11 +1654,17 @@ func main() {
 			level.Error(logger).Log("err", err)
 			os.Exit(1)
 		}
-		http.Handle("/", landingPage)
+		mux.Handle("/", landingPage)
 	}
 
-	server := &http.Server{}
-	if err := web.ListenAndServe(server, webFlagConfig, logger); err != nil {
+	server := &http.Server{Handler: mux}
+	listeners, err := listen(webFlagConfig, logger)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	notifySystemd(logger)
+	if err := web.ServeMultiple(listeners, server, webFlagConfig, logger); err != nil {
 		level.Error(logger).Log("err", err)
 		os.Exit(1)
 	}
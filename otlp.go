@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/version"
+	"github.com/tynany/junos_exporter/config"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	otlpDataPoints = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "junos",
+		Name:      "otlp_data_points_total",
+		Help:      "Total number of data points of polled targets exported using OTLP.",
+	})
+	otlpFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "junos",
+		Name:      "otlp_failures_total",
+		Help:      "Total number of OTLP export requests that failed.",
+	})
+
+	// The OTLP exporter started from the loaded configuration, nil when otlp is not configured.
+	otlpExporter *otlpExport
+)
+
+// AggregationTemporality of cumulative sums and histograms.
+const otlpCumulative = 2
+
+// otlpExport exports the last polled metrics of all targets polled in the background to an OpenTelemetry collector
+// using OTLP over HTTP on an interval.
+type otlpExport struct {
+	conf   config.OTLP
+	client *http.Client
+	cancel context.CancelFunc
+	// Start time of the cumulative metrics.
+	start time.Time
+}
+
+// startOTLPExport starts exporting the metrics of the pollers to the endpoint of conf.
+func startOTLPExport(conf config.OTLP, logger log.Logger) *otlpExport {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &otlpExport{
+		conf:   conf,
+		client: &http.Client{Timeout: time.Second * time.Duration(conf.Timeout)},
+		cancel: cancel,
+		start:  time.Now(),
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second * time.Duration(conf.Interval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.export(ctx); err != nil {
+					otlpFailures.Inc()
+					level.Error(logger).Log("msg", "could not export metrics using otlp", "endpoint", conf.Endpoint, "err", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return e
+}
+
+func (e *otlpExport) stop() {
+	e.cancel()
+}
+
+// export sends the last polled metrics of each polled target in a single OTLP export request, with a resource per
+// target.
+func (e *otlpExport) export(ctx context.Context) error {
+	now := uint64(time.Now().UnixNano())
+	start := uint64(e.start.UnixNano())
+	targets, err := gatherPolled()
+	if err != nil {
+		return err
+	}
+
+	var req []byte
+	var dataPoints int
+	for _, target := range targets {
+		var resource []byte
+		resource = appendOTLPAttribute(resource, 1, "service.name", "junos_exporter")
+		resource = appendOTLPAttribute(resource, 1, "service.instance.id", target.target)
+		for _, name := range sortedLabelNames(target.labels) {
+			resource = appendOTLPAttribute(resource, 1, name, target.labels[name])
+		}
+
+		var scope []byte
+		scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+		scope = protowire.AppendString(scope, "junos_exporter")
+		scope = protowire.AppendTag(scope, 2, protowire.BytesType)
+		scope = protowire.AppendString(scope, version.Version)
+
+		var scopeMetrics []byte
+		scopeMetrics = protowire.AppendTag(scopeMetrics, 1, protowire.BytesType)
+		scopeMetrics = protowire.AppendBytes(scopeMetrics, scope)
+		for _, family := range target.families {
+			metric, n := encodeOTLPMetric(family, target.labels, start, now)
+			if n == 0 {
+				continue
+			}
+			dataPoints += n
+			scopeMetrics = protowire.AppendTag(scopeMetrics, 2, protowire.BytesType)
+			scopeMetrics = protowire.AppendBytes(scopeMetrics, metric)
+		}
+
+		var resourceMetrics []byte
+		resourceMetrics = protowire.AppendTag(resourceMetrics, 1, protowire.BytesType)
+		resourceMetrics = protowire.AppendBytes(resourceMetrics, resource)
+		resourceMetrics = protowire.AppendTag(resourceMetrics, 2, protowire.BytesType)
+		resourceMetrics = protowire.AppendBytes(resourceMetrics, scopeMetrics)
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, resourceMetrics)
+	}
+	if dataPoints == 0 {
+		return nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.conf.Endpoint, bytes.NewReader(req))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("User-Agent", "junos_exporter")
+	for name, value := range e.conf.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	otlpDataPoints.Add(float64(dataPoints))
+	return nil
+}
+
+func sortedLabelNames(labels prometheus.Labels) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appendOTLPAttribute appends a KeyValue attribute with a string value as field num of a message to b.
+func appendOTLPAttribute(b []byte, num protowire.Number, key string, value string) []byte {
+	var anyValue []byte
+	anyValue = protowire.AppendTag(anyValue, 1, protowire.BytesType)
+	anyValue = protowire.AppendString(anyValue, value)
+	var kv []byte
+	kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+	kv = protowire.AppendString(kv, key)
+	kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+	kv = protowire.AppendBytes(kv, anyValue)
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, kv)
+}
+
+// appendOTLPTimes appends the start and time of a data point to b.
+func appendOTLPTimes(b []byte, start uint64, now uint64) []byte {
+	b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, start)
+	b = protowire.AppendTag(b, 3, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, now)
+}
+
+func appendOTLPDouble(b []byte, num protowire.Number, v float64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+// encodeOTLPMetric encodes family as an OTLP Metric message, returning the number of its data points. Counters are
+// cumulative monotonic sums, gauges and untyped metrics are gauges. Labels of resourceLabels are attributes of the
+// resource instead of the data points.
+func encodeOTLPMetric(family *dto.MetricFamily, resourceLabels prometheus.Labels, start uint64, now uint64) ([]byte, int) {
+	// Field numbers of the attributes of NumberDataPoint, HistogramDataPoint and SummaryDataPoint.
+	attributesField := protowire.Number(7)
+	if family.GetType() == dto.MetricType_HISTOGRAM {
+		attributesField = 9
+	}
+
+	var points [][]byte
+	for _, m := range family.GetMetric() {
+		var point []byte
+		for _, l := range m.GetLabel() {
+			if _, ok := resourceLabels[l.GetName()]; ok {
+				continue
+			}
+			point = appendOTLPAttribute(point, attributesField, l.GetName(), l.GetValue())
+		}
+		point = appendOTLPTimes(point, start, now)
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			point = appendOTLPDouble(point, 4, m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			point = appendOTLPDouble(point, 4, m.GetGauge().GetValue())
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			point = protowire.AppendTag(point, 4, protowire.Fixed64Type)
+			point = protowire.AppendFixed64(point, h.GetSampleCount())
+			point = appendOTLPDouble(point, 5, h.GetSampleSum())
+			// OTLP buckets are not cumulative and include the bucket above the last bound.
+			var counts, bounds []byte
+			var previous uint64
+			for _, b := range h.GetBucket() {
+				counts = protowire.AppendFixed64(counts, b.GetCumulativeCount()-previous)
+				bounds = protowire.AppendFixed64(bounds, math.Float64bits(b.GetUpperBound()))
+				previous = b.GetCumulativeCount()
+			}
+			counts = protowire.AppendFixed64(counts, h.GetSampleCount()-previous)
+			point = protowire.AppendTag(point, 6, protowire.BytesType)
+			point = protowire.AppendBytes(point, counts)
+			point = protowire.AppendTag(point, 7, protowire.BytesType)
+			point = protowire.AppendBytes(point, bounds)
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			point = protowire.AppendTag(point, 4, protowire.Fixed64Type)
+			point = protowire.AppendFixed64(point, s.GetSampleCount())
+			point = appendOTLPDouble(point, 5, s.GetSampleSum())
+			for _, q := range s.GetQuantile() {
+				var quantile []byte
+				quantile = appendOTLPDouble(quantile, 1, q.GetQuantile())
+				quantile = appendOTLPDouble(quantile, 2, q.GetValue())
+				point = protowire.AppendTag(point, 6, protowire.BytesType)
+				point = protowire.AppendBytes(point, quantile)
+			}
+		default:
+			point = appendOTLPDouble(point, 4, m.GetUntyped().GetValue())
+		}
+		points = append(points, point)
+	}
+	if len(points) == 0 {
+		return nil, 0
+	}
+
+	var data []byte
+	for _, point := range points {
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendBytes(data, point)
+	}
+	var dataField protowire.Number
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		dataField = 7
+		data = protowire.AppendTag(data, 2, protowire.VarintType)
+		data = protowire.AppendVarint(data, otlpCumulative)
+		data = protowire.AppendTag(data, 3, protowire.VarintType)
+		data = protowire.AppendVarint(data, 1)
+	case dto.MetricType_HISTOGRAM:
+		dataField = 9
+		data = protowire.AppendTag(data, 2, protowire.VarintType)
+		data = protowire.AppendVarint(data, otlpCumulative)
+	case dto.MetricType_SUMMARY:
+		dataField = 11
+	default:
+		dataField = 5
+	}
+
+	var metric []byte
+	metric = protowire.AppendTag(metric, 1, protowire.BytesType)
+	metric = protowire.AppendString(metric, family.GetName())
+	metric = protowire.AppendTag(metric, 2, protowire.BytesType)
+	metric = protowire.AppendString(metric, family.GetHelp())
+	metric = protowire.AppendTag(metric, dataField, protowire.BytesType)
+	metric = protowire.AppendBytes(metric, data)
+	return metric, len(points)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tynany/junos_exporter/collector"
+	"github.com/tynany/junos_exporter/config"
+)
+
+var cacheAgeDesc = prometheus.NewDesc("junos_collector_cache_age_seconds", "Time since the served metrics of the collector were polled in the background.", []string{"collector"}, nil)
+
+// pollerKey identifies a target of a config polled in the background.
+type pollerKey struct {
+	config string
+	target string
+}
+
+// poller polls the collectors of a target in the background, each on its own interval, and caches their metrics.
+type poller struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	cache map[string]pollResult
+}
+
+type pollResult struct {
+	metrics []prometheus.Metric
+	polled  time.Time
+}
+
+// startPollers starts a poller for each allowed target of the configs with polling enabled. configMu must be held.
+func startPollers(logger log.Logger) {
+	for name, configData := range collectorConfig.Config {
+		if configData.Polling.Interval == 0 {
+			continue
+		}
+		targets := configData.AllowedTargets
+		if len(targets) == 0 {
+			targets = collectorConfig.Global.AllowedTargets
+		}
+		for _, target := range targets {
+			if config.IsTargetPattern(target) {
+				continue
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			p := &poller{ctx: ctx, cancel: cancel, cache: map[string]pollResult{}}
+			for _, col := range configCollectors(name) {
+				interval := configData.Polling.Interval
+				if collectorInterval, ok := configData.Polling.CollectorIntervals[col.Name()]; ok {
+					interval = collectorInterval
+				}
+				go p.run(name, target, col, time.Second*time.Duration(interval), logger)
+			}
+			pollers[pollerKey{config: name, target: target}] = p
+		}
+	}
+}
+
+func (p *poller) run(configName string, target string, col collector.Collector, interval time.Duration, logger log.Logger) {
+	for {
+		p.poll(configName, target, col, interval, logger)
+		select {
+		case <-time.After(interval):
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// poll collects the metrics of a collector, aborting when the collector does not complete within interval.
+func (p *poller) poll(configName string, target string, col collector.Collector, interval time.Duration, logger log.Logger) {
+	ctx, cancel := context.WithTimeout(p.ctx, interval)
+	defer cancel()
+
+	configMu.RLock()
+	config := collectorSettings(configName, target)
+	vc, useVault := vaultClients[configName]
+	configMu.RUnlock()
+	// The metrics of the target are sent once by cachedCollector for all its collectors.
+	config.OmitTargetMetrics = true
+	if useVault {
+		var err error
+		if config.SSHClientConfig, err = vc.sshConfig(ctx, target); err != nil {
+			level.Error(logger).Log("msg", "could not get credentials from vault", "target", target, "err", err)
+			return
+		}
+	}
+
+	lock := lockTarget(config.SSHTarget)
+	defer lock.Unlock()
+	// Polls are granted a slot after scrapes due before the end of the poll interval.
+	deadline, _ := ctx.Deadline()
+	if !acquireScrapeSlot(ctx, deadline) {
+		return
+	}
+	defer releaseScrapeSlot()
+
+	exporter, err := collector.NewExporter(ctx, []collector.Collector{col}, config, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "could not create collector", "err", err)
+		return
+	}
+	metrics := collectMetrics(exporter)
+
+	p.mu.Lock()
+	p.cache[col.Name()] = pollResult{metrics: metrics, polled: time.Now()}
+	p.mu.Unlock()
+}
+
+func (p *poller) stop() {
+	p.cancel()
+}
+
+// cachedCollector returns a prometheus.Collector serving the last polled metrics of the passed collectors, along with
+// the metrics of the target of config.
+func (p *poller) cachedCollector(collectors []collector.Collector, config collector.Config) prometheus.Collector {
+	return cachedCollector{poller: p, collectors: collectors, config: config}
+}
+
+type cachedCollector struct {
+	poller     *poller
+	collectors []collector.Collector
+	config     collector.Config
+}
+
+// Describe implemented as per the prometheus.Collector interface.
+func (c cachedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheAgeDesc
+}
+
+// Collect implemented as per the prometheus.Collector interface.
+func (c cachedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.poller.mu.Lock()
+	defer c.poller.mu.Unlock()
+	for _, col := range c.collectors {
+		result, ok := c.poller.cache[col.Name()]
+		if !ok {
+			continue
+		}
+		for _, metric := range result.metrics {
+			ch <- metric
+		}
+		ch <- prometheus.MustNewConstMetric(cacheAgeDesc, prometheus.GaugeValue, time.Since(result.polled).Seconds(), col.Name())
+	}
+	collector.CollectTarget(ch, c.config)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tynany/junos_exporter/config"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	remoteWriteSamples = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "junos",
+		Name:      "remote_write_samples_total",
+		Help:      "Total number of samples of polled targets sent using remote write.",
+	})
+	remoteWriteFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "junos",
+		Name:      "remote_write_failures_total",
+		Help:      "Total number of remote write requests that failed.",
+	})
+
+	// The remote writer started from the loaded configuration, nil when remote_write is not configured.
+	remoteWriter *remoteWrite
+)
+
+// remoteWrite pushes the last polled metrics of all targets polled in the background to a remote write endpoint on
+// an interval.
+type remoteWrite struct {
+	conf   config.RemoteWrite
+	client *http.Client
+	cancel context.CancelFunc
+}
+
+// label is a label of a remote write time series.
+type label struct {
+	name  string
+	value string
+}
+
+// timeSeries is a remote write time series with a single sample.
+type timeSeries struct {
+	labels []label
+	value  float64
+}
+
+// startRemoteWrite starts pushing the metrics of the pollers to the endpoint of conf.
+func startRemoteWrite(conf config.RemoteWrite, logger log.Logger) *remoteWrite {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &remoteWrite{
+		conf:   conf,
+		client: &http.Client{Timeout: time.Second * time.Duration(conf.Timeout)},
+		cancel: cancel,
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second * time.Duration(conf.Interval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.push(ctx); err != nil {
+					remoteWriteFailures.Inc()
+					level.Error(logger).Log("msg", "could not remote write metrics", "url", conf.URL, "err", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return w
+}
+
+func (w *remoteWrite) stop() {
+	w.cancel()
+}
+
+// push sends the last polled metrics of each polled target in a single remote write request.
+func (w *remoteWrite) push(ctx context.Context) error {
+	timestamp := time.Now().UnixMilli()
+	targets, err := gatherPolled()
+	if err != nil {
+		return err
+	}
+	var series []timeSeries
+	for _, target := range targets {
+		for _, family := range target.families {
+			series = appendTimeSeries(series, family, label{name: "job", value: w.conf.Job}, label{name: "instance", value: target.target})
+		}
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.conf.URL, bytes.NewReader(snappy.Encode(nil, encodeWriteRequest(series, timestamp))))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "junos_exporter")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for name, value := range w.conf.Headers {
+		req.Header.Set(name, value)
+	}
+	if w.conf.Username != "" {
+		req.SetBasicAuth(w.conf.Username, w.conf.Password)
+	} else if w.conf.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.conf.BearerToken)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	remoteWriteSamples.Add(float64(len(series)))
+	return nil
+}
+
+// polledTarget holds the last polled metrics of a target polled in the background.
+type polledTarget struct {
+	target string
+	// Labels of the config and target of the poller, which are also added to families.
+	labels   prometheus.Labels
+	families []*dto.MetricFamily
+}
+
+// gatherPolled returns the last polled metrics of each target polled in the background.
+func gatherPolled() ([]polledTarget, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	var targets []polledTarget
+	for key, p := range pollers {
+		labels := targetLabels(key.config, key.target)
+		registry := prometheus.NewRegistry()
+		if err := prometheus.WrapRegistererWith(labels, registry).Register(p.cachedCollector(configCollectors(key.config), collectorSettings(key.config, key.target))); err != nil {
+			return nil, fmt.Errorf("could not register metrics of target %q: %s", key.target, err)
+		}
+		families, err := registry.Gather()
+		if err != nil {
+			return nil, fmt.Errorf("could not gather metrics of target %q: %s", key.target, err)
+		}
+		targets = append(targets, polledTarget{target: key.target, labels: labels, families: families})
+	}
+	return targets, nil
+}
+
+// appendTimeSeries appends the time series of the samples of family to series with the extra labels, splitting
+// histograms and summaries into their buckets or quantiles, sum and count as in the text exposition format. As in a
+// Prometheus scrape, labels of the samples named the same as an extra label are renamed with the exported_ prefix.
+func appendTimeSeries(series []timeSeries, family *dto.MetricFamily, extra ...label) []timeSeries {
+	extraNames := map[string]bool{}
+	for _, l := range extra {
+		extraNames[l.name] = true
+	}
+	name := family.GetName()
+	for _, m := range family.GetMetric() {
+		add := func(name string, value float64, sampleLabels ...label) {
+			labels := []label{{name: "__name__", value: name}}
+			for _, l := range m.GetLabel() {
+				labelName := l.GetName()
+				for extraNames[labelName] {
+					labelName = "exported_" + labelName
+				}
+				labels = append(labels, label{name: labelName, value: l.GetValue()})
+			}
+			labels = append(labels, extra...)
+			labels = append(labels, sampleLabels...)
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].name < labels[j].name
+			})
+			series = append(series, timeSeries{labels: labels, value: value})
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			add(name, m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add(name, m.GetGauge().GetValue())
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			for _, b := range h.GetBucket() {
+				add(name+"_bucket", float64(b.GetCumulativeCount()), label{name: "le", value: strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)})
+			}
+			add(name+"_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+			add(name+"_sum", h.GetSampleSum())
+			add(name+"_count", float64(h.GetSampleCount()))
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				add(name, q.GetValue(), label{name: "quantile", value: strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)})
+			}
+			add(name+"_sum", s.GetSampleSum())
+			add(name+"_count", float64(s.GetSampleCount()))
+		default:
+			add(name, m.GetUntyped().GetValue())
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest encodes series as a remote write WriteRequest protobuf message, with each sample at timestamp in
+// milliseconds.
+func encodeWriteRequest(series []timeSeries, timestamp int64) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(timestamp))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
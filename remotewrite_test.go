@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAppendTimeSeries(t *testing.T) {
+	extra := []label{{name: "job", value: "junos"}, {name: "instance", value: "router1"}}
+	for _, tc := range []struct {
+		name   string
+		family *dto.MetricFamily
+		want   []timeSeries
+	}{
+		{
+			name: "gauge",
+			family: &dto.MetricFamily{
+				Name: proto.String("junos_interface_up"),
+				Type: dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{{
+					Label: []*dto.LabelPair{{Name: proto.String("interface"), Value: proto.String("ge-0/0/0")}},
+					Gauge: &dto.Gauge{Value: proto.Float64(1)},
+				}},
+			},
+			want: []timeSeries{{labels: []label{
+				{name: "__name__", value: "junos_interface_up"},
+				{name: "instance", value: "router1"},
+				{name: "interface", value: "ge-0/0/0"},
+				{name: "job", value: "junos"},
+			}, value: 1}},
+		},
+		{
+			name: "conflicting labels",
+			family: &dto.MetricFamily{
+				Name: proto.String("junos_info"),
+				Type: dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{{
+					Label: []*dto.LabelPair{
+						{Name: proto.String("instance"), Value: proto.String("VRF1")},
+						{Name: proto.String("job"), Value: proto.String("core")},
+					},
+					Gauge: &dto.Gauge{Value: proto.Float64(1)},
+				}},
+			},
+			want: []timeSeries{{labels: []label{
+				{name: "__name__", value: "junos_info"},
+				{name: "exported_instance", value: "VRF1"},
+				{name: "exported_job", value: "core"},
+				{name: "instance", value: "router1"},
+				{name: "job", value: "junos"},
+			}, value: 1}},
+		},
+		{
+			name: "histogram",
+			family: &dto.MetricFamily{
+				Name: proto.String("junos_rpc_duration_seconds"),
+				Type: dto.MetricType_HISTOGRAM.Enum(),
+				Metric: []*dto.Metric{{
+					Histogram: &dto.Histogram{
+						SampleCount: proto.Uint64(3),
+						SampleSum:   proto.Float64(1.5),
+						Bucket:      []*dto.Bucket{{UpperBound: proto.Float64(0.5), CumulativeCount: proto.Uint64(2)}},
+					},
+				}},
+			},
+			want: []timeSeries{
+				{labels: []label{{name: "__name__", value: "junos_rpc_duration_seconds_bucket"}, {name: "instance", value: "router1"}, {name: "job", value: "junos"}, {name: "le", value: "0.5"}}, value: 2},
+				{labels: []label{{name: "__name__", value: "junos_rpc_duration_seconds_bucket"}, {name: "instance", value: "router1"}, {name: "job", value: "junos"}, {name: "le", value: "+Inf"}}, value: 3},
+				{labels: []label{{name: "__name__", value: "junos_rpc_duration_seconds_sum"}, {name: "instance", value: "router1"}, {name: "job", value: "junos"}}, value: 1.5},
+				{labels: []label{{name: "__name__", value: "junos_rpc_duration_seconds_count"}, {name: "instance", value: "router1"}, {name: "job", value: "junos"}}, value: 3},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := appendTimeSeries(nil, tc.family, extra...); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/tynany/junos_exporter/collector"
+)
+
+// scrapeOnce scrapes the target using the config like a scrape by Prometheus, limited to collectorsParam when set, and
+// prints the metrics to stdout. It returns the exit code of the scrape command, which is 1 when a collector failed.
+func scrapeOnce(configParam string, targetParam string, collectorsParam []string, logger log.Logger) int {
+	configMu.RLock()
+	if err := validateRequest(configParam, targetParam); err != nil {
+		configMu.RUnlock()
+		level.Error(logger).Log("err", err)
+		return 2
+	}
+	var err error
+	enabledCollectors := configCollectors(configParam)
+	if len(collectorsParam) > 0 {
+		if enabledCollectors, err = selectCollectors(enabledCollectors, collectorsParam); err != nil {
+			configMu.RUnlock()
+			level.Error(logger).Log("err", err)
+			return 2
+		}
+	}
+	config := collectorSettings(configParam, targetParam)
+	vc, useVault := vaultClients[configParam]
+	labels := targetLabels(configParam, targetParam)
+	configMu.RUnlock()
+
+	ctx := context.Background()
+	if useVault {
+		if config.SSHClientConfig, err = vc.sshConfig(ctx, targetParam); err != nil {
+			level.Error(logger).Log("msg", "could not get credentials from vault", "target", targetParam, "err", err)
+			return 1
+		}
+	}
+
+	nc, err := collector.NewExporter(ctx, enabledCollectors, config, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "could not create collector", "err", err)
+		return 1
+	}
+	registry := prometheus.NewRegistry()
+	if err := prometheus.WrapRegistererWith(labels, registry).Register(nc); err != nil {
+		level.Error(logger).Log("msg", "could not register collector", "err", err)
+		return 1
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		level.Error(logger).Log("msg", "could not gather metrics", "err", err)
+	}
+
+	code := 0
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(os.Stdout, family); err != nil {
+			level.Error(logger).Log("msg", "could not write metrics", "err", err)
+			return 1
+		}
+		if family.GetName() != "junos_collector_up" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetGauge().GetValue() == 0 {
+				code = 1
+			}
+		}
+	}
+	if code != 0 {
+		fmt.Fprintln(os.Stderr, "one or more collectors failed, see the errors logged above")
+	}
+	return code
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tynany/junos_exporter/collector"
+)
+
+var (
+	// Metrics of recent scrapes (value) per config, target and collectors (key), served until they expire.
+	scrapeCache   = map[scrapeCacheKey]scrapeCacheEntry{}
+	scrapeCacheMu sync.Mutex
+)
+
+type scrapeCacheKey struct {
+	config     string
+	module     string
+	target     string
+	collectors string
+}
+
+type scrapeCacheEntry struct {
+	metrics metricSet
+	expires time.Time
+}
+
+func newScrapeCacheKey(configName string, moduleName string, target string, collectors []collector.Collector) scrapeCacheKey {
+	var names []string
+	for _, c := range collectors {
+		names = append(names, c.Name())
+	}
+	sort.Strings(names)
+	return scrapeCacheKey{config: configName, module: moduleName, target: target, collectors: strings.Join(names, ",")}
+}
+
+func cachedScrape(key scrapeCacheKey) (metricSet, bool) {
+	scrapeCacheMu.Lock()
+	defer scrapeCacheMu.Unlock()
+	entry, ok := scrapeCache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.metrics, true
+}
+
+func cacheScrape(key scrapeCacheKey, metrics metricSet, ttl time.Duration) {
+	scrapeCacheMu.Lock()
+	defer scrapeCacheMu.Unlock()
+	now := time.Now()
+	for k, entry := range scrapeCache {
+		if now.After(entry.expires) {
+			delete(scrapeCache, k)
+		}
+	}
+	scrapeCache[key] = scrapeCacheEntry{metrics: metrics, expires: now.Add(ttl)}
+}
+
+// metricSet is a set of collected metrics, implemented as per the prometheus.Collector interface.
+type metricSet []prometheus.Metric
+
+// collectMetrics returns the metrics collected by c.
+func collectMetrics(c prometheus.Collector) metricSet {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	var metrics metricSet
+	for metric := range ch {
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// Describe implemented as per the prometheus.Collector interface. The metrics of a metricSet are unchecked.
+func (m metricSet) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implemented as per the prometheus.Collector interface.
+func (m metricSet) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range m {
+		ch <- metric
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Timeout of scrapes without the X-Prometheus-Scrape-Timeout-Seconds header, such as scrapes by hand, which is the
+// default scrape timeout of Prometheus.
+const defaultScrapeTimeout = 10 * time.Second
+
+var (
+	scrapeQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "junos",
+		Name:      "scrape_queue_length",
+		Help:      "Number of scrapes waiting for a free scrape slot.",
+	})
+	scrapeQueueWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "junos",
+		Name:      "scrape_queue_wait_seconds",
+		Help:      "Time scrapes waited for a free scrape slot.",
+		Buckets:   []float64{0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+	})
+	scrapeQueueRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "junos",
+		Name:      "scrape_queue_rejected_total",
+		Help:      "Total number of scrapes given up while waiting for a free scrape slot.",
+	})
+)
+
+// scrapeTimeout returns the scrape timeout of Prometheus from the X-Prometheus-Scrape-Timeout-Seconds header of r,
+// false when the header is not set.
+func scrapeTimeout(r *http.Request) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// scrapeDeadline returns the time by which Prometheus gives up on the scrape r started at start, using
+// defaultScrapeTimeout when the scrape timeout is not set.
+func scrapeDeadline(r *http.Request, start time.Time) time.Time {
+	timeout, ok := scrapeTimeout(r)
+	if !ok {
+		timeout = defaultScrapeTimeout
+	}
+	return start.Add(timeout)
+}
+
+// scrapeQueue limits the number of targets scraped at the same time. Free slots are granted to the waiting scrape with
+// the earliest deadline first, rather than in the order the scrapes arrived.
+type scrapeQueue struct {
+	mu      sync.Mutex
+	free    int
+	waiting scrapeWaiters
+	// Maximum time a scrape waits for a slot, no limit when 0.
+	maxWait time.Duration
+}
+
+func newScrapeQueue(slots int, maxWait time.Duration) *scrapeQueue {
+	return &scrapeQueue{free: slots, maxWait: maxWait}
+}
+
+// acquire waits for a free slot, returning false when ctx is done or maxWait elapsed first.
+func (q *scrapeQueue) acquire(ctx context.Context, deadline time.Time) bool {
+	start := time.Now()
+	q.mu.Lock()
+	if q.free > 0 && len(q.waiting) == 0 {
+		q.free--
+		q.mu.Unlock()
+		scrapeQueueWait.Observe(0)
+		return true
+	}
+	w := &scrapeWaiter{deadline: deadline, ready: make(chan struct{})}
+	heap.Push(&q.waiting, w)
+	scrapeQueueLength.Set(float64(len(q.waiting)))
+	q.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if q.maxWait > 0 {
+		timer := time.NewTimer(q.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-w.ready:
+		scrapeQueueWait.Observe(time.Since(start).Seconds())
+		return true
+	case <-ctx.Done():
+	case <-timeout:
+	}
+
+	q.mu.Lock()
+	if w.index >= 0 {
+		heap.Remove(&q.waiting, w.index)
+		scrapeQueueLength.Set(float64(len(q.waiting)))
+		q.mu.Unlock()
+	} else {
+		// The slot was granted while giving up, pass it on.
+		q.mu.Unlock()
+		q.release()
+	}
+	scrapeQueueRejected.Inc()
+	return false
+}
+
+// release frees a slot, granting it to the waiting scrape with the earliest deadline.
+func (q *scrapeQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) == 0 {
+		q.free++
+		return
+	}
+	w := heap.Pop(&q.waiting).(*scrapeWaiter)
+	scrapeQueueLength.Set(float64(len(q.waiting)))
+	close(w.ready)
+}
+
+// scrapeWaiter is a scrape waiting for a slot, of which ready is closed once it is granted one.
+type scrapeWaiter struct {
+	deadline time.Time
+	ready    chan struct{}
+	// Index in the heap, -1 once removed.
+	index int
+}
+
+// scrapeWaiters is a heap of the waiting scrapes ordered by deadline, implemented as per the heap.Interface interface.
+type scrapeWaiters []*scrapeWaiter
+
+func (s scrapeWaiters) Len() int           { return len(s) }
+func (s scrapeWaiters) Less(i, j int) bool { return s[i].deadline.Before(s[j].deadline) }
+
+func (s scrapeWaiters) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+	s[i].index = i
+	s[j].index = j
+}
+
+func (s *scrapeWaiters) Push(x any) {
+	w := x.(*scrapeWaiter)
+	w.index = len(*s)
+	*s = append(*s, w)
+}
+
+func (s *scrapeWaiters) Pop() any {
+	old := *s
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*s = old[:len(old)-1]
+	return w
+}
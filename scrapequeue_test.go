@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// waitQueued waits until n scrapes are waiting in q.
+func waitQueued(t *testing.T, q *scrapeQueue, n int) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		q.mu.Lock()
+		queued := len(q.waiting)
+		q.mu.Unlock()
+		if queued == n {
+			return
+		}
+	}
+	t.Fatalf("%d scrapes did not queue", n)
+}
+
+func TestScrapeQueueEarliestDeadlineFirst(t *testing.T) {
+	q := newScrapeQueue(1, 0)
+	now := time.Now()
+	if !q.acquire(context.Background(), now) {
+		t.Fatal("could not acquire free slot")
+	}
+
+	granted := make(chan int)
+	for i, seconds := range []int{30, 10, 20} {
+		go func(i int, deadline time.Time) {
+			if q.acquire(context.Background(), deadline) {
+				granted <- i
+			}
+		}(i, now.Add(time.Duration(seconds)*time.Second))
+		waitQueued(t, q, i+1)
+	}
+
+	for _, want := range []int{1, 2, 0} {
+		q.release()
+		if got := <-granted; got != want {
+			t.Errorf("slot granted to scrape %d, want %d", got, want)
+		}
+	}
+	q.release()
+	if q.free != 1 {
+		t.Errorf("%d free slots, want 1", q.free)
+	}
+}
+
+func TestScrapeQueueGiveUp(t *testing.T) {
+	q := newScrapeQueue(1, 20*time.Millisecond)
+	if !q.acquire(context.Background(), time.Now()) {
+		t.Fatal("could not acquire free slot")
+	}
+	if q.acquire(context.Background(), time.Now()) {
+		t.Error("acquired slot beyond the limit, want maximum wait exceeded")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan bool)
+	go func() {
+		result <- q.acquire(ctx, time.Now())
+	}()
+	waitQueued(t, q, 1)
+	cancel()
+	if <-result {
+		t.Error("acquired slot after the context was canceled")
+	}
+	waitQueued(t, q, 0)
+
+	q.release()
+	if !q.acquire(context.Background(), time.Now()) {
+		t.Error("released slot was not freed")
+	}
+}
+
+func TestScrapeTimeout(t *testing.T) {
+	for header, want := range map[string]time.Duration{
+		"":     0,
+		"abc":  0,
+		"-1":   0,
+		"10":   10 * time.Second,
+		"9.5":  9500 * time.Millisecond,
+		"0.25": 250 * time.Millisecond,
+	} {
+		r, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", header)
+		}
+		got, ok := scrapeTimeout(r)
+		if got != want || ok != (want > 0) {
+			t.Errorf("scrapeTimeout(%q) = %s, %t, want %s", header, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/activation"
+	"github.com/coreos/go-systemd/v22/daemon"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mdlayher/vsock"
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+// listen returns the listeners of the web server on the addresses of flags, or the systemd socket activated listeners
+// when enabled, as web.ListenAndServe does. The listeners are created before systemd is notified that the exporter
+// started, so that it accepts connections once systemd reports it ready.
+func listen(flags *web.FlagConfig, logger log.Logger) ([]net.Listener, error) {
+	if flags.WebSystemdSocket != nil && *flags.WebSystemdSocket {
+		level.Info(logger).Log("msg", "Listening on systemd activated listeners instead of port listeners.")
+		listeners, err := activation.Listeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(listeners) < 1 {
+			return nil, errors.New("no socket activation file descriptors found")
+		}
+		return listeners, nil
+	}
+	if flags.WebListenAddresses == nil || len(*flags.WebListenAddresses) == 0 {
+		return nil, web.ErrNoListeners
+	}
+
+	var listeners []net.Listener
+	for _, address := range *flags.WebListenAddresses {
+		var listener net.Listener
+		var err error
+		if strings.HasPrefix(address, "vsock://") {
+			var port uint32
+			if port, err = vsockPort(address); err == nil {
+				listener, err = vsock.Listen(port, nil)
+			}
+		} else {
+			listener, err = net.Listen("tcp", address)
+		}
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// vsockPort returns the port of a vsock://:{port} address.
+func vsockPort(address string) (uint32, error) {
+	uri, err := url.Parse(address)
+	if err != nil {
+		return 0, err
+	}
+	_, port, err := net.SplitHostPort(uri.Host)
+	if err != nil {
+		return 0, err
+	}
+	p, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(p), nil
+}
+
+// notifySystemd signals systemd that the exporter started when it runs as a Type=notify service. When WatchdogSec is
+// set on the service, the systemd watchdog is notified at half its interval as long as the exporter is healthy, so
+// that systemd restarts an exporter that is stuck while its process is still running.
+func notifySystemd(logger log.Logger) {
+	sent, err := daemon.SdNotify(false, daemon.SdNotifyReady)
+	if err != nil {
+		level.Warn(logger).Log("msg", "could not notify systemd", "err", err)
+		return
+	}
+	if !sent {
+		// Not started by systemd as a Type=notify service.
+		return
+	}
+
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		level.Warn(logger).Log("msg", "could not get the systemd watchdog interval", "err", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	level.Info(logger).Log("msg", "Notifying the systemd watchdog", "interval", interval/2)
+	go func() {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := checkHealth(); err != nil {
+				level.Error(logger).Log("msg", "exporter unhealthy, systemd watchdog not notified", "err", err)
+				continue
+			}
+			if _, err := daemon.SdNotify(false, daemon.SdNotifyWatchdog); err != nil {
+				level.Warn(logger).Log("msg", "could not notify the systemd watchdog", "err", err)
+			}
+		}
+	}()
+}
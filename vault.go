@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/tynany/junos_exporter/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// vaultClient fetches device credentials from HashiCorp Vault and caches the resulting SSH configuration per target.
+type vaultClient struct {
+	client *http.Client
+	conf   config.Vault
+	base   *ssh.ClientConfig
+	signer ssh.Signer
+	ttl    time.Duration
+	logger log.Logger
+
+	mu      sync.Mutex
+	targets map[string]*vaultTarget
+	done    chan struct{}
+}
+
+// vaultTarget holds the SSH configuration of a target, which is kept when its credentials are refreshed so that the
+// sessions established with it are identified the same. Its authentication methods use the last fetched credentials,
+// guarded by the mutex of the vaultClient.
+type vaultTarget struct {
+	config  *ssh.ClientConfig
+	expires time.Time
+	// Signer of the signed certificate, nil when not signing keys.
+	signer   ssh.Signer
+	password string
+}
+
+// vaultResponse is the part of a Vault API response used by the exporter.
+type vaultResponse struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		LeaseDuration int  `json:"lease_duration"`
+		Renewable     bool `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func newVaultClient(conf config.Vault, base *ssh.ClientConfig, signer ssh.Signer, logger log.Logger) *vaultClient {
+	ttl := time.Duration(conf.CacheTTL) * time.Second
+	if ttl == 0 {
+		ttl = 5 * time.Minute
+	}
+	return &vaultClient{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		conf:    conf,
+		base:    base,
+		signer:  signer,
+		ttl:     ttl,
+		logger:  logger,
+		targets: map[string]*vaultTarget{},
+		done:    make(chan struct{}),
+	}
+}
+
+// newTarget returns a vaultTarget without credentials, of which the SSH configuration authenticates with the signed
+// certificate or password of the last refresh.
+func (v *vaultClient) newTarget() *vaultTarget {
+	t := &vaultTarget{}
+	sshConfig := *v.base
+	sshConfig.Auth = nil
+	if v.conf.SSHSignPath != "" {
+		sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			if t.signer == nil {
+				return nil, nil
+			}
+			return []ssh.Signer{t.signer}, nil
+		}))
+	}
+	if v.conf.SecretPath != "" {
+		sshConfig.Auth = append(sshConfig.Auth, ssh.PasswordCallback(func() (string, error) {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			if t.password == "" {
+				return "", fmt.Errorf("no password found in vault")
+			}
+			return t.password, nil
+		}))
+	}
+	t.config = &sshConfig
+	return t
+}
+
+// sshConfig returns the SSH configuration of target using the credentials stored in Vault. The same configuration is
+// returned across refreshes of the credentials, unless the username stored in Vault changed.
+func (v *vaultClient) sshConfig(ctx context.Context, target string) (*ssh.ClientConfig, error) {
+	v.mu.Lock()
+	t, ok := v.targets[target]
+	if !ok {
+		t = v.newTarget()
+		v.targets[target] = t
+	}
+	sshConfig, expires := t.config, t.expires
+	v.mu.Unlock()
+	if time.Now().Before(expires) {
+		return sshConfig, nil
+	}
+
+	var signer ssh.Signer
+	var username, password string
+	expires = time.Now().Add(v.ttl)
+
+	if v.conf.SSHSignPath != "" {
+		resp, err := v.request(ctx, http.MethodPost, expandVaultPath(v.conf.SSHSignPath, target), map[string]string{
+			"public_key": string(ssh.MarshalAuthorizedKey(v.signer.PublicKey())),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not sign ssh key: %s", err)
+		}
+		signedKey, _ := resp.Data["signed_key"].(string)
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signedKey))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse signed ssh key: %s", err)
+		}
+		cert, ok := pub.(*ssh.Certificate)
+		if !ok {
+			return nil, fmt.Errorf("signed ssh key is not a certificate")
+		}
+		if signer, err = ssh.NewCertSigner(cert, v.signer); err != nil {
+			return nil, err
+		}
+		// Renew the certificate before it expires.
+		if cert.ValidBefore != ssh.CertTimeInfinity {
+			validBefore := time.Unix(int64(cert.ValidBefore), 0)
+			if renew := validBefore.Add(-time.Until(validBefore) / 5); renew.Before(expires) {
+				expires = renew
+			}
+		}
+	}
+
+	if v.conf.SecretPath != "" {
+		resp, err := v.request(ctx, http.MethodGet, expandVaultPath(v.conf.SecretPath, target), nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not read secret: %s", err)
+		}
+		data := resp.Data
+		// Secrets of the KV version 2 engine are nested under data.
+		if nested, ok := data["data"].(map[string]interface{}); ok {
+			data = nested
+		}
+		username, _ = data["username"].(string)
+		password, _ = data["password"].(string)
+		if resp.LeaseDuration > 0 {
+			if leaseExpires := time.Now().Add(time.Duration(resp.LeaseDuration) * time.Second); leaseExpires.Before(expires) {
+				expires = leaseExpires
+			}
+		}
+	}
+
+	if signer == nil && password == "" {
+		return nil, fmt.Errorf("no credentials found in vault for %q", target)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	t.signer, t.password, t.expires = signer, password, expires
+	if username != "" && username != t.config.User {
+		// The user is read when dialing, so the configuration is replaced rather than changed in place.
+		userConfig := *t.config
+		userConfig.User = username
+		t.config = &userConfig
+	}
+	return t.config, nil
+}
+
+// renewToken periodically renews the Vault token when it is renewable.
+func (v *vaultClient) renewToken() {
+	for {
+		resp, err := v.request(context.Background(), http.MethodPost, "auth/token/renew-self", map[string]string{})
+		interval := time.Minute
+		if err != nil {
+			level.Error(v.logger).Log("msg", "could not renew vault token", "err", err)
+		} else if resp.Auth == nil || !resp.Auth.Renewable || resp.Auth.LeaseDuration == 0 {
+			return
+		} else {
+			interval = time.Duration(resp.Auth.LeaseDuration) * time.Second / 2
+		}
+		select {
+		case <-time.After(interval):
+		case <-v.done:
+			return
+		}
+	}
+}
+
+// stop stops the renewal of the Vault token.
+func (v *vaultClient) stop() {
+	close(v.done)
+}
+
+func (v *vaultClient) request(ctx context.Context, method string, path string, body interface{}) (*vaultResponse, error) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+	url := strings.TrimSuffix(v.conf.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, url, &reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.conf.Token)
+	if v.conf.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.conf.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var vaultResp vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vaultResp); err != nil {
+		return nil, fmt.Errorf("could not decode vault response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned %q: %s", resp.Status, strings.Join(vaultResp.Errors, ", "))
+	}
+	return &vaultResp, nil
+}
+
+// expandVaultPath replaces {target} in path with the target.
+func expandVaultPath(path string, target string) string {
+	return strings.ReplaceAll(path, "{target}", target)
+}